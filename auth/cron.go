@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CronSecretMiddleware authenticates Vercel Cron invocations, which carry
+// "Authorization: Bearer <CRON_SECRET>". Without a secret every request is rejected.
+func CronSecretMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Cron secret not configured",
+			})
+			c.Abort()
+			return
+		}
+
+		expected := "Bearer " + secret
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte(expected)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid cron secret",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronSecretMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		secret        string
+		authorization string
+		status        int
+	}{
+		{"matching secret", "cron-secret", "Bearer cron-secret", http.StatusOK},
+		{"wrong secret", "cron-secret", "Bearer guess", http.StatusUnauthorized},
+		{"missing header", "cron-secret", "", http.StatusUnauthorized},
+		{"no secret configured", "", "Bearer ", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/cron", CronSecretMiddleware(tt.secret), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/cron", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
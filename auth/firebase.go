@@ -2,8 +2,7 @@ package auth
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
@@ -11,16 +10,14 @@ import (
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 	"google.golang.org/api/option"
 )
 
 var firebaseAuth *auth.Client
-var jwtSecretKey []byte
 
 // InitFirebase initializes Firebase connection
 func InitFirebase() {
-	log.Println("🔥 Initializing Firebase Auth...")
+	slog.Info("Initializing Firebase Auth")
 	
 	ctx := context.Background()
 	var opt option.ClientOption
@@ -32,7 +29,7 @@ func InitFirebase() {
 		// Fallback to file for local development
 		credentialsPath := "auth/firebase_credentials.json"
 		if _, err := os.Stat(credentialsPath); os.IsNotExist(err) {
-			log.Printf("⚠️ No Firebase Auth credentials found, authentication will be disabled")
+			slog.Warn("No Firebase Auth credentials found, authentication will be disabled")
 			return
 		}
 		opt = option.WithCredentialsFile(credentialsPath)
@@ -41,25 +38,23 @@ func InitFirebase() {
 	// Initialize Firebase app
 	app, err := firebase.NewApp(ctx, nil, opt)
 	if err != nil {
-		log.Printf("⚠️ Failed to initialize Firebase app: %v", err)
+		slog.Error("Failed to initialize Firebase app", "error", err)
 		return
 	}
 
 	// Initialize Firebase Auth client
 	firebaseAuth, err = app.Auth(ctx)
 	if err != nil {
-		log.Printf("⚠️ Failed to initialize Firebase Auth client: %v", err)
+		slog.Error("Failed to initialize Firebase Auth client", "error", err)
 		return
 	}
 
-	// Initialize JWT secret
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-default-secret-key" // Change this in production
-	}
-	jwtSecretKey = []byte(jwtSecret)
+	slog.Info("Firebase Auth initialized")
+}
 
-	log.Println("✅ Firebase Auth initialized successfully")
+// Initialized reports whether InitFirebase set up the Firebase Auth client
+func Initialized() bool {
+	return firebaseAuth != nil
 }
 
 // FirebaseAuthMiddleware validates Firebase tokens
@@ -111,34 +106,3 @@ func FirebaseAuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	})
 }
-
-// GenerateJWT generates a JWT token for internal service communication
-func GenerateJWT(userID string, claims map[string]interface{}) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": userID,
-		"claims": claims,
-		"exp":    jwt.TimeFunc().Add(24 * 60 * 60 * 1000).Unix(), // 24 hours
-	})
-
-	return token.SignedString(jwtSecretKey)
-}
-
-// ValidateJWT validates a JWT token for internal service communication
-func ValidateJWT(tokenString string) (*jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtSecretKey, nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return &claims, nil
-	}
-
-	return nil, fmt.Errorf("invalid token")
-}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRoleRouter serves GET /protected behind RequireRoles, with the claims FirebaseAuthMiddleware would set
+func newRoleRouter(userID string, claims map[string]interface{}, roles ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/protected", func(c *gin.Context) {
+		if userID != "" {
+			c.Set("userID", userID)
+			c.Set("userClaims", claims)
+		}
+	}, RequireRoles(roles...), func(c *gin.Context) {
+		granted, _ := c.Get("userRoles")
+		c.JSON(http.StatusOK, gin.H{"roles": granted})
+	})
+	return router
+}
+
+func TestRolesFromClaims(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   map[string]interface{}
+		expected []string
+	}{
+		{"token claims", map[string]interface{}{"roles": []interface{}{"ops", "finance"}}, []string{"finance", "ops"}},
+		{"custom claims", map[string]interface{}{"roles": []string{"support"}}, []string{"support"}},
+		{"unknown and duplicate roles", map[string]interface{}{"roles": []interface{}{"ops", "superuser", "ops", 7}}, []string{"ops"}},
+		{"no roles claim", map[string]interface{}{"admin": true}, nil},
+		{"nil claims", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RolesFromClaims(tt.claims))
+		})
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   string
+		claims   map[string]interface{}
+		required []string
+		status   int
+	}{
+		{"matching role", "uid_ops", map[string]interface{}{"roles": []interface{}{"ops"}}, []string{RoleOps}, http.StatusOK},
+		{"one of several roles", "uid_support", map[string]interface{}{"roles": []interface{}{"support"}}, []string{RoleFinance, RoleSupport}, http.StatusOK},
+		{"admin passes every check", "uid_admin", map[string]interface{}{"roles": []interface{}{"admin"}}, []string{RoleFinance}, http.StatusOK},
+		{"wrong role", "uid_support", map[string]interface{}{"roles": []interface{}{"support"}}, []string{RoleFinance}, http.StatusForbidden},
+		{"signed-in player without roles", "uid_player", map[string]interface{}{}, []string{RoleOps}, http.StatusForbidden},
+		{"not signed in", "", nil, []string{RoleOps}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRoleRouter(tt.userID, tt.claims, tt.required...)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protected", nil))
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestSetUserRolesRequiresFirebase(t *testing.T) {
+	err := SetUserRoles(context.Background(), "uid_1", []string{RoleOps})
+	assert.Error(t, err)
+
+	_, err = GetUserRoles(context.Background(), "uid_1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// Service token validation errors
+var (
+	ErrServiceTokenInvalid  = errors.New("invalid service token")
+	ErrServiceTokenReplayed = errors.New("service token already used")
+)
+
+// minServiceKeyLength is the shortest HMAC secret accepted for service tokens
+const minServiceKeyLength = 32
+
+// serviceTokenLeeway tolerates clock skew between services
+const serviceTokenLeeway = 30 * time.Second
+
+// ServiceTokenConfig configures the signed tokens other GoalHero services use on the internal endpoints
+type ServiceTokenConfig struct {
+	Keys           map[string]string // Key ID -> HMAC secret. Every key verifies, so keys can be rotated.
+	SigningKeyID   string            // Key used for tokens this service issues
+	Audience       string            // Required aud claim, naming this service
+	TrustedIssuers []string          // Services allowed to call the internal endpoints
+	MaxTTL         time.Duration     // Longest accepted exp - iat
+}
+
+// ServiceClaims are the claims of a service token. The issuer names the calling service.
+type ServiceClaims struct {
+	jwt.RegisteredClaims
+}
+
+// ServiceTokens issues and verifies short-lived HS256 service tokens. Each token must carry a
+// kid header naming one of the configured keys and a unique jti, which is accepted only once.
+type ServiceTokens struct {
+	conf ServiceTokenConfig
+	now  func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time // jti -> expiry, for replay protection
+}
+
+var serviceTokens *ServiceTokens
+
+// NewServiceTokens validates conf and returns the token issuer/verifier
+func NewServiceTokens(conf ServiceTokenConfig) (*ServiceTokens, error) {
+	if len(conf.Keys) == 0 {
+		return nil, fmt.Errorf("no service token keys configured")
+	}
+	for kid, secret := range conf.Keys {
+		if len(secret) < minServiceKeyLength {
+			return nil, fmt.Errorf("service token key %q is shorter than %d bytes", kid, minServiceKeyLength)
+		}
+	}
+	if conf.SigningKeyID != "" {
+		if _, exists := conf.Keys[conf.SigningKeyID]; !exists {
+			return nil, fmt.Errorf("signing key %q is not one of the configured service token keys", conf.SigningKeyID)
+		}
+	}
+	if conf.Audience == "" {
+		return nil, fmt.Errorf("service token audience is required")
+	}
+	if len(conf.TrustedIssuers) == 0 {
+		return nil, fmt.Errorf("at least one trusted service token issuer is required")
+	}
+	if conf.MaxTTL <= 0 {
+		return nil, fmt.Errorf("service token max TTL must be positive")
+	}
+
+	return &ServiceTokens{conf: conf, now: time.Now, seen: make(map[string]time.Time)}, nil
+}
+
+// InitServiceAuth sets up service token verification for ServiceTokenMiddleware. Without keys the
+// internal endpoints reject every request, and in production the service refuses to start.
+func InitServiceAuth(conf ServiceTokenConfig, production bool) error {
+	if len(conf.Keys) == 0 {
+		if production {
+			return fmt.Errorf("SERVICE_TOKEN_KEYS must be set in production")
+		}
+		slog.Warn("No service token keys configured, internal endpoints will reject all requests")
+		serviceTokens = nil
+		return nil
+	}
+
+	tokens, err := NewServiceTokens(conf)
+	if err != nil {
+		return err
+	}
+	serviceTokens = tokens
+	slog.Info("Service token auth initialized", "key_ids", len(conf.Keys), "trusted_issuers", conf.TrustedIssuers)
+	return nil
+}
+
+// Issue signs a token from the issuer service to the audience service, valid for ttl
+func (s *ServiceTokens) Issue(issuer, audience string, ttl time.Duration) (string, error) {
+	if s.conf.SigningKeyID == "" {
+		return "", fmt.Errorf("no service token signing key configured")
+	}
+
+	now := s.now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, ServiceClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			ID:        uuid.NewString(),
+		},
+	})
+	token.Header["kid"] = s.conf.SigningKeyID
+	return token.SignedString([]byte(s.conf.Keys[s.conf.SigningKeyID]))
+}
+
+// Verify checks the token's signature, audience, issuer and lifetime, and records its jti so the
+// same token can't be used twice
+func (s *ServiceTokens) Verify(tokenString string) (*ServiceClaims, error) {
+	claims := &ServiceClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		secret, exists := s.conf.Keys[kid]
+		if !exists {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrServiceTokenInvalid, err)
+	}
+
+	now := s.now()
+	switch {
+	case claims.IssuedAt == nil || claims.ExpiresAt == nil:
+		return nil, fmt.Errorf("%w: iat and exp are required", ErrServiceTokenInvalid)
+	case claims.ExpiresAt.Sub(claims.IssuedAt.Time) > s.conf.MaxTTL:
+		return nil, fmt.Errorf("%w: lifetime exceeds %s", ErrServiceTokenInvalid, s.conf.MaxTTL)
+	case now.After(claims.ExpiresAt.Add(serviceTokenLeeway)):
+		return nil, fmt.Errorf("%w: token expired", ErrServiceTokenInvalid)
+	case claims.IssuedAt.After(now.Add(serviceTokenLeeway)):
+		return nil, fmt.Errorf("%w: token issued in the future", ErrServiceTokenInvalid)
+	case claims.NotBefore != nil && claims.NotBefore.After(now.Add(serviceTokenLeeway)):
+		return nil, fmt.Errorf("%w: token not yet valid", ErrServiceTokenInvalid)
+	case !claims.VerifyAudience(s.conf.Audience, true):
+		return nil, fmt.Errorf("%w: wrong audience", ErrServiceTokenInvalid)
+	case !slices.Contains(s.conf.TrustedIssuers, claims.Issuer):
+		return nil, fmt.Errorf("%w: untrusted issuer %q", ErrServiceTokenInvalid, claims.Issuer)
+	case claims.ID == "":
+		return nil, fmt.Errorf("%w: jti is required", ErrServiceTokenInvalid)
+	}
+
+	if !s.markUsed(claims.ID, claims.ExpiresAt.Add(serviceTokenLeeway), now) {
+		return nil, ErrServiceTokenReplayed
+	}
+	return claims, nil
+}
+
+// markUsed records jti until expiry, returning false if it was already recorded. Expired entries
+// are dropped as new ones arrive, so the cache holds at most MaxTTL worth of tokens. The cache is
+// per instance; the short MaxTTL bounds what a replay against another instance could do.
+func (s *ServiceTokens) markUsed(jti string, expiry, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, exp := range s.seen {
+		if now.After(exp) {
+			delete(s.seen, id)
+		}
+	}
+	if _, used := s.seen[jti]; used {
+		return false
+	}
+	s.seen[jti] = expiry
+	return true
+}
+
+// ServiceTokenMiddleware authenticates internal service-to-service requests with a service token
+// in the Authorization header. The calling service is stored in the gin context as "serviceID".
+func ServiceTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if serviceTokens == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Service authentication not configured",
+			})
+			c.Abort()
+			return
+		}
+
+		tokenString, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || tokenString == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Service token required",
+			})
+			c.Abort()
+			return
+		}
+
+		claims, err := serviceTokens.Verify(tokenString)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Service token rejected", "route", c.FullPath(), "error", err)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid service token",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("serviceID", claims.Issuer)
+		c.Next()
+	}
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKeyOld = "old-secret-0123456789abcdefghijklmnop"
+	testKeyNew = "new-secret-0123456789abcdefghijklmnop"
+)
+
+func newTestServiceTokens(t *testing.T, signingKeyID string) *ServiceTokens {
+	t.Helper()
+	tokens, err := NewServiceTokens(ServiceTokenConfig{
+		Keys:           map[string]string{"k1": testKeyOld, "k2": testKeyNew},
+		SigningKeyID:   signingKeyID,
+		Audience:       "goalhero-payment-jobs",
+		TrustedIssuers: []string{"goalhero-api"},
+		MaxTTL:         5 * time.Minute,
+	})
+	require.NoError(t, err)
+	return tokens
+}
+
+// signClaims signs arbitrary claims with one of the test keys
+func signClaims(t *testing.T, kid, secret string, claims jwt.RegisteredClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString([]byte(secret))
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims(now time.Time) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    "goalhero-api",
+		Audience:  jwt.ClaimStrings{"goalhero-payment-jobs"},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		ID:        "jti-1",
+	}
+}
+
+func TestServiceTokensVerify(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		token  func(t *testing.T) string
+		errMsg string
+	}{
+		{"valid token", func(t *testing.T) string {
+			return signClaims(t, "k1", testKeyOld, validClaims(now))
+		}, ""},
+		{"unknown key id", func(t *testing.T) string {
+			return signClaims(t, "k9", testKeyOld, validClaims(now))
+		}, "unknown key id"},
+		{"wrong secret for key id", func(t *testing.T) string {
+			return signClaims(t, "k2", testKeyOld, validClaims(now))
+		}, "signature is invalid"},
+		{"wrong audience", func(t *testing.T) string {
+			claims := validClaims(now)
+			claims.Audience = jwt.ClaimStrings{"goalhero-api"}
+			return signClaims(t, "k1", testKeyOld, claims)
+		}, "wrong audience"},
+		{"untrusted issuer", func(t *testing.T) string {
+			claims := validClaims(now)
+			claims.Issuer = "someone-else"
+			return signClaims(t, "k1", testKeyOld, claims)
+		}, "untrusted issuer"},
+		{"expired", func(t *testing.T) string {
+			claims := validClaims(now.Add(-10 * time.Minute))
+			return signClaims(t, "k1", testKeyOld, claims)
+		}, "expired"},
+		{"lifetime too long", func(t *testing.T) string {
+			claims := validClaims(now)
+			claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Hour))
+			return signClaims(t, "k1", testKeyOld, claims)
+		}, "lifetime exceeds"},
+		{"missing exp", func(t *testing.T) string {
+			claims := validClaims(now)
+			claims.ExpiresAt = nil
+			return signClaims(t, "k1", testKeyOld, claims)
+		}, "iat and exp are required"},
+		{"missing jti", func(t *testing.T) string {
+			claims := validClaims(now)
+			claims.ID = ""
+			return signClaims(t, "k1", testKeyOld, claims)
+		}, "jti is required"},
+		{"none algorithm", func(t *testing.T) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims(now))
+			token.Header["kid"] = "k1"
+			signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			require.NoError(t, err)
+			return signed
+		}, "signing method none is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := newTestServiceTokens(t, "")
+			claims, err := tokens.Verify(tt.token(t))
+
+			if tt.errMsg == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "goalhero-api", claims.Issuer)
+				return
+			}
+			require.ErrorIs(t, err, ErrServiceTokenInvalid)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestServiceTokensRejectReplay(t *testing.T) {
+	tokens := newTestServiceTokens(t, "k2")
+	token, err := tokens.Issue("goalhero-api", "goalhero-payment-jobs", time.Minute)
+	require.NoError(t, err)
+
+	_, err = tokens.Verify(token)
+	require.NoError(t, err)
+
+	_, err = tokens.Verify(token)
+	assert.ErrorIs(t, err, ErrServiceTokenReplayed)
+}
+
+func TestServiceTokensKeyRotation(t *testing.T) {
+	// Callers still signing with the old key keep working while the new one rolls out
+	issuedWithOld := newTestServiceTokens(t, "k1")
+	issuedWithNew := newTestServiceTokens(t, "k2")
+	verifier := newTestServiceTokens(t, "")
+
+	for _, issuer := range []*ServiceTokens{issuedWithOld, issuedWithNew} {
+		token, err := issuer.Issue("goalhero-api", "goalhero-payment-jobs", time.Minute)
+		require.NoError(t, err)
+		_, err = verifier.Verify(token)
+		assert.NoError(t, err)
+	}
+}
+
+func TestNewServiceTokensValidation(t *testing.T) {
+	base := ServiceTokenConfig{
+		Keys:           map[string]string{"k1": testKeyOld},
+		Audience:       "goalhero-payment-jobs",
+		TrustedIssuers: []string{"goalhero-api"},
+		MaxTTL:         5 * time.Minute,
+	}
+
+	shortKey := base
+	shortKey.Keys = map[string]string{"k1": "short"}
+	_, err := NewServiceTokens(shortKey)
+	assert.ErrorContains(t, err, "shorter than")
+
+	unknownSigner := base
+	unknownSigner.SigningKeyID = "k9"
+	_, err = NewServiceTokens(unknownSigner)
+	assert.ErrorContains(t, err, "signing key")
+
+	noIssuers := base
+	noIssuers.TrustedIssuers = nil
+	_, err = NewServiceTokens(noIssuers)
+	assert.ErrorContains(t, err, "trusted service token issuer")
+}
+
+func TestInitServiceAuth(t *testing.T) {
+	defer func() { serviceTokens = nil }()
+
+	err := InitServiceAuth(ServiceTokenConfig{}, true)
+	assert.ErrorContains(t, err, "must be set in production")
+
+	err = InitServiceAuth(ServiceTokenConfig{}, false)
+	assert.NoError(t, err)
+	assert.Nil(t, serviceTokens)
+}
+
+func TestServiceTokenMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer func() { serviceTokens = nil }()
+
+	router := gin.New()
+	router.POST("/internal", ServiceTokenMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("serviceID"))
+	})
+
+	send := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/internal", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("rejects everything when not configured", func(t *testing.T) {
+		serviceTokens = nil
+		assert.Equal(t, http.StatusServiceUnavailable, send("Bearer anything").Code)
+	})
+
+	serviceTokens = newTestServiceTokens(t, "k1")
+
+	t.Run("requires a token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, send("").Code)
+		assert.Equal(t, http.StatusUnauthorized, send("Bearer not-a-jwt").Code)
+	})
+
+	t.Run("accepts a valid token once", func(t *testing.T) {
+		token, err := serviceTokens.Issue("goalhero-api", "goalhero-payment-jobs", time.Minute)
+		require.NoError(t, err)
+
+		w := send("Bearer " + token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "goalhero-api", w.Body.String())
+
+		assert.Equal(t, http.StatusUnauthorized, send("Bearer "+token).Code)
+	})
+}
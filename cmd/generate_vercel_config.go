@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+)
+
+// Regenerates the crons in vercel.json from the job registry. Usage:
+//
+//	go run cmd/generate_vercel_config.go [-file vercel.json]
+func main() {
+	file := flag.String("file", "vercel.json", "Path of vercel.json")
+	flag.Parse()
+
+	current, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	generated, err := services.GenerateVercelConfig(current)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	if err := os.WriteFile(*file, generated, 0o644); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Printf("✅ Wrote %d crons to %s", len(services.VercelCrons()), *file)
+}
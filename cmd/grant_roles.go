@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/auth"
+)
+
+// Grants or revokes operator roles on a Firebase user. Usage:
+//
+//	go run cmd/grant_roles.go -uid <uid>                      # show current roles
+//	go run cmd/grant_roles.go -uid <uid> -grant ops,finance   # add roles
+//	go run cmd/grant_roles.go -uid <uid> -revoke finance      # remove roles
+//	go run cmd/grant_roles.go -uid <uid> -set support         # replace all roles
+//
+// The user picks up the change the next time their ID token is refreshed.
+func main() {
+	uid := flag.String("uid", "", "Firebase UID of the user")
+	grant := flag.String("grant", "", "Comma-separated roles to add")
+	revoke := flag.String("revoke", "", "Comma-separated roles to remove")
+	set := flag.String("set", "", "Comma-separated roles replacing the current ones (use \"none\" to clear)")
+	flag.Parse()
+
+	if *uid == "" {
+		fmt.Fprintf(os.Stderr, "-uid is required (valid roles: %s)\n", strings.Join(auth.Roles, ", "))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	auth.InitFirebase()
+	ctx := context.Background()
+
+	current, err := auth.GetUserRoles(ctx, *uid)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Printf("Current roles for %s: %v", *uid, current)
+
+	if *grant == "" && *revoke == "" && *set == "" {
+		return
+	}
+
+	roles := current
+	if *set != "" {
+		roles = nil
+		if *set != "none" {
+			roles = splitRoles(*set)
+		}
+	}
+	for _, role := range splitRoles(*grant) {
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	roles = slices.DeleteFunc(roles, func(role string) bool {
+		return slices.Contains(splitRoles(*revoke), role)
+	})
+	slices.Sort(roles)
+
+	if err := auth.SetUserRoles(ctx, *uid, roles); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Printf("✅ Roles for %s set to %v", *uid, roles)
+}
+
+func splitRoles(value string) []string {
+	var roles []string
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
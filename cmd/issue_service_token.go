@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/auth"
+)
+
+// Prints a single-use service token for the internal endpoints, signed with the key named by
+// SERVICE_TOKEN_SIGNING_KEY_ID from SERVICE_TOKEN_KEYS. Usage:
+//
+//	TOKEN=$(go run cmd/issue_service_token.go -issuer goalhero-api)
+//	curl -X POST -H "Authorization: Bearer $TOKEN" $URL/api/jobs/internal/trigger-auto-release
+func main() {
+	issuer := flag.String("issuer", "goalhero-api", "Calling service, sent as iss")
+	audience := flag.String("audience", "goalhero-payment-jobs", "Receiving service, sent as aud")
+	ttl := flag.Duration("ttl", time.Minute, "Token lifetime")
+	flag.Parse()
+
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("SERVICE_TOKEN_KEYS"), ",") {
+		if kid, secret, found := strings.Cut(strings.TrimSpace(pair), ":"); found {
+			keys[kid] = secret
+		}
+	}
+
+	tokens, err := auth.NewServiceTokens(auth.ServiceTokenConfig{
+		Keys:           keys,
+		SigningKeyID:   os.Getenv("SERVICE_TOKEN_SIGNING_KEY_ID"),
+		Audience:       *audience,
+		TrustedIssuers: []string{*issuer},
+		MaxTTL:         *ttl,
+	})
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	token, err := tokens.Issue(*issuer, *audience, *ttl)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/stripetest"
+)
+
+// Load tests the payment flows against a local Stripe stand-in with in-memory stores: concurrent
+// users create and confirm game payments, then concurrent auto-release runs compete for the
+// escrows. Exits with status 1 when an operation's p95 latency exceeds -max-latency-ms or any
+// request failed. Usage:
+//
+//	go run cmd/load_test_payments.go -users 20 -payments 10 -stripe-latency 150ms \
+//	  -json load-test.json -markdown load-test.md
+func main() {
+	defaults := services.NewTestUtilities().GetDefaultPerformanceConfig()
+	users := flag.Int("users", defaults.ConcurrentUsers, "Concurrent users")
+	payments := flag.Int("payments", defaults.PaymentsPerUser, "Payments made by each user")
+	duration := flag.Int("duration", defaults.TestDurationSeconds, "Seconds after which no new payments are started")
+	maxLatency := flag.Int64("max-latency-ms", defaults.MaxAcceptableLatencyMs, "Highest acceptable p95 latency per operation")
+	autoReleaseRuns := flag.Int("auto-release-runs", 3, "Concurrent auto-release runs once the payments are in escrow")
+	amount := flag.Float64("amount", 10.0, "Game price in euros")
+	stripeLatency := flag.Duration("stripe-latency", 0, "Delay added to every stand-in response, e.g. 150ms for a realistic Stripe round trip")
+	jsonPath := flag.String("json", "", "Write the JSON report to this file")
+	markdownPath := flag.String("markdown", "", "Write the Markdown report to this file")
+	verbose := flag.Bool("verbose", false, "Keep the service's info logs")
+	flag.Parse()
+
+	if !*verbose {
+		slog.SetLogLoggerLevel(slog.LevelWarn)
+	}
+
+	server := stripetest.NewServer()
+	defer server.Close()
+	server.Latency = *stripeLatency
+	server.AddAccount("acct_test_organizer")
+
+	os.Setenv("STRIPE_API_BASE", server.URL)
+	os.Setenv("STRIPE_SECRET_KEY", "sk_test_standin")
+	os.Setenv("STRIPE_TEST_MODE", "true")
+	if err := config.InitJobsConfig(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	fmt.Printf("🚀 %d users × %d payments, %d auto-release runs against the Stripe stand-in\n", *users, *payments, *autoReleaseRuns)
+	tester := services.NewLoadTester(services.NewInMemoryPaymentService())
+	report := tester.Run(context.Background(), services.LoadTestConfig{
+		PerformanceTestConfig: services.PerformanceTestConfig{
+			ConcurrentUsers:        *users,
+			PaymentsPerUser:        *payments,
+			TestDurationSeconds:    *duration,
+			MaxAcceptableLatencyMs: *maxLatency,
+		},
+		AutoReleaseRuns: *autoReleaseRuns,
+		Amount:          *amount,
+		OrganizerID:     "acct_test_organizer",
+	})
+
+	markdown := report.Markdown()
+	fmt.Println()
+	fmt.Print(markdown)
+
+	if *jsonPath != "" {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(encoded, '\n'), 0o644); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+	if *markdownPath != "" {
+		if err := os.WriteFile(*markdownPath, []byte(markdown), 0o644); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+
+	if !report.Passed {
+		fmt.Printf("\n❌ Load test failed with %d regressions after %s\n", len(report.Regressions), report.Duration)
+		os.Exit(1)
+	}
+	fmt.Printf("\n✅ Load test passed in %s\n", report.Duration)
+}
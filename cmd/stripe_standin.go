@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/stripetest"
+)
+
+// Serves the Stripe stand-in so the service can run its payment flows offline. Usage:
+//
+//	go run cmd/stripe_standin.go -addr localhost:12111 -accounts acct_test_organizer
+//	STRIPE_API_BASE=http://localhost:12111 STRIPE_SECRET_KEY=sk_test_standin go run .
+func main() {
+	addr := flag.String("addr", "localhost:12111", "Address to listen on")
+	webhookURL := flag.String("webhook-url", "", "Endpoint every event is POSTed to, e.g. http://localhost:8080/webhooks/stripe")
+	webhookSecret := flag.String("webhook-secret", "", "Secret the events are signed with; random when empty")
+	accounts := flag.String("accounts", "acct_test_organizer", "Comma-separated connected accounts to register")
+	flag.Parse()
+
+	server := stripetest.New()
+	server.URL = "http://" + *addr
+	if *webhookSecret != "" {
+		server.WebhookSecret = *webhookSecret
+	}
+	server.SetWebhookEndpoint(*webhookURL)
+	for _, accountID := range strings.Split(*accounts, ",") {
+		if accountID = strings.TrimSpace(accountID); accountID != "" {
+			server.AddAccount(accountID)
+		}
+	}
+
+	fmt.Printf("💳 Stripe stand-in listening on %s\n", server.URL)
+	fmt.Printf("🔑 STRIPE_API_BASE=%s\n", server.URL)
+	fmt.Printf("🔏 Webhook secret: %s\n", server.WebhookSecret)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
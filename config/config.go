@@ -2,9 +2,8 @@ package config
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
-	"strconv"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -32,38 +31,86 @@ type JobsConfig struct {
 	DisputeEscalationHours   int
 	MaxRetries               int
 	RetryDelay               time.Duration
+	ShutdownTimeout          time.Duration
+	MaxReleaseFailures       int
+	AutoReleaseBatchSize     int
+	AutoReleaseConcurrency   int
+	AutoReleaseMaxPerRun     int
+	RatingAggregation        string
+	MinRaters                int
+	EscrowHoldPeriod         time.Duration
+	ReleaseGracePeriod       time.Duration // After the hold, how long unrated escrows wait for ratings
+	TrustedOrganizers        []string // Organizer UIDs that get TrustedOrganizerHoldPeriod
+	TrustedOrganizerHoldPeriod time.Duration
+	ReviewSLAHours           int // Hours a reviewer has to decide on an under_review escrow
+	StripeSecretKey          string            // Must be a live key in production and a test key elsewhere
+	StripeConnectAccount     string
+	StripeTestMode           bool
+	StripeAPIBase            string            // Stripe API base URL; empty for api.stripe.com, set to a stand-in in tests
+	VirtualClock             bool              // Sandbox time that admins can advance; never in production or cron mode
+	SlackSigningSecret       string            // Verifies Slack interaction requests
+	SlackAdminUsers          map[string]string // Slack user ID -> admin Firebase UID allowed to decide reviews
+	SlackWebhookURL          string            // Slack notification channel
+	NotifyWebhookURL         string            // Generic JSON webhook notification channel
+	SMTPAddr                 string            // host:port of the SMTP server for the email channel
+	SMTPUsername             string
+	SMTPPassword             string
+	SMTPFrom                 string
+	NotifyEmailTo            []string          // Email channel recipients
+	NotifyRoutes             map[string]string // Event type or severity -> channels separated by "|"
+	NotifyTimeout            time.Duration     // Timeout of each notification delivery attempt
+	NotifyDedupWindow        time.Duration     // Repeats of an event for the same escrow within the window are dropped
+	NotifyRateLimits         map[string]string // Channel -> notifications per hour
+	NotifyQuietWhenIdle      bool              // Skip job summaries of runs that did nothing
+	NotifyDigest             string            // off, hourly or daily digest of release successes
+	MetricsToken             string            // Bearer token required on /metrics when set
+	TracesExporter           string            // none, otlp or stdout
+	TracesSampleRatio        float64           // Fraction of new traces recorded
+	ServiceName              string            // service.name reported on traces
+	ServiceTokenKeys         map[string]string // Key ID -> secret accepted on service tokens for the internal endpoints
+	ServiceTokenSigningKeyID string            // Key used to sign service tokens issued with cmd/issue_service_token.go
+	ServiceTokenAudience     string            // aud claim required on service tokens
+	ServiceTokenIssuers      []string          // iss claims accepted on service tokens
+	ServiceTokenMaxTTL       time.Duration     // Longest service token lifetime accepted
+	ExecutionMode            string            // background (tickers in the process) or cron (runs inside cron requests)
+	CronSecret               string            // Bearer secret Vercel Cron sends to the cron endpoint
+	CronTimeBudget           time.Duration     // Time a cron invocation may spend before checkpointing
+	CronMaxReleases          int               // Releases per cron invocation of auto_release
 }
 
+// Job execution modes
+const (
+	ExecutionModeBackground = "background"
+	ExecutionModeCron       = "cron"
+)
+
 var (
 	AppConfig *Config
 	jobsConfig *JobsConfig
+	effectiveConfig []EffectiveSetting
 	firestoreClient *firestore.Client
 )
 
+// InitConfig initializes the base configuration. InitJobsConfig loads it together with the jobs
+// configuration and validates both.
 func InitConfig() {
-	AppConfig = &Config{
-		PaymentTestMode:    getBoolEnv("PAYMENT_TEST_MODE", true),
-		StripeTestMode:     getBoolEnv("STRIPE_TEST_MODE", true),
-		AutoAcceptPayments: getBoolEnv("AUTO_ACCEPT_PAYMENTS", true),
-		Port:              getEnv("PORT", "8080"),
-		Environment:       getEnv("ENVIRONMENT", "development"),
-	}
+	AppConfig = loadAppConfig(newLoader())
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func loadAppConfig(l *loader) *Config {
+	environment := l.string("ENVIRONMENT", "development")
+	return &Config{
+		PaymentTestMode:    l.bool("PAYMENT_TEST_MODE", true),
+		StripeTestMode:     l.bool("STRIPE_TEST_MODE", environment != "production"),
+		AutoAcceptPayments: l.bool("AUTO_ACCEPT_PAYMENTS", true),
+		Port:               l.string("PORT", "8080"),
+		Environment:        environment,
 	}
-	return defaultValue
 }
 
-func getBoolEnv(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := strconv.ParseBool(value); err == nil {
-			return parsed
-		}
-	}
-	return defaultValue
+// IsProduction reports whether ENVIRONMENT is production
+func IsProduction() bool {
+	return AppConfig != nil && AppConfig.Environment == "production"
 }
 
 func IsTestMode() bool {
@@ -74,38 +121,118 @@ func IsAutoAcceptEnabled() bool {
 	return AppConfig.AutoAcceptPayments && IsTestMode()
 }
 
-// InitJobsConfig initializes the jobs service configuration
-func InitJobsConfig() {
-	// Initialize base config first
-	InitConfig()
-	
+// InitJobsConfig loads the jobs service configuration from the environment, the optional
+// CONFIG_FILE and defaults, in that order of precedence. Invalid settings are replaced by their
+// defaults and all of them are reported in the returned *ValidationError, on which main refuses
+// to start.
+func InitJobsConfig() error {
+	app, jobs, effective, err := load()
+	AppConfig, jobsConfig, effectiveConfig = app, jobs, effective
+
 	// Initialize Firestore
 	InitFirestore()
-	
-	jobsConfig = &JobsConfig{
-		Port:                      getEnv("JOBS_PORT", "8081"),
-		MainAPIURL:                getEnv("MAIN_API_URL", "http://localhost:8080"),
-		RatingReminderInterval:    getDurationEnv("RATING_REMINDER_INTERVAL", 24*time.Hour),
-		AutoReleaseInterval:       getDurationEnv("AUTO_RELEASE_INTERVAL", 1*time.Hour),
-		DisputeEscalationInterval: getDurationEnv("DISPUTE_ESCALATION_INTERVAL", 24*time.Hour),
-		RatingDeadlineDays:        getIntEnv("RATING_DEADLINE_DAYS", 7),
-		MinRatingForAutoRelease:   getFloatEnv("MIN_RATING_FOR_AUTO_RELEASE", 3.0),
-		DisputeEscalationHours:    getIntEnv("DISPUTE_ESCALATION_HOURS", 72),
-		MaxRetries:                getIntEnv("MAX_RETRIES", 3),
-		RetryDelay:                getDurationEnv("RETRY_DELAY", 30*time.Second),
+
+	slog.Info("Jobs service config loaded", "port", jobsConfig.Port, "main_api_url", jobsConfig.MainAPIURL, "environment", AppConfig.Environment)
+	return err
+}
+
+// load reads the whole configuration and validates it
+func load() (*Config, *JobsConfig, []EffectiveSetting, error) {
+	l := newLoader()
+	app := loadAppConfig(l)
+
+	// Outside production the main API defaults to a local instance; production must name it
+	defaultMainAPIURL := "http://localhost:8080"
+	if app.Environment == "production" {
+		defaultMainAPIURL = ""
+	}
+
+	jobs := &JobsConfig{
+		Port:                       l.string("JOBS_PORT", "8081"),
+		MainAPIURL:                 l.string("MAIN_API_URL", defaultMainAPIURL),
+		RatingReminderInterval:     l.duration("RATING_REMINDER_INTERVAL", 24*time.Hour),
+		AutoReleaseInterval:        l.duration("AUTO_RELEASE_INTERVAL", 1*time.Hour),
+		DisputeEscalationInterval:  l.duration("DISPUTE_ESCALATION_INTERVAL", 24*time.Hour),
+		RatingDeadlineDays:         l.int("RATING_DEADLINE_DAYS", 7),
+		MinRatingForAutoRelease:    l.float("MIN_RATING_FOR_AUTO_RELEASE", 3.0),
+		DisputeEscalationHours:     l.int("DISPUTE_ESCALATION_HOURS", 72),
+		MaxRetries:                 l.int("MAX_RETRIES", 3),
+		RetryDelay:                 l.duration("RETRY_DELAY", 30*time.Second),
+		ShutdownTimeout:            l.duration("SHUTDOWN_TIMEOUT", 25*time.Second),
+		MaxReleaseFailures:         l.int("MAX_RELEASE_FAILURES", 5),
+		AutoReleaseBatchSize:       l.int("AUTO_RELEASE_BATCH_SIZE", 100),
+		AutoReleaseConcurrency:     l.int("AUTO_RELEASE_CONCURRENCY", 4),
+		AutoReleaseMaxPerRun:       l.int("AUTO_RELEASE_MAX_PER_RUN", 1000),
+		RatingAggregation:          l.string("RATING_AGGREGATION", "mean"),
+		MinRaters:                  l.int("MIN_RATERS", 1),
+		EscrowHoldPeriod:           l.duration("ESCROW_HOLD_PERIOD", 24*time.Hour),
+		ReleaseGracePeriod:         l.duration("RELEASE_GRACE_PERIOD", 24*time.Hour),
+		TrustedOrganizers:          l.list("TRUSTED_ORGANIZERS"),
+		TrustedOrganizerHoldPeriod: l.duration("TRUSTED_ORGANIZER_HOLD_PERIOD", 6*time.Hour),
+		ReviewSLAHours:             l.int("REVIEW_SLA_HOURS", 48),
+		StripeSecretKey:            l.secret("STRIPE_SECRET_KEY"),
+		StripeConnectAccount:       l.string("STRIPE_CONNECT_ACCOUNT", ""),
+		StripeTestMode:             app.StripeTestMode,
+		StripeAPIBase:              l.string("STRIPE_API_BASE", ""),
+		VirtualClock:               l.bool("VIRTUAL_CLOCK", false),
+		SlackSigningSecret:         l.secret("SLACK_SIGNING_SECRET"),
+		SlackAdminUsers:            l.stringMap("SLACK_ADMIN_USERS", false),
+		SlackWebhookURL:            l.secret("SLACK_ESCROW_WEBHOOK_URL"),
+		NotifyWebhookURL:           l.secret("NOTIFY_WEBHOOK_URL"),
+		SMTPAddr:                   l.string("SMTP_ADDR", ""),
+		SMTPUsername:               l.string("SMTP_USERNAME", ""),
+		SMTPPassword:               l.secret("SMTP_PASSWORD"),
+		SMTPFrom:                   l.string("SMTP_FROM", "payments@goalhero.app"),
+		NotifyEmailTo:              l.list("NOTIFY_EMAIL_TO"),
+		NotifyRoutes:               l.stringMap("NOTIFY_ROUTES", false),
+		NotifyTimeout:              l.duration("NOTIFY_TIMEOUT", 10*time.Second),
+		NotifyDedupWindow:          l.duration("NOTIFY_DEDUP_WINDOW", time.Hour),
+		NotifyRateLimits:           l.stringMap("NOTIFY_RATE_LIMITS", false),
+		NotifyQuietWhenIdle:        l.bool("NOTIFY_QUIET_WHEN_IDLE", false),
+		NotifyDigest:               l.string("NOTIFY_DIGEST", "off"),
+		MetricsToken:               l.secret("METRICS_TOKEN"),
+		TracesExporter:             l.string("OTEL_TRACES_EXPORTER", "none"),
+		TracesSampleRatio:          l.float("OTEL_TRACES_SAMPLER_ARG", 1.0),
+		ServiceName:                l.string("OTEL_SERVICE_NAME", "goalhero-payment-jobs"),
+		ServiceTokenKeys:           l.stringMap("SERVICE_TOKEN_KEYS", true),
+		ServiceTokenSigningKeyID:   l.string("SERVICE_TOKEN_SIGNING_KEY_ID", ""),
+		ServiceTokenAudience:       l.string("SERVICE_TOKEN_AUDIENCE", "goalhero-payment-jobs"),
+		ServiceTokenIssuers:        l.list("SERVICE_TOKEN_ISSUERS"),
+		ServiceTokenMaxTTL:         l.duration("SERVICE_TOKEN_MAX_TTL", 5*time.Minute),
+		ExecutionMode:              l.string("JOBS_EXECUTION_MODE", defaultExecutionMode()),
+		CronSecret:                 l.secret("CRON_SECRET"),
+		CronTimeBudget:             l.duration("CRON_TIME_BUDGET", 45*time.Second),
+		CronMaxReleases:            l.int("CRON_MAX_RELEASES", 200),
 	}
 
-	log.Printf("🔧 Jobs Service Config: Port=%s, MainAPI=%s", jobsConfig.Port, jobsConfig.MainAPIURL)
+	validate(l, app, jobs)
+	return app, jobs, l.settings, l.err()
+}
+
+// defaultExecutionMode runs jobs in cron mode on Vercel, which sets VERCEL=1 in its functions
+func defaultExecutionMode() string {
+	if os.Getenv("VERCEL") != "" {
+		return ExecutionModeCron
+	}
+	return ExecutionModeBackground
 }
 
-// GetJobsConfig returns the jobs configuration
+// GetJobsConfig returns the jobs configuration, loading it on first use
 func GetJobsConfig() *JobsConfig {
 	if jobsConfig == nil {
-		InitJobsConfig()
+		if err := InitJobsConfig(); err != nil {
+			slog.Error("Jobs service config is invalid, using defaults for invalid settings", "error", err)
+		}
 	}
 	return jobsConfig
 }
 
+// EffectiveConfig returns every setting as loaded, with its source and secrets redacted
+func EffectiveConfig() []EffectiveSetting {
+	GetJobsConfig()
+	return append([]EffectiveSetting(nil), effectiveConfig...)
+}
+
 // InitFirestore initializes Firestore client
 func InitFirestore() {
 	ctx := context.Background()
@@ -119,7 +246,7 @@ func InitFirestore() {
 		// Fallback to file for local development
 		credentialsPath := "auth/firebase_credentials.json"
 		if _, err := os.Stat(credentialsPath); os.IsNotExist(err) {
-			log.Printf("⚠️ No Firebase credentials found, Firestore will be disabled")
+			slog.Warn("No Firebase credentials found, Firestore will be disabled")
 			return
 		}
 		opt = option.WithCredentialsFile(credentialsPath)
@@ -127,46 +254,17 @@ func InitFirestore() {
 	
 	app, err := firebase.NewApp(ctx, nil, opt)
 	if err != nil {
-		log.Printf("⚠️ Error initializing Firebase app: %v", err)
+		slog.Error("Error initializing Firebase app", "error", err)
 		return
 	}
 	
 	client, err := app.Firestore(ctx)
 	if err != nil {
-		log.Printf("⚠️ Error initializing Firestore: %v", err)
+		slog.Error("Error initializing Firestore", "error", err)
 		return
 	}
 	
 	firestoreClient = client
 	SetFirestoreClient(client)
-	log.Println("✅ Firestore initialized")
-}
-
-// Helper functions for jobs config
-func getIntEnv(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
-}
-
-func getFloatEnv(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
-		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
-			return floatValue
-		}
-	}
-	return defaultValue
-}
-
-func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
-		}
-	}
-	return defaultValue
+	slog.Info("Firestore initialized")
 }
-
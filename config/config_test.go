@@ -102,123 +102,6 @@ func TestJobsConfig(t *testing.T) {
 	})
 }
 
-func TestHelperFunctions(t *testing.T) {
-	t.Run("getEnv should return environment value when set", func(t *testing.T) {
-		os.Setenv("TEST_ENV_VAR", "test_value")
-		defer os.Unsetenv("TEST_ENV_VAR")
-
-		result := getEnv("TEST_ENV_VAR", "default_value")
-		assert.Equal(t, "test_value", result)
-	})
-
-	t.Run("getEnv should return default value when env var not set", func(t *testing.T) {
-		os.Unsetenv("NON_EXISTENT_VAR")
-
-		result := getEnv("NON_EXISTENT_VAR", "default_value")
-		assert.Equal(t, "default_value", result)
-	})
-
-	t.Run("getBoolEnv should return environment value when set to true", func(t *testing.T) {
-		os.Setenv("TEST_BOOL_VAR", "true")
-		defer os.Unsetenv("TEST_BOOL_VAR")
-
-		result := getBoolEnv("TEST_BOOL_VAR", false)
-		assert.True(t, result)
-	})
-
-	t.Run("getBoolEnv should return environment value when set to false", func(t *testing.T) {
-		os.Setenv("TEST_BOOL_VAR", "false")
-		defer os.Unsetenv("TEST_BOOL_VAR")
-
-		result := getBoolEnv("TEST_BOOL_VAR", true)
-		assert.False(t, result)
-	})
-
-	t.Run("getBoolEnv should return default value when env var not set", func(t *testing.T) {
-		os.Unsetenv("NON_EXISTENT_BOOL_VAR")
-
-		result := getBoolEnv("NON_EXISTENT_BOOL_VAR", true)
-		assert.True(t, result)
-	})
-
-	t.Run("getBoolEnv should return default value when env var has invalid format", func(t *testing.T) {
-		os.Setenv("INVALID_BOOL_VAR", "not_a_boolean")
-		defer os.Unsetenv("INVALID_BOOL_VAR")
-
-		result := getBoolEnv("INVALID_BOOL_VAR", false)
-		assert.False(t, result)
-	})
-
-	t.Run("getIntEnv should return environment value when set", func(t *testing.T) {
-		os.Setenv("TEST_INT_VAR", "42")
-		defer os.Unsetenv("TEST_INT_VAR")
-
-		result := getIntEnv("TEST_INT_VAR", 0)
-		assert.Equal(t, 42, result)
-	})
-
-	t.Run("getIntEnv should return default value when env var not set", func(t *testing.T) {
-		os.Unsetenv("NON_EXISTENT_INT_VAR")
-
-		result := getIntEnv("NON_EXISTENT_INT_VAR", 100)
-		assert.Equal(t, 100, result)
-	})
-
-	t.Run("getIntEnv should return default value when env var has invalid format", func(t *testing.T) {
-		os.Setenv("INVALID_INT_VAR", "not_a_number")
-		defer os.Unsetenv("INVALID_INT_VAR")
-
-		result := getIntEnv("INVALID_INT_VAR", 50)
-		assert.Equal(t, 50, result)
-	})
-
-	t.Run("getFloatEnv should return environment value when set", func(t *testing.T) {
-		os.Setenv("TEST_FLOAT_VAR", "3.14")
-		defer os.Unsetenv("TEST_FLOAT_VAR")
-
-		result := getFloatEnv("TEST_FLOAT_VAR", 0.0)
-		assert.Equal(t, 3.14, result)
-	})
-
-	t.Run("getFloatEnv should return default value when env var not set", func(t *testing.T) {
-		os.Unsetenv("NON_EXISTENT_FLOAT_VAR")
-
-		result := getFloatEnv("NON_EXISTENT_FLOAT_VAR", 2.5)
-		assert.Equal(t, 2.5, result)
-	})
-
-	t.Run("getFloatEnv should return default value when env var has invalid format", func(t *testing.T) {
-		os.Setenv("INVALID_FLOAT_VAR", "not_a_float")
-		defer os.Unsetenv("INVALID_FLOAT_VAR")
-
-		result := getFloatEnv("INVALID_FLOAT_VAR", 1.5)
-		assert.Equal(t, 1.5, result)
-	})
-
-	t.Run("getDurationEnv should return environment value when set", func(t *testing.T) {
-		os.Setenv("TEST_DURATION_VAR", "30s")
-		defer os.Unsetenv("TEST_DURATION_VAR")
-
-		result := getDurationEnv("TEST_DURATION_VAR", 0)
-		assert.Equal(t, 30*time.Second, result)
-	})
-
-	t.Run("getDurationEnv should return default value when env var not set", func(t *testing.T) {
-		os.Unsetenv("NON_EXISTENT_DURATION_VAR")
-
-		result := getDurationEnv("NON_EXISTENT_DURATION_VAR", 1*time.Minute)
-		assert.Equal(t, 1*time.Minute, result)
-	})
-
-	t.Run("getDurationEnv should return default value when env var has invalid format", func(t *testing.T) {
-		os.Setenv("INVALID_DURATION_VAR", "not_a_duration")
-		defer os.Unsetenv("INVALID_DURATION_VAR")
-
-		result := getDurationEnv("INVALID_DURATION_VAR", 5*time.Second)
-		assert.Equal(t, 5*time.Second, result)
-	})
-}
-
 func TestConfigUtilities(t *testing.T) {
 	t.Run("IsTestMode should return true when PaymentTestMode is true", func(t *testing.T) {
 		AppConfig = &Config{
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Where a setting's value came from, lowest precedence first
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// FieldError is one invalid or missing setting
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Key + ": " + e.Message
+}
+
+// ValidationError lists every problem found while loading the configuration, so a deploy can be
+// fixed in one go instead of one restart per variable
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fieldErr.Error())
+	}
+	return fmt.Sprintf("invalid configuration (%d problems): %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// EffectiveSetting is one setting as loaded, with secrets redacted
+type EffectiveSetting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// loader reads typed settings from the environment, falling back to the config file named by
+// CONFIG_FILE and then to defaults. Unlike the getXEnv helpers it records unparsable values
+// instead of ignoring them, and keeps track of every setting for the effective configuration view.
+type loader struct {
+	file     map[string]string // Settings from CONFIG_FILE, keyed by environment variable name
+	settings []EffectiveSetting
+	errs     []FieldError
+}
+
+func newLoader() *loader {
+	l := &loader{file: make(map[string]string)}
+
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return l
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.fail("CONFIG_FILE", "%v", err)
+		return l
+	}
+	if err := l.readFile(data); err != nil {
+		l.fail("CONFIG_FILE", "%s: %v", path, err)
+	}
+	return l
+}
+
+// readFile parses a JSON object keyed by environment variable name. Values may be strings,
+// numbers, booleans, lists (for comma-separated settings) or objects (for key:value settings).
+func (l *loader) readFile(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return err
+	}
+
+	for key, raw := range values {
+		switch value := raw.(type) {
+		case string:
+			l.file[key] = value
+		case json.Number:
+			l.file[key] = value.String()
+		case bool:
+			l.file[key] = strconv.FormatBool(value)
+		case []interface{}:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			l.file[key] = strings.Join(items, ",")
+		case map[string]interface{}:
+			pairs := make([]string, 0, len(value))
+			for k, v := range value {
+				pairs = append(pairs, k+":"+fmt.Sprint(v))
+			}
+			sort.Strings(pairs)
+			l.file[key] = strings.Join(pairs, ",")
+		default:
+			return fmt.Errorf("unsupported value for %s", key)
+		}
+	}
+	return nil
+}
+
+func (l *loader) fail(key, format string, args ...interface{}) {
+	l.errs = append(l.errs, FieldError{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the problems found so far, or nil
+func (l *loader) err() error {
+	if len(l.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: l.errs}
+}
+
+// lookup returns the raw value of a setting and where it came from; ok is false when it is unset
+func (l *loader) lookup(key string) (value, source string, ok bool) {
+	if value := os.Getenv(key); value != "" {
+		return value, SourceEnv, true
+	}
+	if value, exists := l.file[key]; exists && value != "" {
+		return value, SourceFile, true
+	}
+	return "", SourceDefault, false
+}
+
+func (l *loader) record(key, value, source string, secret bool) {
+	if secret {
+		value = redact(value)
+	}
+	l.settings = append(l.settings, EffectiveSetting{Key: key, Value: value, Source: source, Secret: secret})
+}
+
+func (l *loader) string(key, defaultValue string) string {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		value = defaultValue
+	}
+	l.record(key, value, source, false)
+	return value
+}
+
+// secret reads a setting that is redacted in the effective configuration
+func (l *loader) secret(key string) string {
+	value, source, _ := l.lookup(key)
+	l.record(key, value, source, true)
+	return value
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		l.record(key, strconv.FormatBool(defaultValue), source, false)
+		return defaultValue
+	}
+	l.record(key, value, source, false)
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		l.fail(key, "invalid boolean %q", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+func (l *loader) int(key string, defaultValue int) int {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		l.record(key, strconv.Itoa(defaultValue), source, false)
+		return defaultValue
+	}
+	l.record(key, value, source, false)
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		l.fail(key, "invalid integer %q", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+func (l *loader) float(key string, defaultValue float64) float64 {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		l.record(key, strconv.FormatFloat(defaultValue, 'g', -1, 64), source, false)
+		return defaultValue
+	}
+	l.record(key, value, source, false)
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.fail(key, "invalid number %q", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		l.record(key, defaultValue.String(), source, false)
+		return defaultValue
+	}
+	l.record(key, value, source, false)
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		l.fail(key, "invalid duration %q (e.g. 30s, 1h)", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+func (l *loader) list(key string) []string {
+	value, source, _ := l.lookup(key)
+	l.record(key, value, source, false)
+	return splitList(value)
+}
+
+// stringMap parses "key:value,key:value" pairs. With secret set only the values are redacted, so
+// the effective configuration still shows e.g. which service token key IDs are configured.
+func (l *loader) stringMap(key string, secret bool) map[string]string {
+	value, source, _ := l.lookup(key)
+
+	values := make(map[string]string)
+	shown := make([]string, 0)
+	for _, pair := range splitList(value) {
+		k, v, found := strings.Cut(pair, ":")
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); !found || k == "" || v == "" {
+			l.fail(key, "malformed entry %q, expected key:value", pair)
+			continue
+		}
+		values[k] = v
+		if secret {
+			v = redact(v)
+		}
+		shown = append(shown, k+":"+v)
+	}
+
+	l.settings = append(l.settings, EffectiveSetting{Key: key, Value: strings.Join(shown, ","), Source: source, Secret: secret})
+	return values
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// redact hides a secret, keeping the Stripe key prefix (which tells test from live keys) and the
+// last four characters of long values so operators can tell which secret is deployed
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	prefix := ""
+	for _, stripePrefix := range []string{"sk_test_", "sk_live_", "rk_test_", "rk_live_"} {
+		if strings.HasPrefix(value, stripePrefix) {
+			prefix = stripePrefix
+		}
+	}
+	if len(value)-len(prefix) < 16 {
+		return prefix + "****"
+	}
+	return prefix + "****" + value[len(value)-4:]
+}
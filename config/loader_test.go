@@ -0,0 +1,210 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoaderTypedSettings(t *testing.T) {
+	t.Run("should return environment values when set", func(t *testing.T) {
+		t.Setenv("TEST_STRING_VAR", "test_value")
+		t.Setenv("TEST_BOOL_VAR", "false")
+		t.Setenv("TEST_INT_VAR", "42")
+		t.Setenv("TEST_FLOAT_VAR", "3.14")
+		t.Setenv("TEST_DURATION_VAR", "30s")
+		t.Setenv("TEST_LIST_VAR", "a, b,,c")
+		t.Setenv("TEST_MAP_VAR", "k1:v1, k2:v2")
+
+		l := newLoader()
+		assert.Equal(t, "test_value", l.string("TEST_STRING_VAR", "default_value"))
+		assert.False(t, l.bool("TEST_BOOL_VAR", true))
+		assert.Equal(t, 42, l.int("TEST_INT_VAR", 0))
+		assert.Equal(t, 3.14, l.float("TEST_FLOAT_VAR", 0))
+		assert.Equal(t, 30*time.Second, l.duration("TEST_DURATION_VAR", 0))
+		assert.Equal(t, []string{"a", "b", "c"}, l.list("TEST_LIST_VAR"))
+		assert.Equal(t, map[string]string{"k1": "v1", "k2": "v2"}, l.stringMap("TEST_MAP_VAR", false))
+		assert.NoError(t, l.err())
+	})
+
+	t.Run("should return defaults when unset", func(t *testing.T) {
+		l := newLoader()
+		assert.Equal(t, "default_value", l.string("NON_EXISTENT_VAR", "default_value"))
+		assert.True(t, l.bool("NON_EXISTENT_VAR", true))
+		assert.Equal(t, 100, l.int("NON_EXISTENT_VAR", 100))
+		assert.Equal(t, 2.5, l.float("NON_EXISTENT_VAR", 2.5))
+		assert.Equal(t, time.Minute, l.duration("NON_EXISTENT_VAR", time.Minute))
+		assert.NoError(t, l.err())
+
+		for _, setting := range l.settings {
+			assert.Equal(t, SourceDefault, setting.Source)
+		}
+	})
+
+	t.Run("should report every invalid value and fall back to defaults", func(t *testing.T) {
+		t.Setenv("INVALID_BOOL_VAR", "not_a_boolean")
+		t.Setenv("INVALID_INT_VAR", "not_a_number")
+		t.Setenv("INVALID_FLOAT_VAR", "not_a_float")
+		t.Setenv("INVALID_DURATION_VAR", "not_a_duration")
+		t.Setenv("INVALID_MAP_VAR", "k1:v1,broken")
+
+		l := newLoader()
+		assert.False(t, l.bool("INVALID_BOOL_VAR", false))
+		assert.Equal(t, 50, l.int("INVALID_INT_VAR", 50))
+		assert.Equal(t, 1.5, l.float("INVALID_FLOAT_VAR", 1.5))
+		assert.Equal(t, 5*time.Second, l.duration("INVALID_DURATION_VAR", 5*time.Second))
+		assert.Equal(t, map[string]string{"k1": "v1"}, l.stringMap("INVALID_MAP_VAR", false))
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, l.err(), &validationErr)
+		keys := make([]string, 0, len(validationErr.Errors))
+		for _, fieldErr := range validationErr.Errors {
+			keys = append(keys, fieldErr.Key)
+		}
+		assert.Equal(t, []string{"INVALID_BOOL_VAR", "INVALID_INT_VAR", "INVALID_FLOAT_VAR", "INVALID_DURATION_VAR", "INVALID_MAP_VAR"}, keys)
+	})
+}
+
+func TestLoaderConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"AUTO_RELEASE_INTERVAL": "30m",
+		"MAX_RETRIES": 5,
+		"NOTIFY_QUIET_WHEN_IDLE": true,
+		"TRUSTED_ORGANIZERS": ["org_1", "org_2"],
+		"SLACK_ADMIN_USERS": {"U123": "admin_1"}
+	}`), 0o600))
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("MAX_RETRIES", "7")
+
+	l := newLoader()
+	require.NoError(t, l.err())
+	assert.Equal(t, 30*time.Minute, l.duration("AUTO_RELEASE_INTERVAL", time.Hour))
+	assert.Equal(t, 7, l.int("MAX_RETRIES", 3), "environment overrides the file")
+	assert.True(t, l.bool("NOTIFY_QUIET_WHEN_IDLE", false))
+	assert.Equal(t, []string{"org_1", "org_2"}, l.list("TRUSTED_ORGANIZERS"))
+	assert.Equal(t, map[string]string{"U123": "admin_1"}, l.stringMap("SLACK_ADMIN_USERS", false))
+
+	sources := make(map[string]string)
+	for _, setting := range l.settings {
+		sources[setting.Key] = setting.Source
+	}
+	assert.Equal(t, SourceFile, sources["AUTO_RELEASE_INTERVAL"])
+	assert.Equal(t, SourceEnv, sources["MAX_RETRIES"])
+
+	t.Run("should report an unreadable file", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorContains(t, newLoader().err(), "CONFIG_FILE")
+	})
+}
+
+// setProductionEnv sets a valid production configuration
+func setProductionEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("MAIN_API_URL", "https://api.goalhero.app")
+	t.Setenv("STRIPE_SECRET_KEY", "sk_live_0123456789abcdef")
+	t.Setenv("SERVICE_TOKEN_KEYS", "k1:0123456789abcdef0123456789abcdef")
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("should accept the development defaults", func(t *testing.T) {
+		app, jobs, _, err := load()
+		require.NoError(t, err)
+		assert.Equal(t, "development", app.Environment)
+		assert.Equal(t, "http://localhost:8080", jobs.MainAPIURL)
+		assert.True(t, jobs.StripeTestMode)
+		assert.Equal(t, 24*time.Hour, jobs.ReleaseGracePeriod)
+	})
+
+	t.Run("should accept a valid production configuration", func(t *testing.T) {
+		setProductionEnv(t)
+		_, jobs, _, err := load()
+		require.NoError(t, err)
+		assert.False(t, jobs.StripeTestMode, "production defaults to live mode")
+	})
+
+	t.Run("should report every problem at once", func(t *testing.T) {
+		t.Setenv("ENVIRONMENT", "production")
+		t.Setenv("STRIPE_SECRET_KEY", "sk_test_0123456789abcdef")
+		t.Setenv("AUTO_RELEASE_INTERVAL", "often")
+		t.Setenv("MIN_RATING_FOR_AUTO_RELEASE", "7")
+
+		_, _, _, err := load()
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+
+		problems := make(map[string]string)
+		for _, fieldErr := range validationErr.Errors {
+			problems[fieldErr.Key] = fieldErr.Message
+		}
+		assert.Equal(t, map[string]string{
+			"AUTO_RELEASE_INTERVAL":       `invalid duration "often" (e.g. 30s, 1h)`,
+			"MIN_RATING_FOR_AUTO_RELEASE": "must be between 1 and 5, got 7",
+			"MAIN_API_URL":                "required in production",
+			"STRIPE_SECRET_KEY":           "test key not allowed in production",
+			"SERVICE_TOKEN_KEYS":          "required in production",
+		}, problems)
+	})
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		key    string
+		errMsg string
+	}{
+		{"live key outside production", map[string]string{"STRIPE_SECRET_KEY": "sk_live_0123456789abcdef"}, "STRIPE_SECRET_KEY", "live key only allowed in production"},
+		{"test mode in production", map[string]string{"ENVIRONMENT": "production", "STRIPE_TEST_MODE": "true"}, "STRIPE_TEST_MODE", "must be false in production"},
+		{"publishable key", map[string]string{"STRIPE_SECRET_KEY": "pk_test_0123"}, "STRIPE_SECRET_KEY", "not a Stripe secret"},
+		{"localhost main API in production", map[string]string{"ENVIRONMENT": "production", "MAIN_API_URL": "http://localhost:8080"}, "MAIN_API_URL", "must not point at localhost"},
+		{"cron mode without secret in production", map[string]string{"ENVIRONMENT": "production", "JOBS_EXECUTION_MODE": "cron"}, "CRON_SECRET", "required in production in cron mode"},
+		{"Stripe stand-in in production", map[string]string{"ENVIRONMENT": "production", "STRIPE_API_BASE": "http://localhost:12111"}, "STRIPE_API_BASE", "not allowed in production"},
+		{"virtual clock in production", map[string]string{"ENVIRONMENT": "production", "VIRTUAL_CLOCK": "true"}, "VIRTUAL_CLOCK", "not allowed in production"},
+		{"virtual clock in cron mode", map[string]string{"VIRTUAL_CLOCK": "true", "JOBS_EXECUTION_MODE": "cron"}, "VIRTUAL_CLOCK", "not allowed in cron mode"},
+		{"malformed Stripe API base", map[string]string{"STRIPE_API_BASE": "localhost:12111"}, "STRIPE_API_BASE", "invalid URL"},
+		{"unknown environment", map[string]string{"ENVIRONMENT": "prod"}, "ENVIRONMENT", "expected one of"},
+		{"zero grace period", map[string]string{"RELEASE_GRACE_PERIOD": "0s"}, "RELEASE_GRACE_PERIOD", "must be positive"},
+		{"unknown digest", map[string]string{"NOTIFY_DIGEST": "weekly"}, "NOTIFY_DIGEST", "expected one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env["ENVIRONMENT"] == "production" {
+				setProductionEnv(t)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, _, _, err := load()
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			require.Len(t, validationErr.Errors, 1, err.Error())
+			assert.Equal(t, tt.key, validationErr.Errors[0].Key)
+			assert.Contains(t, validationErr.Errors[0].Message, tt.errMsg)
+		})
+	}
+}
+
+func TestEffectiveConfigRedactsSecrets(t *testing.T) {
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test_0123456789abcdefWXYZ")
+	t.Setenv("SERVICE_TOKEN_KEYS", "k1:0123456789abcdef0123456789abcdef")
+	t.Setenv("SMTP_PASSWORD", "hunter2")
+
+	_, _, effective, err := load()
+	require.NoError(t, err)
+
+	values := make(map[string]EffectiveSetting)
+	for _, setting := range effective {
+		values[setting.Key] = setting
+	}
+	assert.Equal(t, EffectiveSetting{Key: "STRIPE_SECRET_KEY", Value: "sk_test_****WXYZ", Source: SourceEnv, Secret: true}, values["STRIPE_SECRET_KEY"])
+	assert.Equal(t, "k1:****cdef", values["SERVICE_TOKEN_KEYS"].Value)
+	assert.Equal(t, "****", values["SMTP_PASSWORD"].Value)
+	assert.Equal(t, "mean", values["RATING_AGGREGATION"].Value)
+	assert.Equal(t, SourceDefault, values["RATING_AGGREGATION"].Source)
+}
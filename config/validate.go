@@ -0,0 +1,160 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Environments ENVIRONMENT may name
+var environments = []string{"development", "test", "staging", "production"}
+
+// validate records every setting that is out of range or breaks a rule of the environment
+func validate(l *loader, app *Config, jobs *JobsConfig) {
+	production := app.Environment == "production"
+
+	oneOf(l, "ENVIRONMENT", app.Environment, environments...)
+	oneOf(l, "RATING_AGGREGATION", jobs.RatingAggregation, "mean", "median", "min")
+	oneOf(l, "NOTIFY_DIGEST", jobs.NotifyDigest, "off", "hourly", "daily")
+	oneOf(l, "OTEL_TRACES_EXPORTER", jobs.TracesExporter, "none", "otlp", "stdout")
+	oneOf(l, "JOBS_EXECUTION_MODE", jobs.ExecutionMode, ExecutionModeBackground, ExecutionModeCron)
+
+	for _, setting := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"RATING_REMINDER_INTERVAL", jobs.RatingReminderInterval},
+		{"AUTO_RELEASE_INTERVAL", jobs.AutoReleaseInterval},
+		{"DISPUTE_ESCALATION_INTERVAL", jobs.DisputeEscalationInterval},
+		{"SHUTDOWN_TIMEOUT", jobs.ShutdownTimeout},
+		{"NOTIFY_TIMEOUT", jobs.NotifyTimeout},
+		{"SERVICE_TOKEN_MAX_TTL", jobs.ServiceTokenMaxTTL},
+		{"CRON_TIME_BUDGET", jobs.CronTimeBudget},
+		{"RELEASE_GRACE_PERIOD", jobs.ReleaseGracePeriod},
+	} {
+		if setting.value <= 0 {
+			l.fail(setting.key, "must be positive, got %s", setting.value)
+		}
+	}
+	for _, setting := range []struct {
+		key   string
+		value int
+	}{
+		{"RATING_DEADLINE_DAYS", jobs.RatingDeadlineDays},
+		{"DISPUTE_ESCALATION_HOURS", jobs.DisputeEscalationHours},
+		{"MAX_RELEASE_FAILURES", jobs.MaxReleaseFailures},
+		{"AUTO_RELEASE_BATCH_SIZE", jobs.AutoReleaseBatchSize},
+		{"AUTO_RELEASE_CONCURRENCY", jobs.AutoReleaseConcurrency},
+		{"AUTO_RELEASE_MAX_PER_RUN", jobs.AutoReleaseMaxPerRun},
+		{"MIN_RATERS", jobs.MinRaters},
+		{"REVIEW_SLA_HOURS", jobs.ReviewSLAHours},
+		{"CRON_MAX_RELEASES", jobs.CronMaxReleases},
+	} {
+		if setting.value < 1 {
+			l.fail(setting.key, "must be at least 1, got %d", setting.value)
+		}
+	}
+	if jobs.MaxRetries < 0 {
+		l.fail("MAX_RETRIES", "must not be negative, got %d", jobs.MaxRetries)
+	}
+	if jobs.MinRatingForAutoRelease < 1 || jobs.MinRatingForAutoRelease > 5 {
+		l.fail("MIN_RATING_FOR_AUTO_RELEASE", "must be between 1 and 5, got %g", jobs.MinRatingForAutoRelease)
+	}
+	if jobs.TracesSampleRatio < 0 || jobs.TracesSampleRatio > 1 {
+		l.fail("OTEL_TRACES_SAMPLER_ARG", "must be between 0 and 1, got %g", jobs.TracesSampleRatio)
+	}
+
+	// The clock offset lives in the process, so every cron request could see a different time
+	if jobs.VirtualClock && jobs.ExecutionMode == ExecutionModeCron {
+		l.fail("VIRTUAL_CLOCK", "not allowed in cron mode, the offset is only kept by a single long-running instance")
+	}
+
+	validateMainAPIURL(l, jobs.MainAPIURL, production)
+	validateStripe(l, jobs, production)
+
+	if production {
+		if jobs.VirtualClock {
+			l.fail("VIRTUAL_CLOCK", "not allowed in production, releases must follow real time")
+		}
+		if len(jobs.ServiceTokenKeys) == 0 {
+			l.fail("SERVICE_TOKEN_KEYS", "required in production")
+		}
+		if jobs.ExecutionMode == ExecutionModeCron && jobs.CronSecret == "" {
+			l.fail("CRON_SECRET", "required in production in cron mode")
+		}
+	}
+}
+
+func oneOf(l *loader, key, value string, valid ...string) {
+	for _, candidate := range valid {
+		if value == candidate {
+			return
+		}
+	}
+	l.fail(key, "invalid value %q, expected one of %s", value, strings.Join(valid, ", "))
+}
+
+func validateMainAPIURL(l *loader, mainAPIURL string, production bool) {
+	if mainAPIURL == "" {
+		l.fail("MAIN_API_URL", "required in production")
+		return
+	}
+
+	parsed, err := url.Parse(mainAPIURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		l.fail("MAIN_API_URL", "invalid URL %q", mainAPIURL)
+		return
+	}
+	if production && (parsed.Hostname() == "localhost" || parsed.Hostname() == "127.0.0.1") {
+		l.fail("MAIN_API_URL", "must not point at %s in production", parsed.Hostname())
+	}
+}
+
+// validateStripe requires a live key in production and forbids one elsewhere: test payments must
+// never move real money, and production must never run on test data
+func validateStripe(l *loader, jobs *JobsConfig, production bool) {
+	mode := StripeKeyMode(jobs.StripeSecretKey)
+	switch {
+	case jobs.StripeSecretKey == "":
+		if production {
+			l.fail("STRIPE_SECRET_KEY", "required in production")
+		}
+	case mode == "":
+		l.fail("STRIPE_SECRET_KEY", "not a Stripe secret or restricted key (sk_ or rk_)")
+	case production && mode != "live":
+		l.fail("STRIPE_SECRET_KEY", "test key not allowed in production")
+	case !production && mode == "live":
+		l.fail("STRIPE_SECRET_KEY", "live key only allowed in production")
+	}
+
+	if production && jobs.StripeTestMode {
+		l.fail("STRIPE_TEST_MODE", "must be false in production")
+	}
+	if !production && !jobs.StripeTestMode {
+		l.fail("STRIPE_TEST_MODE", "may only be false in production")
+	}
+
+	if jobs.StripeAPIBase == "" {
+		return
+	}
+	if production {
+		l.fail("STRIPE_API_BASE", "not allowed in production, payments must go to Stripe")
+		return
+	}
+	parsed, err := url.Parse(jobs.StripeAPIBase)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		l.fail("STRIPE_API_BASE", "invalid URL %q", jobs.StripeAPIBase)
+	}
+}
+
+// StripeKeyMode returns "test" or "live" for a Stripe secret or restricted key, or "" for anything
+// else
+func StripeKeyMode(key string) string {
+	switch {
+	case strings.HasPrefix(key, "sk_test_"), strings.HasPrefix(key, "rk_test_"):
+		return "test"
+	case strings.HasPrefix(key, "sk_live_"), strings.HasPrefix(key, "rk_live_"):
+		return "live"
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+)
+
+// AdvanceClockRequest moves sandbox time forward
+type AdvanceClockRequest struct {
+	Duration string `json:"duration" binding:"required"` // e.g. "24h" or "90m"
+}
+
+// GetClock handles GET /api/jobs/clock
+func GetClock(c *gin.Context) {
+	clock, ok := sandboxClock(c)
+	if !ok {
+		return
+	}
+	respondClock(c, clock)
+}
+
+// AdvanceClock handles POST /api/jobs/clock/advance. Holds, grace periods and review deadlines
+// are evaluated at the advanced time from then on; trigger a job to act on them right away.
+func AdvanceClock(c *gin.Context) {
+	clock, ok := sandboxClock(c)
+	if !ok {
+		return
+	}
+
+	var req AdvanceClockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil || d <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "duration must be a positive Go duration such as 24h, got " + req.Duration,
+		})
+		return
+	}
+
+	clock.Advance(d)
+	slog.WarnContext(c.Request.Context(), "Virtual clock advanced", "by", d.String(), "offset", clock.Offset().String(), "admin_id", c.GetString("userID"))
+	respondClock(c, clock)
+}
+
+// ResetClock handles POST /api/jobs/clock/reset, bringing sandbox time back to real time
+func ResetClock(c *gin.Context) {
+	clock, ok := sandboxClock(c)
+	if !ok {
+		return
+	}
+
+	clock.Reset()
+	slog.WarnContext(c.Request.Context(), "Virtual clock reset", "admin_id", c.GetString("userID"))
+	respondClock(c, clock)
+}
+
+// sandboxClock returns the virtual process clock, responding with 403 outside a sandbox: Stripe
+// test mode with VIRTUAL_CLOCK set
+func sandboxClock(c *gin.Context) (*services.VirtualClock, bool) {
+	clock, virtual := services.VirtualProcessClock()
+	if !virtual || !config.GetJobsConfig().StripeTestMode {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Virtual time is only available in test mode with VIRTUAL_CLOCK enabled",
+		})
+		return nil, false
+	}
+	return clock, true
+}
+
+func respondClock(c *gin.Context, clock *services.VirtualClock) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"now":         clock.Now(),
+		"realTime":    time.Now(),
+		"offset":      clock.Offset().String(),
+		"offsetHours": clock.Offset().Hours(),
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockEndpoints(t *testing.T) {
+	router := setupRouter()
+	router.GET("/api/jobs/clock", GetClock)
+	router.POST("/api/jobs/clock/advance", AdvanceClock)
+	router.POST("/api/jobs/clock/reset", ResetClock)
+
+	call := func(method, path, body string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	t.Run("should refuse without a virtual clock", func(t *testing.T) {
+		code, response := call(http.MethodPost, "/api/jobs/clock/advance", `{"duration": "24h"}`)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Contains(t, response["error"], "VIRTUAL_CLOCK")
+	})
+
+	clock := services.NewVirtualClock(services.SystemClock{})
+	previous := services.SetClock(clock)
+	defer services.SetClock(previous)
+
+	t.Run("should advance and reset sandbox time", func(t *testing.T) {
+		code, response := call(http.MethodPost, "/api/jobs/clock/advance", `{"duration": "24h"}`)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "24h0m0s", response["offset"])
+
+		code, response = call(http.MethodPost, "/api/jobs/clock/advance", `{"duration": "90m"}`)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 25.5, response["offsetHours"])
+
+		code, response = call(http.MethodGet, "/api/jobs/clock", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "25h30m0s", response["offset"])
+
+		code, response = call(http.MethodPost, "/api/jobs/clock/reset", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "0s", response["offset"])
+	})
+
+	t.Run("should reject an invalid duration", func(t *testing.T) {
+		for _, body := range []string{`{"duration": "1 day"}`, `{"duration": "-1h"}`, `{}`} {
+			code, _ := call(http.MethodPost, "/api/jobs/clock/advance", body)
+			assert.Equal(t, http.StatusBadRequest, code, body)
+		}
+		assert.Equal(t, "0s", clock.Offset().String())
+	})
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+)
+
+// ResolveEscrowRequest represents the request to force-resolve a dead-lettered escrow
+type ResolveEscrowRequest struct {
+	Resolution string `json:"resolution" binding:"required,oneof=released refunded resolved"`
+	Notes      string `json:"notes" binding:"required"`
+}
+
+// ReviewDecisionRequest represents a reviewer's decision on an escrow under review
+type ReviewDecisionRequest struct {
+	Notes string `json:"notes" binding:"required"`
+}
+
+// ReviewRefundRequest represents a full or partial refund decided by a reviewer
+type ReviewRefundRequest struct {
+	Amount float64 `json:"amount" binding:"gte=0"` // Zero refunds the full escrow amount
+	Notes  string  `json:"notes" binding:"required"`
+}
+
+// ReviewDisputeRequest represents a dispute opened by a reviewer
+type ReviewDisputeRequest struct {
+	RequestedAction string `json:"requestedAction" binding:"required,oneof=release refund partial_refund"`
+	Notes           string `json:"notes" binding:"required"`
+}
+
+// ListDeadLetteredEscrows handles GET /api/jobs/escrows/dead-letter
+func ListDeadLetteredEscrows(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "Retrieving dead-lettered escrows")
+
+	escrows, err := services.NewPaymentService().WithContext(c.Request.Context()).ListDeadLetteredEscrows()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list dead-lettered escrows", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"escrows": escrows,
+		"count":   len(escrows),
+	})
+}
+
+// RetryDeadLetteredEscrow handles POST /api/jobs/escrows/dead-letter/:escrowId/retry
+func RetryDeadLetteredEscrow(c *gin.Context) {
+	escrowID := c.Param("escrowId")
+	adminID := c.GetString("userID")
+	slog.InfoContext(c.Request.Context(), "Dead-lettered escrow retry requested", "escrow_id", escrowID, "admin_id", adminID)
+
+	if err := services.NewPaymentService().WithContext(c.Request.Context()).RetryDeadLetteredEscrow(escrowID, adminID); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Dead-lettered escrow retry failed", "escrow_id", escrowID, "error", err)
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "Escrow released successfully",
+		"escrowId": escrowID,
+	})
+}
+
+// ResolveDeadLetteredEscrow handles POST /api/jobs/escrows/dead-letter/:escrowId/resolve
+func ResolveDeadLetteredEscrow(c *gin.Context) {
+	escrowID := c.Param("escrowId")
+	adminID := c.GetString("userID")
+
+	var req ResolveEscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Resolving dead-lettered escrow", "escrow_id", escrowID, "resolution", req.Resolution, "admin_id", adminID)
+
+	escrow, err := services.NewPaymentService().WithContext(c.Request.Context()).ForceResolveEscrow(escrowID, req.Resolution, req.Notes, adminID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to resolve dead-lettered escrow", "escrow_id", escrowID, "error", err)
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Escrow resolved successfully",
+		"escrow":  escrow,
+	})
+}
+
+// respondDeadLetterError maps a dead-letter action error to the HTTP response
+func respondDeadLetterError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, services.ErrEscrowNotDeadLettered) {
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// ListEscrowsUnderReview handles GET /api/jobs/escrows/review
+func ListEscrowsUnderReview(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "Retrieving escrows under review")
+
+	escrows, err := services.NewPaymentService().WithContext(c.Request.Context()).ListEscrowsUnderReview()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list escrows under review", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"escrows": escrows,
+		"count":   len(escrows),
+	})
+}
+
+// ApproveReviewedEscrow handles POST /api/jobs/escrows/review/:escrowId/approve
+func ApproveReviewedEscrow(c *gin.Context) {
+	escrowID := c.Param("escrowId")
+	reviewerID := c.GetString("userID")
+
+	var req ReviewDecisionRequest
+	if !bindReviewRequest(c, &req) {
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Approving release of reviewed escrow", "escrow_id", escrowID, "reviewer_id", reviewerID)
+
+	escrow, err := services.NewPaymentService().WithContext(c.Request.Context()).ApproveReviewedEscrow(escrowID, reviewerID, req.Notes)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to approve reviewed escrow", "escrow_id", escrowID, "error", err)
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Escrow released successfully",
+		"escrow":  escrow,
+	})
+}
+
+// RefundReviewedEscrow handles POST /api/jobs/escrows/review/:escrowId/refund
+func RefundReviewedEscrow(c *gin.Context) {
+	escrowID := c.Param("escrowId")
+	reviewerID := c.GetString("userID")
+
+	var req ReviewRefundRequest
+	if !bindReviewRequest(c, &req) {
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Refunding reviewed escrow", "escrow_id", escrowID, "amount", req.Amount, "reviewer_id", reviewerID)
+
+	escrow, err := services.NewPaymentService().WithContext(c.Request.Context()).RefundReviewedEscrow(escrowID, req.Amount, reviewerID, req.Notes)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to refund reviewed escrow", "escrow_id", escrowID, "error", err)
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Escrow refunded successfully",
+		"escrow":  escrow,
+	})
+}
+
+// DisputeReviewedEscrow handles POST /api/jobs/escrows/review/:escrowId/dispute
+func DisputeReviewedEscrow(c *gin.Context) {
+	escrowID := c.Param("escrowId")
+	reviewerID := c.GetString("userID")
+
+	var req ReviewDisputeRequest
+	if !bindReviewRequest(c, &req) {
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Opening dispute for reviewed escrow", "escrow_id", escrowID, "reviewer_id", reviewerID)
+
+	dispute, err := services.NewPaymentService().WithContext(c.Request.Context()).DisputeReviewedEscrow(escrowID, req.RequestedAction, reviewerID, req.Notes)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to open dispute for reviewed escrow", "escrow_id", escrowID, "error", err)
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dispute opened successfully",
+		"dispute": dispute,
+	})
+}
+
+// bindReviewRequest binds a review decision body, responding with 400 when it is invalid
+func bindReviewRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// respondReviewError maps a review decision error to the HTTP response
+func respondReviewError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, services.ErrEscrowNotUnderReview) {
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+)
+
+// Readiness handles GET /ready: 200 when every dependency in checks is usable, 503 otherwise.
+// Each dependency is reported with its status and how long the check took.
+func Readiness(checks []services.ReadinessCheck) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		readiness := services.CheckReadiness(c.Request.Context(), checks)
+
+		status := http.StatusOK
+		if !readiness.Ready {
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, gin.H{
+			"service":   "goalhero-payment-jobs",
+			"readiness": readiness,
+		})
+	}
+}
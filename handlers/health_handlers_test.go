@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	ok := services.ReadinessCheck{Name: "firestore", Check: func(context.Context) (string, error) { return "", nil }}
+	down := services.ReadinessCheck{Name: "stripe", Check: func(context.Context) (string, error) { return "", errors.New("invalid api key") }}
+
+	tests := []struct {
+		name   string
+		checks []services.ReadinessCheck
+		status int
+	}{
+		{"all dependencies ok", []services.ReadinessCheck{ok}, http.StatusOK},
+		{"a dependency down", []services.ReadinessCheck{ok, down}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupRouter()
+			router.GET("/ready", Readiness(tt.checks))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+			assert.Equal(t, tt.status, w.Code)
+
+			var response struct {
+				Readiness services.Readiness `json:"readiness"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Len(t, response.Readiness.Dependencies, len(tt.checks))
+			assert.Equal(t, tt.status == http.StatusOK, response.Readiness.Ready)
+		})
+	}
+}
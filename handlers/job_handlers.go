@@ -1,17 +1,19 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
 )
 
 // GetJobStatuses handles GET /api/jobs/status
 func GetJobStatuses(c *gin.Context) {
-	log.Printf("[GetJobStatuses] Retrieving job statuses")
+	slog.DebugContext(c.Request.Context(), "Retrieving job statuses")
 
 	statuses := services.GetJobStatuses()
 
@@ -25,7 +27,7 @@ func GetJobStatuses(c *gin.Context) {
 
 // GetJobHealth handles GET /api/jobs/health
 func GetJobHealth(c *gin.Context) {
-	log.Printf("[GetJobHealth] Performing health check")
+	slog.DebugContext(c.Request.Context(), "Performing job health check")
 
 	health := services.GetJobHealth()
 
@@ -43,28 +45,71 @@ func GetJobHealth(c *gin.Context) {
 // TriggerJob handles POST /api/jobs/trigger/:jobName
 func TriggerJob(c *gin.Context) {
 	jobName := c.Param("jobName")
-	log.Printf("[TriggerJob] Manual trigger requested for job: %s", jobName)
-
-	var err error
-	switch jobName {
-	case "rating-reminder":
-		err = services.TriggerRatingReminder()
-	case "auto-release":
-		err = services.TriggerAutoRelease()
-	case "dispute-escalation":
-		err = services.TriggerDisputeEscalation()
-	default:
+	adminID := c.GetString("userID")
+	slog.InfoContext(c.Request.Context(), "Manual job trigger requested", "job_name", jobName, "admin_id", adminID)
+
+	runID, err := services.TriggerJob(jobName, adminID)
+	if errors.Is(err, services.ErrUnknownJob) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid job name",
-			"validJobs": []string{"rating-reminder", "auto-release", "dispute-escalation"},
+			"success":   false,
+			"error":     "Invalid job name",
+			"validJobs": services.JobSlugs(),
 		})
 		return
 	}
 
 	if err != nil {
-		log.Printf("[TriggerJob] Failed to trigger job %s: %v", jobName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		slog.ErrorContext(c.Request.Context(), "Failed to trigger job", "job_name", jobName, "error", err)
+		respondTriggerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Job %s triggered successfully", jobName),
+		"jobName": jobName,
+		"runId":   runID,
+	})
+}
+
+// RunCronJob handles GET /api/jobs/cron/:jobName, called by Vercel Cron. The job runs within the
+// request, bounded by CRON_TIME_BUDGET, and the response carries the finished run.
+func RunCronJob(c *gin.Context) {
+	jobName := c.Param("jobName")
+	slog.InfoContext(c.Request.Context(), "Cron invocation received", "job_name", jobName)
+
+	run, err := services.RunCronJob(c.Request.Context(), jobName)
+	if errors.Is(err, services.ErrUnknownJob) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success":   false,
+			"error":     err.Error(),
+			"validJobs": services.JobSlugs(),
+		})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Cron run failed to start", "job_name", jobName, "error", err)
+		respondTriggerError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if run.Status == services.JobRunStatusFailed {
+		status = http.StatusInternalServerError
+	}
+	c.JSON(status, gin.H{
+		"success": run.Status != services.JobRunStatusFailed,
+		"run":     run,
+	})
+}
+
+// GetJobRun handles GET /api/jobs/runs/:runId
+func GetJobRun(c *gin.Context) {
+	runID := c.Param("runId")
+
+	run, err := services.GetJobRun(runID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
 			"error":   err.Error(),
 		})
@@ -73,14 +118,32 @@ func TriggerJob(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": fmt.Sprintf("Job %s triggered successfully", jobName),
-		"jobName": jobName,
+		"run":     run,
+	})
+}
+
+// respondTriggerError maps a job trigger error to the HTTP response
+func respondTriggerError(c *gin.Context, err error) {
+	var alreadyRunning *services.JobAlreadyRunningError
+	if errors.As(err, &alreadyRunning) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success":     false,
+			"error":       err.Error(),
+			"activeRunId": alreadyRunning.ActiveRunID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error":   err.Error(),
 	})
 }
 
 // UpdateJobConfig handles POST /api/jobs/config
 func UpdateJobConfig(c *gin.Context) {
-	log.Printf("[UpdateJobConfig] Updating job configuration")
+	adminID := c.GetString("userID")
+	slog.InfoContext(c.Request.Context(), "Updating job configuration", "admin_id", adminID)
 
 	var newConfig services.JobConfig
 	if err := c.ShouldBindJSON(&newConfig); err != nil {
@@ -93,7 +156,7 @@ func UpdateJobConfig(c *gin.Context) {
 	}
 
 	if err := services.UpdateJobConfig(&newConfig); err != nil {
-		log.Printf("[UpdateJobConfig] Failed to update configuration: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to update job configuration", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -110,7 +173,7 @@ func UpdateJobConfig(c *gin.Context) {
 
 // GetJobConfig handles GET /api/jobs/config
 func GetJobConfig(c *gin.Context) {
-	log.Printf("[GetJobConfig] Retrieving job configuration")
+	slog.DebugContext(c.Request.Context(), "Retrieving job configuration")
 
 	config := services.GetJobConfig()
 	if config == nil {
@@ -127,9 +190,20 @@ func GetJobConfig(c *gin.Context) {
 	})
 }
 
+// GetEffectiveConfig handles GET /api/jobs/config/effective: every setting the service loaded,
+// where it came from (default, file or env), with secrets redacted
+func GetEffectiveConfig(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "Retrieving effective configuration")
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"settings": config.EffectiveConfig(),
+	})
+}
+
 // RestartJobs handles POST /api/jobs/restart
 func RestartJobs(c *gin.Context) {
-	log.Printf("[RestartJobs] Restarting job system")
+	slog.InfoContext(c.Request.Context(), "Restarting job system", "admin_id", c.GetString("userID"))
 
 	// TODO: Implement job restart functionality
 	c.JSON(http.StatusNotImplemented, gin.H{
@@ -138,57 +212,56 @@ func RestartJobs(c *gin.Context) {
 	})
 }
 
+// internalTriggeredBy is recorded as TriggeredBy for runs started through the internal endpoints
+func internalTriggeredBy(c *gin.Context) string {
+	return "service:" + c.GetString("serviceID")
+}
+
 // Internal trigger handlers for inter-service communication
 func TriggerRatingReminder(c *gin.Context) {
-	log.Printf("[Internal] Rating reminder trigger received")
+	slog.InfoContext(c.Request.Context(), "Internal job trigger received", "job_name", "rating_reminder", "service_id", c.GetString("serviceID"))
 
-	err := services.TriggerRatingReminder()
+	runID, err := services.TriggerRatingReminder(internalTriggeredBy(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
+		respondTriggerError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Rating reminder job triggered",
+		"runId":   runID,
 	})
 }
 
 func TriggerAutoRelease(c *gin.Context) {
-	log.Printf("[Internal] Auto release trigger received")
+	slog.InfoContext(c.Request.Context(), "Internal job trigger received", "job_name", "auto_release", "service_id", c.GetString("serviceID"))
 
-	err := services.TriggerAutoRelease()
+	runID, err := services.TriggerAutoRelease(internalTriggeredBy(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
+		respondTriggerError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Auto release job triggered",
+		"runId":   runID,
 	})
 }
 
 func TriggerDisputeEscalation(c *gin.Context) {
-	log.Printf("[Internal] Dispute escalation trigger received")
+	slog.InfoContext(c.Request.Context(), "Internal job trigger received", "job_name", "dispute_escalation", "service_id", c.GetString("serviceID"))
 
-	err := services.TriggerDisputeEscalation()
+	runID, err := services.TriggerDisputeEscalation(internalTriggeredBy(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
+		respondTriggerError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Dispute escalation job triggered",
+		"runId":   runID,
 	})
 }
\ No newline at end of file
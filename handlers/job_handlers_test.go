@@ -3,11 +3,14 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -144,6 +147,26 @@ func TestGetJobConfig(t *testing.T) {
 	})
 }
 
+func TestGetEffectiveConfig(t *testing.T) {
+	t.Setenv("CRON_SECRET", "cron-secret-0123456789")
+	config.InitJobsConfig()
+	defer config.InitJobsConfig()
+
+	router := setupRouter()
+	router.GET("/config/effective", GetEffectiveConfig)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config/effective", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Settings []config.EffectiveSetting `json:"settings"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotContains(t, w.Body.String(), "cron-secret-0123456789")
+	assert.Contains(t, response.Settings, config.EffectiveSetting{Key: "CRON_SECRET", Value: "****6789", Source: config.SourceEnv, Secret: true})
+}
+
 func TestRestartJobs(t *testing.T) {
 	t.Run("should return not implemented error", func(t *testing.T) {
 		router := setupRouter()
@@ -199,4 +222,136 @@ func TestInternalTriggerHandlers(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestGetJobRun(t *testing.T) {
+	t.Run("should return not found for unknown run", func(t *testing.T) {
+		router := setupRouter()
+		router.GET("/runs/:runId", GetJobRun)
+
+		req, _ := http.NewRequest(http.MethodGet, "/runs/unknown-run", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.False(t, response["success"].(bool))
+	})
+}
+
+func TestRespondTriggerError(t *testing.T) {
+	t.Run("should return conflict with active run ID when job is already running", func(t *testing.T) {
+		router := setupRouter()
+		router.POST("/trigger", func(c *gin.Context) {
+			respondTriggerError(c, &services.JobAlreadyRunningError{JobKey: "auto_release", ActiveRunID: "run-123"})
+		})
+
+		req, _ := http.NewRequest(http.MethodPost, "/trigger", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.False(t, response["success"].(bool))
+		assert.Equal(t, "run-123", response["activeRunId"])
+	})
+}
+
+func TestResolveDeadLetteredEscrow(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{"missing notes", `{"resolution": "released"}`},
+		{"invalid resolution", `{"resolution": "deleted", "notes": "paid manually"}`},
+		{"malformed body", `{"resolution":`},
+	}
+
+	for _, tc := range testCases {
+		t.Run("should reject "+tc.name, func(t *testing.T) {
+			router := setupRouter()
+			router.POST("/escrows/dead-letter/:escrowId/resolve", ResolveDeadLetteredEscrow)
+
+			req, _ := http.NewRequest(http.MethodPost, "/escrows/dead-letter/escrow_1/resolve", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestRespondDeadLetterError(t *testing.T) {
+	t.Run("should return conflict when escrow is not dead-lettered", func(t *testing.T) {
+		router := setupRouter()
+		router.POST("/retry", func(c *gin.Context) {
+			respondDeadLetterError(c, fmt.Errorf("%w, current status: held", services.ErrEscrowNotDeadLettered))
+		})
+
+		req, _ := http.NewRequest(http.MethodPost, "/retry", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestReviewDecisionValidation(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"approval without notes", "/escrows/review/escrow_1/approve", `{}`},
+		{"negative refund", "/escrows/review/escrow_1/refund", `{"amount": -5, "notes": "no show"}`},
+		{"refund without notes", "/escrows/review/escrow_1/refund", `{"amount": 5}`},
+		{"dispute with unknown action", "/escrows/review/escrow_1/dispute", `{"requestedAction": "cancel", "notes": "unclear"}`},
+		{"dispute without action", "/escrows/review/escrow_1/dispute", `{"notes": "unclear"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run("should reject "+tc.name, func(t *testing.T) {
+			router := setupRouter()
+			router.POST("/escrows/review/:escrowId/approve", ApproveReviewedEscrow)
+			router.POST("/escrows/review/:escrowId/refund", RefundReviewedEscrow)
+			router.POST("/escrows/review/:escrowId/dispute", DisputeReviewedEscrow)
+
+			req, _ := http.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestRespondReviewError(t *testing.T) {
+	t.Run("should return conflict when escrow is not under review", func(t *testing.T) {
+		router := setupRouter()
+		router.POST("/approve", func(c *gin.Context) {
+			respondReviewError(c, fmt.Errorf("%w, current status: released", services.ErrEscrowNotUnderReview))
+		})
+
+		req, _ := http.NewRequest(http.MethodPost, "/approve", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
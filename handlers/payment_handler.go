@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -21,6 +21,12 @@ func NewPaymentHandler() *PaymentHandler {
 	}
 }
 
+// service returns the payment service scoped to the request, so its logs and Stripe metadata
+// carry the request ID
+func (h *PaymentHandler) service(c *gin.Context) *services.PaymentService {
+	return h.paymentService.WithContext(c.Request.Context())
+}
+
 // CreateGamePaymentRequest represents the request to create a game payment
 type CreateGamePaymentRequest struct {
 	UserID        string  `json:"userId" binding:"required"`
@@ -34,7 +40,7 @@ type CreateGamePaymentRequest struct {
 func (h *PaymentHandler) CreateGamePayment(c *gin.Context) {
 	var req CreateGamePaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[PaymentHandler] Invalid request: %v", err)
+		slog.WarnContext(c.Request.Context(), "Invalid request", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Invalid request format",
@@ -43,9 +49,9 @@ func (h *PaymentHandler) CreateGamePayment(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[PaymentHandler] Creating game payment for user %s, game %s", req.UserID, req.GameID)
+	slog.InfoContext(c.Request.Context(), "Creating game payment", "user_id", req.UserID, "game_id", req.GameID)
 
-	payment, result, err := h.paymentService.CreateGamePayment(
+	payment, result, err := h.service(c).CreateGamePayment(
 		req.UserID,
 		req.GameID,
 		req.ApplicationID,
@@ -54,7 +60,7 @@ func (h *PaymentHandler) CreateGamePayment(c *gin.Context) {
 	)
 
 	if err != nil {
-		log.Printf("[PaymentHandler] Failed to create payment: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to create payment", "game_id", req.GameID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to create payment",
@@ -80,7 +86,7 @@ type ConfirmPaymentRequest struct {
 func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
 	var req ConfirmPaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[PaymentHandler] Invalid request: %v", err)
+		slog.WarnContext(c.Request.Context(), "Invalid request", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Invalid request format",
@@ -89,11 +95,11 @@ func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[PaymentHandler] Confirming payment: %s", req.PaymentID)
+	slog.InfoContext(c.Request.Context(), "Confirming payment", "payment_id", req.PaymentID)
 
-	payment, escrow, err := h.paymentService.ConfirmGamePayment(req.PaymentID)
+	payment, escrow, err := h.service(c).ConfirmGamePayment(req.PaymentID)
 	if err != nil {
-		log.Printf("[PaymentHandler] Failed to confirm payment: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to confirm payment", "payment_id", req.PaymentID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to confirm payment",
@@ -124,7 +130,7 @@ type ReleaseEscrowRequest struct {
 func (h *PaymentHandler) ReleaseEscrow(c *gin.Context) {
 	var req ReleaseEscrowRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[PaymentHandler] Invalid request: %v", err)
+		slog.WarnContext(c.Request.Context(), "Invalid request", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Invalid request format",
@@ -133,11 +139,11 @@ func (h *PaymentHandler) ReleaseEscrow(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[PaymentHandler] Releasing escrow: %s", req.EscrowID)
+	slog.InfoContext(c.Request.Context(), "Releasing escrow", "escrow_id", req.EscrowID)
 
-	err := h.paymentService.ProcessEscrowRelease(req.EscrowID, req.ReleaseReason)
+	err := h.service(c).ProcessEscrowRelease(req.EscrowID, req.ReleaseReason)
 	if err != nil {
-		log.Printf("[PaymentHandler] Failed to release escrow: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to release escrow", "escrow_id", req.EscrowID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to release escrow",
@@ -164,7 +170,7 @@ type RefundPaymentRequest struct {
 func (h *PaymentHandler) RefundPayment(c *gin.Context) {
 	var req RefundPaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[PaymentHandler] Invalid request: %v", err)
+		slog.WarnContext(c.Request.Context(), "Invalid request", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Invalid request format",
@@ -173,11 +179,11 @@ func (h *PaymentHandler) RefundPayment(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[PaymentHandler] Refunding payment: %s, Amount: €%.2f", req.PaymentID, req.Amount)
+	slog.InfoContext(c.Request.Context(), "Refunding payment", "payment_id", req.PaymentID, "amount", req.Amount)
 
-	err := h.paymentService.ProcessRefund(req.PaymentID, req.Amount, req.Reason)
+	err := h.service(c).ProcessRefund(req.PaymentID, req.Amount, req.Reason)
 	if err != nil {
-		log.Printf("[PaymentHandler] Failed to process refund: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to process refund", "payment_id", req.PaymentID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to process refund",
@@ -196,11 +202,11 @@ func (h *PaymentHandler) RefundPayment(c *gin.Context) {
 
 // GetEligibleEscrowReleases handles GET /api/payments/escrow/eligible
 func (h *PaymentHandler) GetEligibleEscrowReleases(c *gin.Context) {
-	log.Printf("[PaymentHandler] Getting eligible escrow releases")
+	slog.DebugContext(c.Request.Context(), "Getting eligible escrow releases")
 
-	escrows, err := h.paymentService.GetEligibleEscrowReleases()
+	escrows, err := h.service(c).GetEligibleEscrowReleases()
 	if err != nil {
-		log.Printf("[PaymentHandler] Failed to get eligible escrow releases: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to get eligible escrow releases", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to get eligible escrow releases",
@@ -218,11 +224,11 @@ func (h *PaymentHandler) GetEligibleEscrowReleases(c *gin.Context) {
 
 // ProcessEligibleReleases handles POST /api/payments/escrow/process-eligible
 func (h *PaymentHandler) ProcessEligibleReleases(c *gin.Context) {
-	log.Printf("[PaymentHandler] Processing eligible escrow releases")
+	slog.InfoContext(c.Request.Context(), "Processing eligible escrow releases")
 
-	escrows, err := h.paymentService.GetEligibleEscrowReleases()
+	escrows, err := h.service(c).GetEligibleEscrowReleases()
 	if err != nil {
-		log.Printf("[PaymentHandler] Failed to get eligible escrow releases: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to get eligible escrow releases", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to get eligible escrow releases",
@@ -236,9 +242,9 @@ func (h *PaymentHandler) ProcessEligibleReleases(c *gin.Context) {
 	var errors []string
 
 	for _, escrow := range escrows {
-		err := h.paymentService.ProcessEscrowRelease(escrow.ID, "automatic_release_job")
+		err := h.service(c).ProcessEscrowRelease(escrow.ID, "automatic_release_job")
 		if err != nil {
-			log.Printf("[PaymentHandler] Failed to process escrow %s: %v", escrow.ID, err)
+			slog.ErrorContext(c.Request.Context(), "Failed to process escrow", "escrow_id", escrow.ID, "error", err)
 			failed++
 			errors = append(errors, fmt.Sprintf("Escrow %s: %v", escrow.ID, err))
 		} else {
@@ -266,7 +272,7 @@ func (h *PaymentHandler) GetPaymentStatus(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[PaymentHandler] Getting payment status: %s", paymentID)
+	slog.DebugContext(c.Request.Context(), "Getting payment status", "payment_id", paymentID)
 
 	// This would require implementing a method to get payment by ID
 	c.JSON(http.StatusNotImplemented, gin.H{
@@ -307,7 +313,7 @@ type UpdateEscrowRatingRequest struct {
 func (h *PaymentHandler) UpdateEscrowRating(c *gin.Context) {
 	var req UpdateEscrowRatingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[PaymentHandler] Invalid request: %v", err)
+		slog.WarnContext(c.Request.Context(), "Invalid request", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Invalid request format",
@@ -316,11 +322,11 @@ func (h *PaymentHandler) UpdateEscrowRating(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[PaymentHandler] Updating escrow rating: %s, Rating: %.1f", req.EscrowID, req.Rating)
+	slog.InfoContext(c.Request.Context(), "Updating escrow rating", "escrow_id", req.EscrowID, "rating", req.Rating)
 
-	err := h.paymentService.UpdateEscrowRating(req.EscrowID, req.Rating, req.ReviewerID)
+	err := h.service(c).UpdateEscrowRating(req.EscrowID, req.Rating, req.ReviewerID)
 	if err != nil {
-		log.Printf("[PaymentHandler] Failed to update escrow rating: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to update escrow rating", "escrow_id", req.EscrowID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to update escrow rating",
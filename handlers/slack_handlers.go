@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+)
+
+// slackRequestMaxAge bounds how old a signed Slack request may be, so captured requests can't be replayed
+const slackRequestMaxAge = 5 * time.Minute
+
+// pendingSlackInteractions counts button presses that were acknowledged but are still being decided
+var pendingSlackInteractions atomic.Int64
+
+// slackInteraction is the part of a Slack block_actions payload used to decide reviews
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// HandleSlackInteraction handles POST /api/jobs/slack/interactions, called by Slack when a
+// review button is pressed. Slack expects an acknowledgement within 3 seconds, so the button press
+// is acknowledged first and the decision runs in the background; its outcome is posted back to
+// the message's response_url.
+func HandleSlackInteraction(c *gin.Context) {
+	jobsConf := config.GetJobsConfig()
+	if jobsConf.SlackSigningSecret == "" {
+		slog.WarnContext(c.Request.Context(), "SLACK_SIGNING_SECRET not configured, rejecting Slack interaction")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "Slack interactions are not configured",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to read request body",
+		})
+		return
+	}
+
+	timestamp := c.GetHeader("X-Slack-Request-Timestamp")
+	signature := c.GetHeader("X-Slack-Signature")
+	if err := verifySlackSignature(jobsConf.SlackSigningSecret, timestamp, signature, body, time.Now()); err != nil {
+		slog.WarnContext(c.Request.Context(), "Rejected Slack interaction", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Invalid Slack signature",
+		})
+		return
+	}
+
+	interaction, err := parseSlackInteraction(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid interaction payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Only button presses are acted on; acknowledge anything else
+	if interaction.Type != "block_actions" || len(interaction.Actions) == 0 {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	// The decision outlives the request, so it must not be cancelled when Slack's request ends
+	ctx := context.WithoutCancel(c.Request.Context())
+	adminID, allowed := jobsConf.SlackAdminUsers[interaction.User.ID]
+	pendingSlackInteractions.Add(1)
+	go func() {
+		defer pendingSlackInteractions.Add(-1)
+		decideSlackInteraction(ctx, interaction, adminID, allowed)
+	}()
+
+	c.Status(http.StatusOK)
+}
+
+// decideSlackInteraction carries out a review button press and posts the outcome to Slack
+func decideSlackInteraction(ctx context.Context, interaction *slackInteraction, adminID string, allowed bool) {
+	action := interaction.Actions[0]
+	paymentService := services.NewPaymentService().WithContext(ctx)
+
+	if !allowed {
+		slog.WarnContext(ctx, "Slack user is not mapped to an admin", "slack_user_id", interaction.User.ID, "slack_username", interaction.User.Username)
+		respondToSlack(paymentService, interaction.ResponseURL, services.SlackMessage{
+			ResponseType: "ephemeral",
+			Text:         "⛔ You are not allowed to decide escrow reviews. Ask an admin to add your Slack user to SLACK_ADMIN_USERS.",
+		})
+		return
+	}
+
+	slog.InfoContext(ctx, "Slack review action", "action", action.ActionID, "escrow_id", action.Value, "slack_user_id", interaction.User.ID, "admin_id", adminID)
+
+	message, err := paymentService.DecideReviewFromSlack(action.ActionID, action.Value, adminID, interaction.User.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to decide escrow from Slack", "escrow_id", action.Value, "error", err)
+		message = services.SlackMessage{
+			ResponseType: "ephemeral",
+			Text:         fmt.Sprintf("⚠️ Could not decide escrow %s: %v", action.Value, err),
+		}
+	}
+
+	respondToSlack(paymentService, interaction.ResponseURL, message)
+}
+
+// WaitForSlackInteractions waits for acknowledged Slack button presses to be decided or for ctx
+// to expire
+func WaitForSlackInteractions(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for pendingSlackInteractions.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %d Slack interaction(s): %w", pendingSlackInteractions.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// verifySlackSignature checks the request was signed by Slack with the signing secret and is recent
+func verifySlackSignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	if timestamp == "" || signature == "" {
+		return errors.New("missing Slack signature headers")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Slack request timestamp %q", timestamp)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > slackRequestMaxAge || age < -slackRequestMaxAge {
+		return fmt.Errorf("Slack request timestamp is %v away from now", age.Round(time.Second))
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("Slack signature mismatch")
+	}
+	return nil
+}
+
+// parseSlackInteraction decodes the form-encoded payload Slack posts for interactions
+func parseSlackInteraction(body []byte) (*slackInteraction, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	payload := form.Get("payload")
+	if payload == "" {
+		return nil, errors.New("missing payload")
+	}
+
+	var interaction slackInteraction
+	if err := json.Unmarshal([]byte(payload), &interaction); err != nil {
+		return nil, err
+	}
+	return &interaction, nil
+}
+
+func respondToSlack(paymentService *services.PaymentService, responseURL string, message services.SlackMessage) {
+	if err := paymentService.RespondToSlackAction(responseURL, message); err != nil {
+		slog.Error("Failed to respond to Slack", "error", err)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signSlackRequest(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte("payload=%7B%7D")
+	signature := signSlackRequest("signing_secret", timestamp, body)
+
+	assert.NoError(t, verifySlackSignature("signing_secret", timestamp, signature, body, now))
+
+	t.Run("should reject a tampered body", func(t *testing.T) {
+		assert.Error(t, verifySlackSignature("signing_secret", timestamp, signature, []byte("payload=tampered"), now))
+	})
+
+	t.Run("should reject the wrong secret", func(t *testing.T) {
+		assert.Error(t, verifySlackSignature("other_secret", timestamp, signature, body, now))
+	})
+
+	t.Run("should reject stale requests", func(t *testing.T) {
+		assert.Error(t, verifySlackSignature("signing_secret", timestamp, signature, body, now.Add(10*time.Minute)))
+	})
+
+	t.Run("should reject missing headers", func(t *testing.T) {
+		assert.Error(t, verifySlackSignature("signing_secret", "", signature, body, now))
+		assert.Error(t, verifySlackSignature("signing_secret", timestamp, "", body, now))
+	})
+}
+
+func TestHandleSlackInteraction(t *testing.T) {
+	postInteraction := func(body []byte, timestamp, signature string) *httptest.ResponseRecorder {
+		router := setupRouter()
+		router.POST("/slack/interactions", HandleSlackInteraction)
+
+		req, _ := http.NewRequest(http.MethodPost, "/slack/interactions", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+		req.Header.Set("X-Slack-Signature", signature)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	body := []byte("payload=" + url.QueryEscape(`{"type":"view_submission","user":{"id":"U123"}}`))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	t.Run("should be unavailable without a signing secret", func(t *testing.T) {
+		t.Setenv("SLACK_SIGNING_SECRET", "")
+		config.InitJobsConfig()
+
+		w := postInteraction(body, timestamp, signSlackRequest("signing_secret", timestamp, body))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("should reject an invalid signature", func(t *testing.T) {
+		t.Setenv("SLACK_SIGNING_SECRET", "signing_secret")
+		config.InitJobsConfig()
+
+		w := postInteraction(body, timestamp, signSlackRequest("other_secret", timestamp, body))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should acknowledge interactions other than button presses", func(t *testing.T) {
+		t.Setenv("SLACK_SIGNING_SECRET", "signing_secret")
+		config.InitJobsConfig()
+
+		w := postInteraction(body, timestamp, signSlackRequest("signing_secret", timestamp, body))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should reject a signed request without a payload", func(t *testing.T) {
+		t.Setenv("SLACK_SIGNING_SECRET", "signing_secret")
+		config.InitJobsConfig()
+
+		empty := []byte("token=abc")
+		w := postInteraction(empty, timestamp, signSlackRequest("signing_secret", timestamp, empty))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should acknowledge a button press before deciding it", func(t *testing.T) {
+		t.Setenv("SLACK_SIGNING_SECRET", "signing_secret")
+		config.InitJobsConfig()
+
+		// An unmapped user is refused in the background; the response URL is not Slack's, so no
+		// reply leaves the test
+		press := []byte("payload=" + url.QueryEscape(`{"type":"block_actions","user":{"id":"U_unmapped"},"actions":[{"action_id":"escrow_review_approve","value":"escrow_1"}],"response_url":"https://example.com/respond"}`))
+		w := postInteraction(press, timestamp, signSlackRequest("signing_secret", timestamp, press))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, WaitForSlackInteractions(ctx))
+	})
+
+	config.InitJobsConfig()
+}
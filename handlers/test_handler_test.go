@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/stripetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76/webhook"
+)
+
+// webhookRecorder collects the event types of webhooks whose signature checks out
+type webhookRecorder struct {
+	mu    sync.Mutex
+	types []string
+}
+
+func (wr *webhookRecorder) handler(secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		event, err := webhook.ConstructEvent(payload, r.Header.Get("Stripe-Signature"), secret)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		wr.mu.Lock()
+		wr.types = append(wr.types, string(event.Type))
+		wr.mu.Unlock()
+	}
+}
+
+func (wr *webhookRecorder) eventTypes() []string {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return append([]string(nil), wr.types...)
+}
+
+// setupOfflinePayments runs the test handler against the Stripe stand-in and the Firestore emulator
+func setupOfflinePayments(t *testing.T) (*stripetest.Server, *webhookRecorder) {
+	t.Helper()
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("Skipping offline payment flow tests: FIRESTORE_EMULATOR_HOST not set")
+	}
+
+	stripeServer := stripetest.NewServer()
+	stripeServer.AddAccount("acct_test_organizer")
+	recorder := &webhookRecorder{}
+	endpoint := httptest.NewServer(recorder.handler(stripeServer.WebhookSecret))
+	stripeServer.SetWebhookEndpoint(endpoint.URL)
+
+	client, err := firestore.NewClient(context.Background(), fmt.Sprintf("goalhero-it-%d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	previous := config.FirestoreClient()
+
+	t.Cleanup(func() {
+		config.SetFirestoreClient(previous)
+		client.Close()
+		endpoint.Close()
+		stripeServer.Close()
+		config.InitJobsConfig()
+	})
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test_standin")
+	t.Setenv("STRIPE_TEST_MODE", "true")
+	t.Setenv("STRIPE_API_BASE", stripeServer.URL)
+	require.NoError(t, config.InitJobsConfig())
+	config.SetFirestoreClient(client)
+
+	return stripeServer, recorder
+}
+
+func TestFullPaymentFlowOffline(t *testing.T) {
+	_, webhooks := setupOfflinePayments(t)
+
+	handler := NewTestHandler()
+	router := setupRouter()
+	router.POST("/api/test/full-flow", handler.FullPaymentFlow)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/test/full-flow", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Success   bool                     `json:"success"`
+		FlowSteps []map[string]interface{} `json:"flow_steps"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	assert.True(t, response.Success, w.Body.String())
+	require.Len(t, response.FlowSteps, 4)
+	for i, name := range []string{"create_payment", "pay_with_test_card", "confirm_payment", "release_escrow"} {
+		assert.Equal(t, name, response.FlowSteps[i]["name"])
+		assert.Equal(t, true, response.FlowSteps[i]["success"], name)
+	}
+	assert.Equal(t, "confirmed", response.FlowSteps[2]["payment_status"])
+	assert.Equal(t, "held", response.FlowSteps[2]["escrow_status"])
+
+	assert.Equal(t, []string{"payment_intent.created", "payment_intent.succeeded"}, webhooks.eventTypes())
+}
+
+func TestRunTestScenarioOffline(t *testing.T) {
+	setupOfflinePayments(t)
+
+	handler := NewTestHandler()
+	router := setupRouter()
+	router.POST("/api/test/scenarios/:scenario", handler.RunTestScenario)
+
+	run := func(t *testing.T, scenario string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/test/scenarios/"+scenario, nil)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	for _, scenario := range []string{"successful_payment", "insufficient_funds", "below_minimum", "poor_rating_dispute"} {
+		t.Run("should pass "+scenario, func(t *testing.T) {
+			code, response := run(t, scenario)
+			require.Equal(t, http.StatusOK, code)
+			assert.Equal(t, true, response["passed"], response["report"])
+		})
+	}
+}
+
+func TestTestScenarioEndpoints(t *testing.T) {
+	handler := NewTestHandler()
+	router := setupRouter()
+	router.GET("/api/test/scenarios", handler.GetTestScenarios)
+	router.POST("/api/test/scenarios/:scenario", handler.RunTestScenario)
+
+	t.Run("should list the scenario definitions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/test/scenarios", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Scenarios []struct {
+				Name  string                   `json:"name"`
+				Steps []map[string]interface{} `json:"steps"`
+			} `json:"scenarios"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotEmpty(t, response.Scenarios)
+		for _, scenario := range response.Scenarios {
+			assert.NotEmpty(t, scenario.Steps, scenario.Name)
+		}
+	})
+
+	t.Run("should reject an unknown scenario", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/test/scenarios/teleport", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Unknown scenario: teleport")
+	})
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// LeveledLogger adapts printf-style leveled logging, as used by stripe-go, to the default slog
+// logger, so those messages are masked and formatted like every other record. Component is added
+// to each record to tell where it came from.
+type LeveledLogger struct {
+	Component string
+}
+
+// Debugf logs a debug message using Printf conventions
+func (l LeveledLogger) Debugf(format string, v ...interface{}) {
+	l.log(slog.LevelDebug, format, v...)
+}
+
+// Infof logs an informational message using Printf conventions
+func (l LeveledLogger) Infof(format string, v ...interface{}) {
+	l.log(slog.LevelInfo, format, v...)
+}
+
+// Warnf logs a warning message using Printf conventions
+func (l LeveledLogger) Warnf(format string, v ...interface{}) {
+	l.log(slog.LevelWarn, format, v...)
+}
+
+// Errorf logs an error message using Printf conventions
+func (l LeveledLogger) Errorf(format string, v ...interface{}) {
+	l.log(slog.LevelError, format, v...)
+}
+
+func (l LeveledLogger) log(level slog.Level, format string, v ...interface{}) {
+	logger := slog.Default()
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+	logger.Log(context.Background(), level, fmt.Sprintf(format, v...), "component", l.Component)
+}
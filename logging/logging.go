@@ -0,0 +1,113 @@
+// Package logging configures structured logging with log/slog, carries correlation IDs through
+// contexts and masks secrets before they reach the logs
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field names shared by every log record, so logs can be filtered by them
+const (
+	KeyRequestID = "request_id"
+	KeyJobName   = "job_name"
+	KeyRunID     = "run_id"
+	KeyPaymentID = "payment_id"
+	KeyEscrowID  = "escrow_id"
+	KeyGameID    = "game_id"
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	jobNameKey
+	runIDKey
+)
+
+// Setup makes a structured logger the default for slog and the standard log package. Format is
+// json (the default) or text; level is debug, info (the default), warn or error.
+func Setup(format, level string) {
+	slog.SetDefault(slog.New(NewHandler(os.Stdout, format, parseLevel(level))))
+}
+
+// NewHandler returns a handler that masks secrets and adds the correlation IDs found in the
+// record's context
+func NewHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: maskAttr}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "text") {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return contextHandler{handler}
+}
+
+func parseLevel(level string) slog.Level {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return parsed
+}
+
+// contextHandler adds request_id, job_name, run_id and the current trace and span IDs from the
+// context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		for _, key := range []struct {
+			ctxKey contextKey
+			name   string
+		}{{requestIDKey, KeyRequestID}, {jobNameKey, KeyJobName}, {runIDKey, KeyRunID}} {
+			if value, ok := ctx.Value(key.ctxKey).(string); ok && value != "" {
+				record.AddAttrs(slog.String(key.name, value))
+			}
+		}
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			record.AddAttrs(
+				slog.String(KeyTraceID, spanContext.TraceID().String()),
+				slog.String(KeySpanID, spanContext.SpanID().String()),
+			)
+		}
+	}
+	record.Message = MaskSecrets(record.Message)
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or ""
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithJobRun returns a context carrying the job name and run ID of a background job run
+func WithJobRun(ctx context.Context, jobName, runID string) context.Context {
+	ctx = context.WithValue(ctx, jobNameKey, jobName)
+	return context.WithValue(ctx, runIDKey, runID)
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// sensitiveKeys are attribute keys whose values are always masked
+var sensitiveKeys = []string{"secret", "token", "password", "authorization", "webhook_url", "api_key"}
+
+// secretPatterns find secrets embedded in free text, such as messages from the standard log package
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\b((?:pi|seti)_[A-Za-z0-9]+)_secret_[A-Za-z0-9]+`), "${1}_secret_****"},
+	{regexp.MustCompile(`\b(sk|rk)_(live|test)_[A-Za-z0-9]+`), "${1}_${2}_****"},
+	{regexp.MustCompile(`\bwhsec_[A-Za-z0-9]+`), "whsec_****"},
+	{regexp.MustCompile(`https://hooks\.slack\.com/[^\s"']+`), "https://hooks.slack.com/****"},
+	{regexp.MustCompile(`(?i)\b(Bearer)\s+[A-Za-z0-9\-._~+/]+=*`), "${1} ****"},
+}
+
+// MaskSecrets replaces client secrets, API keys, webhook URLs and bearer tokens in s
+func MaskSecrets(s string) string {
+	for _, secret := range secretPatterns {
+		s = secret.pattern.ReplaceAllString(s, secret.replacement)
+	}
+	return s
+}
+
+// Mask hides all but the last four characters of a secret value
+func Mask(value string) string {
+	if len(value) <= 8 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
+
+// maskAttr masks attributes with sensitive keys and secrets inside string and error values.
+// Errors are logged as their masked message, as HTTP client errors include the request URL.
+func maskAttr(groups []string, attr slog.Attr) slog.Attr {
+	if err, ok := attr.Value.Any().(error); ok && attr.Value.Kind() == slog.KindAny {
+		return slog.String(attr.Key, MaskSecrets(err.Error()))
+	}
+	if attr.Value.Kind() != slog.KindString {
+		return attr
+	}
+
+	key := strings.ToLower(attr.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(attr.Key, Mask(attr.Value.String()))
+		}
+	}
+	return slog.String(attr.Key, MaskSecrets(attr.Value.String()))
+}
@@ -1,52 +1,118 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/auth"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/handlers"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/logging"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/metrics"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/services"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/tracing"
 )
 
 var router *gin.Engine
 var jobManager *services.BackgroundJobManager
+var shutdownTracing = func(context.Context) error { return nil }
+var bootstrapOnce sync.Once
 
-func init() {
-	log.Println("🔧 Initializing GoalHero Payment Jobs Service...")
+// Probe modes, used by container health checks against an instance that is already running
+var healthCheck = flag.Bool("health-check", false, "Probe the liveness endpoint (/ping) of the running instance and exit 0 if it is up")
+var readyCheck = flag.Bool("ready", false, "Probe the readiness endpoint (/ready) of the running instance and exit 0 if it is ready")
 
-	// Initialize configuration
-	config.InitJobsConfig()
+// probeTimeout keeps a probe inside the Docker HEALTHCHECK timeout
+const probeTimeout = 2 * time.Second
+
+// ensureBootstrapped initializes the service once, on the first request (Vercel) or in main
+func ensureBootstrapped() {
+	bootstrapOnce.Do(bootstrap)
+}
+
+func bootstrap() {
+	// Structured JSON logs; the standard log package is routed through the same handler
+	logging.Setup(os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL"))
+	slog.Info("Initializing GoalHero Payment Jobs Service")
+
+	// Load and validate the configuration; every problem is reported at once
+	if err := config.InitJobsConfig(); err != nil {
+		slog.Error("Refusing to start with invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize tracing before anything makes calls worth tracing
+	jobsConf := config.GetJobsConfig()
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    jobsConf.TracesExporter,
+		ServiceName: jobsConf.ServiceName,
+		SampleRatio: jobsConf.TracesSampleRatio,
+	})
+	if err != nil {
+		slog.Error("Tracing disabled", "error", err)
+	} else {
+		shutdownTracing = shutdown
+	}
 
 	// Initialize Firebase
 	auth.InitFirebase()
 
-	// Start background job manager (Railway supports long-running processes)
-	if os.Getenv("DISABLE_BACKGROUND_JOBS") != "true" {
-		fmt.Print("⚙️ Starting background jobs...\n")
+	// Service tokens for the internal endpoints; production must not run with them open
+	if err := auth.InitServiceAuth(serviceTokenConfig(jobsConf), config.IsProduction()); err != nil {
+		slog.Error("Refusing to start without service token auth", "error", err)
+		os.Exit(1)
+	}
+
+	// Sandbox time that admins can advance through /api/jobs/clock
+	if jobsConf.VirtualClock {
+		services.SetClock(services.NewVirtualClock(services.SystemClock{}))
+		slog.Warn("Virtual clock enabled, escrow releases and jobs follow time advanced by admins")
+	}
+
+	// Start the job manager: tickers where the process stays up (Railway), cron mode on serverless
+	// platforms (Vercel), where the function is frozen between requests
+	switch {
+	case os.Getenv("DISABLE_BACKGROUND_JOBS") == "true":
+		slog.Warn("Background jobs disabled via DISABLE_BACKGROUND_JOBS environment variable")
+	case jobsConf.ExecutionMode == config.ExecutionModeCron:
+		jobManager = services.StartCronJobs()
+	default:
+		slog.Info("Starting background jobs")
 		jobManager = services.StartBackgroundJobs()
-	} else {
-		log.Println("⚠️ Background jobs disabled via DISABLE_BACKGROUND_JOBS environment variable")
 	}
 
 	// Setup HTTP server
 	gin.SetMode(gin.ReleaseMode)
 	router = gin.New()
-	router.Use(gin.Logger())
+	router.Use(logging.RequestIDMiddleware())
+	router.Use(tracing.GinMiddleware())
+	router.Use(logging.GinLogger())
 	router.Use(gin.Recovery())
+	router.Use(metrics.GinMiddleware())
 
 	// Health check
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"service": "goalhero-payment-jobs", "status": "healthy"})
 	})
 
+	// Liveness: the process serves HTTP. Readiness: its dependencies are usable too.
 	router.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"service": "goalhero-payment-jobs", "status": "healthy"})
 	})
+	router.GET("/ready", handlers.Readiness(readinessChecks()))
+
+	// Prometheus metrics
+	router.GET("/metrics", metrics.GinHandler(config.GetJobsConfig().MetricsToken))
 
 	// API routes
 	api := router.Group("/api/jobs")
@@ -55,40 +121,184 @@ func init() {
 		api.GET("/status", handlers.GetJobStatuses)
 		api.GET("/health", handlers.GetJobHealth)
 
-		// Job control (admin only)
+		// Job control and escrow operations, restricted by role custom claims. Admins pass every check.
 		adminApi := api.Group("")
 		adminApi.Use(auth.FirebaseAuthMiddleware())
 		{
-			adminApi.POST("/trigger/:jobName", handlers.TriggerJob)
-			adminApi.POST("/config", handlers.UpdateJobConfig)
-			adminApi.GET("/config", handlers.GetJobConfig)
-			adminApi.POST("/restart", handlers.RestartJobs)
+			adminApi.POST("/trigger/:jobName", auth.RequireRoles(auth.RoleOps), handlers.TriggerJob)
+			adminApi.GET("/runs/:runId", auth.RequireRoles(auth.RoleOps), handlers.GetJobRun)
+			adminApi.POST("/config", auth.RequireRoles(auth.RoleAdmin), handlers.UpdateJobConfig)
+			adminApi.GET("/config", auth.RequireRoles(auth.RoleOps), handlers.GetJobConfig)
+			adminApi.GET("/config/effective", auth.RequireRoles(auth.RoleOps), handlers.GetEffectiveConfig)
+			adminApi.POST("/restart", auth.RequireRoles(auth.RoleOps), handlers.RestartJobs)
+
+			// Escrows that repeatedly failed to release
+			adminApi.GET("/escrows/dead-letter", auth.RequireRoles(auth.RoleOps, auth.RoleFinance, auth.RoleSupport), handlers.ListDeadLetteredEscrows)
+			adminApi.POST("/escrows/dead-letter/:escrowId/retry", auth.RequireRoles(auth.RoleOps, auth.RoleFinance), handlers.RetryDeadLetteredEscrow)
+			adminApi.POST("/escrows/dead-letter/:escrowId/resolve", auth.RequireRoles(auth.RoleFinance), handlers.ResolveDeadLetteredEscrow)
+			adminApi.GET("/escrows/review", auth.RequireRoles(auth.RoleOps, auth.RoleFinance, auth.RoleSupport), handlers.ListEscrowsUnderReview)
+			adminApi.POST("/escrows/review/:escrowId/approve", auth.RequireRoles(auth.RoleFinance), handlers.ApproveReviewedEscrow)
+			adminApi.POST("/escrows/review/:escrowId/refund", auth.RequireRoles(auth.RoleFinance), handlers.RefundReviewedEscrow)
+			adminApi.POST("/escrows/review/:escrowId/dispute", auth.RequireRoles(auth.RoleFinance, auth.RoleSupport), handlers.DisputeReviewedEscrow)
+
+			// Sandbox time, only with VIRTUAL_CLOCK in test mode
+			adminApi.GET("/clock", auth.RequireRoles(auth.RoleOps), handlers.GetClock)
+			adminApi.POST("/clock/advance", auth.RequireRoles(auth.RoleAdmin), handlers.AdvanceClock)
+			adminApi.POST("/clock/reset", auth.RequireRoles(auth.RoleAdmin), handlers.ResetClock)
 		}
 
-		// Inter-service communication (no auth required for internal calls)
+		// Vercel Cron invocations (authenticated by CRON_SECRET); the paths are generated into
+		// vercel.json from the job registry
+		api.GET("/cron/:jobName", auth.CronSecretMiddleware(config.GetJobsConfig().CronSecret), handlers.RunCronJob)
+
+		// Slack review buttons (authenticated by the Slack signing secret)
+		api.POST("/slack/interactions", handlers.HandleSlackInteraction)
+
+		// Inter-service communication, authenticated with service tokens
 		internal := api.Group("/internal")
+		internal.Use(auth.ServiceTokenMiddleware())
 		{
 			internal.POST("/trigger-rating-reminder", handlers.TriggerRatingReminder)
 			internal.POST("/trigger-auto-release", handlers.TriggerAutoRelease)
 			internal.POST("/trigger-dispute-escalation", handlers.TriggerDisputeEscalation)
+			internal.GET("/runs/:runId", handlers.GetJobRun)
 		}
 	}
 
-	log.Println("✅ GoalHero Payment Jobs Service initialized")
+	slog.Info("GoalHero Payment Jobs Service initialized")
+}
+
+// serviceTokenConfig maps the jobs config onto the service token settings
+func serviceTokenConfig(conf *config.JobsConfig) auth.ServiceTokenConfig {
+	issuers := conf.ServiceTokenIssuers
+	if len(issuers) == 0 {
+		issuers = []string{"goalhero-api"}
+	}
+	return auth.ServiceTokenConfig{
+		Keys:           conf.ServiceTokenKeys,
+		SigningKeyID:   conf.ServiceTokenSigningKeyID,
+		Audience:       conf.ServiceTokenAudience,
+		TrustedIssuers: issuers,
+		MaxTTL:         conf.ServiceTokenMaxTTL,
+	}
+}
+
+// readinessChecks lists the dependencies /ready checks
+func readinessChecks() []services.ReadinessCheck {
+	return append(services.ServiceReadinessChecks(), services.ReadinessCheck{
+		Name: "firebase_auth",
+		Check: func(context.Context) (string, error) {
+			if !auth.Initialized() {
+				return "", fmt.Errorf("firebase auth not initialized, admin endpoints are unavailable")
+			}
+			return "", nil
+		},
+	})
+}
+
+// listenPort returns the HTTP port (Railway sets PORT automatically)
+func listenPort() string {
+	if port := os.Getenv("PORT"); port != "" {
+		return port
+	}
+	return "8081" // Default for local development
+}
+
+// probe GETs path on the instance listening on port and returns the process exit code: 0 when it
+// answers 200, 1 otherwise
+func probe(port, path string) int {
+	client := &http.Client{Timeout: probeTimeout}
+	resp, err := client.Get("http://127.0.0.1:" + port + path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s probe failed: %v\n", path, err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "%s probe failed: status %d\n", path, resp.StatusCode)
+		return 1
+	}
+	return 0
 }
 
 func main() {
-	// Get port from environment variable (Railway sets PORT automatically)
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081" // Default for local development
-	}
-	
-	log.Printf("🚀 Starting GoalHero Payment Jobs Service on port %s", port)
-	router.Run(":" + port)
+	flag.Parse()
+	port := listenPort()
+
+	// Probes only talk to the running instance; they must not start jobs or connect to anything
+	switch {
+	case *healthCheck:
+		os.Exit(probe(port, "/ping"))
+	case *readyCheck:
+		os.Exit(probe(port, "/ready"))
+	}
+
+	ensureBootstrapped()
+
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	// Railway sends SIGTERM on redeploy; Ctrl+C sends SIGINT locally
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting GoalHero Payment Jobs Service", "port", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutdown signal received")
+	case err := <-serverErr:
+		slog.Error("HTTP server stopped", "error", err)
+	}
+
+	gracefulShutdown(srv, config.GetJobsConfig().ShutdownTimeout)
+}
+
+// gracefulShutdown stops accepting HTTP traffic, drains in-flight requests and Slack review
+// decisions, stops background jobs and flushes pending Slack notifications and traces, all within
+// a single deadline
+func gracefulShutdown(srv *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	slog.Info("Shutting down", "timeout", timeout.String())
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("HTTP server shutdown failed", "error", err)
+	}
+
+	if err := handlers.WaitForSlackInteractions(ctx); err != nil {
+		slog.Warn("Slack review decisions did not finish", "error", err)
+	}
+
+	if jobManager != nil {
+		if err := jobManager.Shutdown(ctx); err != nil {
+			slog.Warn("Background jobs shutdown failed", "error", err)
+		}
+	}
+
+	if err := services.FlushNotifications(ctx); err != nil {
+		slog.Warn("Flushing notifications failed", "error", err)
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Flushing traces failed", "error", err)
+	}
+
+	slog.Info("GoalHero Payment Jobs Service stopped")
 }
 
 // Handler for Vercel - this is the entry point for serverless functions
 func Handler(w http.ResponseWriter, r *http.Request) {
+	ensureBootstrapped()
 	router.ServeHTTP(w, r)
 }
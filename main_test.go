@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -94,6 +95,20 @@ func TestHandlerFunction(t *testing.T) {
 	})
 }
 
+func TestJobRunsRequireAuthentication(t *testing.T) {
+	for _, path := range []string{"/api/jobs/runs/run_1", "/api/jobs/internal/runs/run_1"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			Handler(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			// Rejected by the auth middleware (503 while no credentials are configured), never
+			// answered by the run lookup
+			assert.Contains(t, []int{http.StatusUnauthorized, http.StatusServiceUnavailable}, w.Code)
+		})
+	}
+}
+
 func TestMainFunction(t *testing.T) {
 	t.Run("main function should handle test environment", func(t *testing.T) {
 		// Set production environment to prevent server startup in test
@@ -113,4 +128,23 @@ func TestMainFunction(t *testing.T) {
 		// Should complete without starting server in production mode
 		assert.True(t, true)
 	})
-}
\ No newline at end of file
+}
+func TestProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, probe(port, "/ping"))
+	assert.Equal(t, 1, probe(port, "/ready"))
+
+	server.Close()
+	assert.Equal(t, 1, probe(port, "/ping"), "nothing listening")
+}
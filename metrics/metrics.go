@@ -0,0 +1,160 @@
+// Package metrics exposes the service's Prometheus metrics on /metrics
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "goalhero"
+
+// Registry holds every metric served on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// JobRuns counts finished background job runs by job and final status (completed, failed, aborted)
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Finished background job runs by job and status.",
+	}, []string{"job", "status"})
+
+	// JobRunDuration observes how long background job runs take
+	JobRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_run_duration_seconds",
+		Help:      "Duration of background job runs.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900},
+	}, []string{"job"})
+
+	// EscrowsByStatus is the number of escrows in each status, refreshed after each auto-release run
+	EscrowsByStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "escrows",
+		Help:      "Escrow transactions by status, refreshed after each auto-release run.",
+	}, []string{"status"})
+
+	// EscrowReleases counts escrows released to organizers
+	EscrowReleases = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "escrow_releases_total",
+		Help:      "Escrows released to organizers.",
+	})
+
+	// EscrowReleasedAmount sums the euros released to organizers
+	EscrowReleasedAmount = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "escrow_released_euros_total",
+		Help:      "Euros released from escrow to organizers.",
+	})
+
+	// Refunds counts refunds issued to players
+	Refunds = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refunds_total",
+		Help:      "Refunds issued to players.",
+	})
+
+	// RefundedAmount sums the euros refunded to players
+	RefundedAmount = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refunded_euros_total",
+		Help:      "Euros refunded to players.",
+	})
+
+	// ExternalCallDuration observes each attempt of a call to Stripe, Firestore, Slack or a
+	// notification webhook, by service and operation
+	ExternalCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "external_call_duration_seconds",
+		Help:      "Duration of each attempt of a call to an external service.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"service", "operation"})
+
+	// ExternalCallErrors counts failed attempts of calls to external services
+	ExternalCallErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "external_call_errors_total",
+		Help:      "Failed attempts of calls to external services.",
+	}, []string{"service", "operation"})
+
+	// NotificationDeliveries counts notifications delivered per channel and outcome (sent, failed)
+	NotificationDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notification_deliveries_total",
+		Help:      "Notification deliveries by channel and outcome.",
+	}, []string{"channel", "outcome"})
+
+	// HTTPRequests counts HTTP requests by method, route template and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes HTTP request latency by method and route template
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		JobRuns,
+		JobRunDuration,
+		EscrowsByStatus,
+		EscrowReleases,
+		EscrowReleasedAmount,
+		Refunds,
+		RefundedAmount,
+		ExternalCallDuration,
+		ExternalCallErrors,
+		NotificationDeliveries,
+		HTTPRequests,
+		HTTPRequestDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveExternalCall records one attempt of an operation named "service.operation", such as
+// "stripe.create_transfer"
+func ObserveExternalCall(operation string, duration time.Duration, err error) {
+	service, name, found := strings.Cut(operation, ".")
+	if !found {
+		service, name = "other", operation
+	}
+
+	ExternalCallDuration.WithLabelValues(service, name).Observe(duration.Seconds())
+	if err != nil {
+		ExternalCallErrors.WithLabelValues(service, name).Inc()
+	}
+}
+
+// ObserveJobRun records a finished background job run
+func ObserveJobRun(job, status string, duration time.Duration) {
+	JobRuns.WithLabelValues(job, status).Inc()
+	JobRunDuration.WithLabelValues(job).Observe(duration.Seconds())
+}
+
+// ObserveNotification records a notification delivery attempt on a channel
+func ObserveNotification(channel string, err error) {
+	outcome := "sent"
+	if err != nil {
+		outcome = "failed"
+	}
+	NotificationDeliveries.WithLabelValues(channel, outcome).Inc()
+}
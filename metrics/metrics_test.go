@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveExternalCall(t *testing.T) {
+	before := testutil.ToFloat64(ExternalCallErrors.WithLabelValues("stripe", "create_transfer"))
+
+	ObserveExternalCall("stripe.create_transfer", 120*time.Millisecond, nil)
+	ObserveExternalCall("stripe.create_transfer", 80*time.Millisecond, errors.New("rate limited"))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(ExternalCallErrors.WithLabelValues("stripe", "create_transfer")))
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(ExternalCallDuration, "goalhero_external_call_duration_seconds"), 1)
+
+	ObserveExternalCall("unnamed", time.Millisecond, errors.New("failed"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(ExternalCallErrors.WithLabelValues("other", "unnamed")))
+}
+
+func TestGinMiddlewareUsesRouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+	router.GET("/api/jobs/runs/:runId", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	for _, path := range []string{"/api/jobs/runs/run_1", "/api/jobs/runs/run_2", "/no/such/route"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/api/jobs/runs/:runId", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+}
+
+func TestGinHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	JobRuns.WithLabelValues("auto_release", "completed").Inc()
+
+	t.Run("should serve the Prometheus text format", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/metrics", GinHandler(""))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.True(t, strings.Contains(body, `goalhero_job_runs_total{job="auto_release",status="completed"}`))
+		assert.Contains(t, body, "go_goroutines")
+	})
+
+	t.Run("should require the token when set", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/metrics", GinHandler("scrape-secret"))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Authorization", "Bearer scrape-secret")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
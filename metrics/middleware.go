@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so unknown paths can't grow the label set
+const unmatchedRoute = "unmatched"
+
+// GinMiddleware records HTTP request counts and latency, labelled by route template
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// GinHandler serves /metrics. When token is set, scrapers must send it as a bearer token.
+func GinHandler(token string) gin.HandlerFunc {
+	handler := Handler()
+	return func(c *gin.Context) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Invalid metrics token",
+			})
+			return
+		}
+		handler.ServeHTTP(c.Writer, c.Request)
+	}
+}
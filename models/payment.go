@@ -31,7 +31,7 @@ type EscrowTransaction struct {
 	OrganizerID         string     `json:"organizerId" firestore:"organizerId"`
 	PaymentID           string     `json:"paymentId" firestore:"paymentId"`
 	Amount              float64    `json:"amount" firestore:"amount"`
-	Status              string     `json:"status" firestore:"status"`         // held, pending_rating, approved, released, disputed, resolved, refunded
+	Status              string     `json:"status" firestore:"status"`         // held, pending_rating, approved, under_review, releasing, released, disputed, resolved, refunded, release_failed
 	HeldAt              time.Time  `json:"heldAt" firestore:"heldAt"`
 	ReleasedAt          *time.Time `json:"releasedAt,omitempty" firestore:"releasedAt,omitempty"`
 	ReleaseReason       string     `json:"releaseReason,omitempty" firestore:"releaseReason,omitempty"`
@@ -40,8 +40,34 @@ type EscrowTransaction struct {
 	RatingReceived      bool       `json:"ratingReceived" firestore:"ratingReceived"`
 	RatingApproved      bool       `json:"ratingApproved" firestore:"ratingApproved"`
 	MinRatingRequired   float64    `json:"minRatingRequired" firestore:"minRatingRequired"`
-	ActualRating        float64    `json:"actualRating,omitempty" firestore:"actualRating,omitempty"`
+	ActualRating        float64    `json:"actualRating,omitempty" firestore:"actualRating,omitempty"` // Aggregated from attendee ratings
 	ReviewedBy          string     `json:"reviewedBy,omitempty" firestore:"reviewedBy,omitempty"`
+	ReleaseFailureCount int        `json:"releaseFailureCount,omitempty" firestore:"releaseFailureCount,omitempty"` // Consecutive failed release attempts
+	LastReleaseError    string     `json:"lastReleaseError,omitempty" firestore:"lastReleaseError,omitempty"`
+	LastReleaseAttemptAt *time.Time `json:"lastReleaseAttemptAt,omitempty" firestore:"lastReleaseAttemptAt,omitempty"`
+	DeadLetteredAt      *time.Time `json:"deadLetteredAt,omitempty" firestore:"deadLetteredAt,omitempty"` // When moved to release_failed
+	ResolvedBy          string     `json:"resolvedBy,omitempty" firestore:"resolvedBy,omitempty"`         // Admin who force-resolved the escrow
+	ResolutionNotes     string     `json:"resolutionNotes,omitempty" firestore:"resolutionNotes,omitempty"`
+	ReviewAlertSentAt   *time.Time `json:"reviewAlertSentAt,omitempty" firestore:"reviewAlertSentAt,omitempty"` // When the poor-rating manual review alert went out
+	RatingCount         int        `json:"ratingCount,omitempty" firestore:"ratingCount,omitempty"`             // Ratings counted towards ActualRating
+	RatingAggregation   string     `json:"ratingAggregation,omitempty" firestore:"ratingAggregation,omitempty"` // mean, median, min
+	RaterRatings        []EscrowRaterRating `json:"raterRatings,omitempty" firestore:"raterRatings,omitempty"` // Per-rater breakdown
+	ReleasePolicy       *ReleasePolicy `json:"releasePolicy,omitempty" firestore:"releasePolicy,omitempty"` // Policy applied when the escrow was created
+	ReviewStartedAt     *time.Time `json:"reviewStartedAt,omitempty" firestore:"reviewStartedAt,omitempty"`     // When the escrow entered under_review
+	ReviewDueAt         *time.Time `json:"reviewDueAt,omitempty" firestore:"reviewDueAt,omitempty"`             // Review SLA deadline
+	ReviewEscalatedAt   *time.Time `json:"reviewEscalatedAt,omitempty" firestore:"reviewEscalatedAt,omitempty"` // When the overdue review was escalated
+	ReviewDecision      string     `json:"reviewDecision,omitempty" firestore:"reviewDecision,omitempty"`       // approve_release, refund, partial_refund, dispute
+	ReviewNotes         string     `json:"reviewNotes,omitempty" firestore:"reviewNotes,omitempty"`
+	ReviewedAt          *time.Time `json:"reviewedAt,omitempty" firestore:"reviewedAt,omitempty"`
+	RefundedAmount      float64    `json:"refundedAmount,omitempty" firestore:"refundedAmount,omitempty"` // Refunded to players by a review decision
+}
+
+// EscrowRaterRating is one attendee's rating of the organizer, as counted for an escrow
+type EscrowRaterRating struct {
+	RaterID   string    `json:"raterId" firestore:"raterId"`
+	RatingID  string    `json:"ratingId" firestore:"ratingId"`
+	Rating    float64   `json:"rating" firestore:"rating"`
+	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
 }
 
 // UserPaymentMethod represents stored payment methods
@@ -120,6 +146,16 @@ const (
 	EscrowStatusDisputed      = "disputed"
 	EscrowStatusResolved      = "resolved"
 	EscrowStatusRefunded      = "refunded"
+	EscrowStatusReleaseFailed = "release_failed" // Dead-lettered after repeated release failures
+	EscrowStatusUnderReview   = "under_review"   // Poorly rated, waiting for a reviewer's decision
+	EscrowStatusReleasing     = "releasing"      // Claimed by a release that is moving the funds
+	EscrowStatusDeciding      = "deciding"       // Claimed by a review decision that is being carried out
+
+	// Review Decisions
+	ReviewDecisionApproveRelease = "approve_release"
+	ReviewDecisionRefund         = "refund"
+	ReviewDecisionPartialRefund  = "partial_refund"
+	ReviewDecisionDispute        = "dispute"
 
 	// Payment Methods
 	PaymentMethodStripe = "stripe"
@@ -143,6 +179,8 @@ const (
 	MinimumGamePrice     = 5.0     // €5
 	MaximumGamePrice     = 50.0    // €50
 	EscrowHoldHours      = 24      // 24 hours after game ends
+	MaxReleaseFailures   = 5       // Failed auto-release attempts before an escrow is dead-lettered
+	DefaultReviewSLAHours = 48     // Hours a reviewer has to decide before the review is escalated
 	
 	// Currency
 	DefaultCurrency = "EUR"
@@ -63,6 +63,12 @@ const (
 
 	// Default rating requirements
 	DefaultMinRatingRequired = 3.0
+	DefaultMinRaters         = 1 // Ratings needed before the aggregate counts
+
+	// Rating Aggregation Methods (combine attendee ratings into the escrow rating)
+	RatingAggregationMean   = "mean"
+	RatingAggregationMedian = "median"
+	RatingAggregationMin    = "min"
 
 	// Note: Dispute status constants are defined in payment.go to avoid duplication
 
@@ -74,4 +80,5 @@ const (
 	// Dispute Roles
 	DisputeRolePlayer    = "player"
 	DisputeRoleOrganizer = "organizer"
+	DisputeRoleAdmin     = "admin" // Opened by a reviewer from the manual review queue
 )
\ No newline at end of file
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReleasePolicy decides when escrowed funds are released automatically. The policy in force when
+// an escrow is created is recorded on it, so later configuration changes don't affect it.
+type ReleasePolicy struct {
+	Source            string        `json:"source,omitempty" firestore:"source,omitempty"`   // default, organizer
+	MinRating         float64       `json:"minRating" firestore:"minRating"`                 // Aggregated rating needed for release
+	HoldPeriod        time.Duration `json:"holdPeriod" firestore:"holdPeriod"`               // From payment confirmation until release is possible
+	GracePeriod       time.Duration `json:"gracePeriod" firestore:"gracePeriod"`             // After the hold, how long unrated escrows wait for ratings
+	RatingAggregation string        `json:"ratingAggregation" firestore:"ratingAggregation"` // mean, median, min
+	MinRaters         int           `json:"minRaters" firestore:"minRaters"`                 // Ratings needed before the aggregate counts
+}
+
+// ReleasePolicyOverride adjusts the default release policy for one organizer. Zero fields keep the default.
+type ReleasePolicyOverride struct {
+	MinRating   float64       `json:"minRating,omitempty"`
+	HoldPeriod  time.Duration `json:"holdPeriod,omitempty"`
+	GracePeriod time.Duration `json:"gracePeriod,omitempty"`
+}
+
+// Release policy constants
+const (
+	// Policy Sources
+	ReleasePolicySourceDefault   = "default"
+	ReleasePolicySourceOrganizer = "organizer" // Default with an organizer override applied
+
+	DefaultRatingDeadlineDays = 7              // Days players have to rate after the hold
+	DefaultGracePeriod        = 24 * time.Hour // After the hold, how long unrated escrows wait for ratings
+)
+
+// WithDefaults fills in unset fields from the platform defaults
+func (p ReleasePolicy) WithDefaults() ReleasePolicy {
+	if p.Source == "" {
+		p.Source = ReleasePolicySourceDefault
+	}
+	if p.MinRating == 0 {
+		p.MinRating = DefaultMinRatingRequired
+	}
+	if p.HoldPeriod == 0 {
+		p.HoldPeriod = time.Duration(EscrowHoldHours) * time.Hour
+	}
+	if p.GracePeriod == 0 {
+		p.GracePeriod = DefaultGracePeriod
+	}
+	if p.RatingAggregation == "" {
+		p.RatingAggregation = RatingAggregationMean
+	}
+	if p.MinRaters <= 0 {
+		p.MinRaters = DefaultMinRaters
+	}
+	return p
+}
+
+// WithOverride applies an organizer override on top of the policy
+func (p ReleasePolicy) WithOverride(override ReleasePolicyOverride) ReleasePolicy {
+	p.Source = ReleasePolicySourceOrganizer
+	if override.MinRating != 0 {
+		p.MinRating = override.MinRating
+	}
+	if override.HoldPeriod != 0 {
+		p.HoldPeriod = override.HoldPeriod
+	}
+	if override.GracePeriod != 0 {
+		p.GracePeriod = override.GracePeriod
+	}
+	return p
+}
+
+// Validate checks the policy values are usable
+func (p ReleasePolicy) Validate() error {
+	if p.MinRating < MinRating || p.MinRating > MaxRating {
+		return fmt.Errorf("minimum rating must be between %.1f and %.1f, got %.1f", MinRating, MaxRating, p.MinRating)
+	}
+	if p.HoldPeriod < 0 || p.GracePeriod < 0 {
+		return fmt.Errorf("hold and grace periods must not be negative")
+	}
+	switch p.RatingAggregation {
+	case RatingAggregationMean, RatingAggregationMedian, RatingAggregationMin:
+	default:
+		return fmt.Errorf("unknown rating aggregation %q (valid: mean, median, min)", p.RatingAggregation)
+	}
+	return nil
+}
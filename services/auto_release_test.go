@@ -1,15 +1,20 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsEligibleForAutoRelease(t *testing.T) {
 	paymentService := NewPaymentService()
+	paymentService.releasePolicies = ReleasePolicies{Default: models.ReleasePolicy{GracePeriod: 24 * time.Hour}}
 	now := time.Now()
 
 	testCases := []struct {
@@ -88,6 +93,100 @@ func TestIsEligibleForAutoRelease(t *testing.T) {
 			expected: false,
 			reason:   "Should wait for rating within grace period",
 		},
+		{
+			name: "eligible_approved",
+			escrow: &models.EscrowTransaction{
+				ID:                "escrow_7",
+				Status:            models.EscrowStatusApproved,
+				ReleaseEligibleAt: now.Add(-1 * time.Minute),
+				RatingReceived:    true,
+				RatingApproved:    true,
+				ActualRating:      4.0,
+				MinRatingRequired: 3.0,
+			},
+			expected: true,
+			reason:   "Should release approved escrow as soon as the hold ends",
+		},
+		{
+			name: "not_eligible_approved_before_time",
+			escrow: &models.EscrowTransaction{
+				ID:                "escrow_8",
+				Status:            models.EscrowStatusApproved,
+				ReleaseEligibleAt: now.Add(1 * time.Hour),
+				RatingReceived:    true,
+				RatingApproved:    true,
+			},
+			expected: false,
+			reason:   "Should not release approved escrow before the hold ends",
+		},
+		{
+			name: "eligible_pending_rating_after_good_rating",
+			escrow: &models.EscrowTransaction{
+				ID:                "escrow_9",
+				Status:            models.EscrowStatusPendingRating,
+				ReleaseEligibleAt: now.Add(-2 * time.Hour),
+				RatingReceived:    true,
+				ActualRating:      3.5,
+				MinRatingRequired: 3.0,
+			},
+			expected: true,
+			reason:   "Should release pending escrow once a good rating arrives",
+		},
+		{
+			name: "eligible_pending_rating_after_grace",
+			escrow: &models.EscrowTransaction{
+				ID:                "escrow_10",
+				Status:            models.EscrowStatusPendingRating,
+				ReleaseEligibleAt: now.Add(-25 * time.Hour),
+				RatingReceived:    false,
+			},
+			expected: true,
+			reason:   "Should release pending escrow after grace period with no rating",
+		},
+		{
+			name: "not_eligible_pending_rating_within_grace",
+			escrow: &models.EscrowTransaction{
+				ID:                "escrow_11",
+				Status:            models.EscrowStatusPendingRating,
+				ReleaseEligibleAt: now.Add(-2 * time.Hour),
+				RatingReceived:    false,
+			},
+			expected: false,
+			reason:   "Should keep waiting for rating within grace period",
+		},
+		{
+			name: "not_eligible_pending_rating_poor_rating",
+			escrow: &models.EscrowTransaction{
+				ID:                "escrow_12",
+				Status:            models.EscrowStatusPendingRating,
+				ReleaseEligibleAt: now.Add(-25 * time.Hour),
+				RatingReceived:    true,
+				ActualRating:      2.0,
+				MinRatingRequired: 3.0,
+			},
+			expected: false,
+			reason:   "Should not release pending escrow with poor rating, even after grace period",
+		},
+		{
+			name: "not_eligible_released",
+			escrow: &models.EscrowTransaction{
+				ID:                "escrow_13",
+				Status:            models.EscrowStatusReleased,
+				ReleaseEligibleAt: now.Add(-25 * time.Hour),
+			},
+			expected: false,
+			reason:   "Should not release an escrow twice",
+		},
+		{
+			name: "not_eligible_dead_lettered",
+			escrow: &models.EscrowTransaction{
+				ID:                "escrow_14",
+				Status:            models.EscrowStatusReleaseFailed,
+				ReleaseEligibleAt: now.Add(-25 * time.Hour),
+			},
+			expected: false,
+			reason:   "Should leave dead-lettered escrows to the admin endpoints",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -96,6 +195,24 @@ func TestIsEligibleForAutoRelease(t *testing.T) {
 			assert.Equal(t, tc.expected, result, tc.reason)
 		})
 	}
+
+	t.Run("should_send_manual_review_alert_once", func(t *testing.T) {
+		escrow := &models.EscrowTransaction{
+			ID:                "escrow_15",
+			Status:            models.EscrowStatusHeld,
+			ReleaseEligibleAt: now.Add(-1 * time.Hour),
+			RatingReceived:    true,
+			ActualRating:      1.5,
+			MinRatingRequired: 3.0,
+		}
+
+		assert.False(t, paymentService.isEligibleForAutoRelease(escrow))
+		require.NotNil(t, escrow.ReviewAlertSentAt)
+		alertSentAt := *escrow.ReviewAlertSentAt
+
+		assert.False(t, paymentService.isEligibleForAutoRelease(escrow))
+		assert.Equal(t, alertSentAt, *escrow.ReviewAlertSentAt, "Should not alert again on the next run")
+	})
 }
 
 func TestProcessAutomaticReleases(t *testing.T) {
@@ -143,40 +260,55 @@ func TestEscrowRatingLogic(t *testing.T) {
 			rating:           2.5,
 			minRequired:      3.0,
 			expectedApproved: false,
-			expectedStatus:   models.EscrowStatusHeld, // Stays held for manual review
+			expectedStatus:   models.EscrowStatusPendingRating, // Waits for manual review
 		},
 		{
 			name:             "very_poor_rating",
 			rating:           1.0,
 			minRequired:      3.0,
 			expectedApproved: false,
-			expectedStatus:   models.EscrowStatusHeld,
+			expectedStatus:   models.EscrowStatusPendingRating,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			escrow := &models.EscrowTransaction{
+			store := NewMemoryEscrowStore(&models.EscrowTransaction{
+				ID:                "escrow_rating",
+				GameID:            "game_1",
+				OrganizerID:       "organizer_1",
 				MinRatingRequired: tc.minRequired,
-				Status:           models.EscrowStatusHeld,
-			}
+				Status:            models.EscrowStatusHeld,
+			})
+			ratingStore := NewMemoryRatingStore()
+			ratingStore.SetPlayersPresent("game_1", "organizer_1", "reviewer_1")
+			paymentService := &PaymentService{escrowStore: store, ratingStore: ratingStore}
 
-			// Simulate the rating update logic
-			escrow.RatingReceived = true
-			escrow.ActualRating = tc.rating
-
-			if tc.rating >= tc.minRequired {
-				escrow.RatingApproved = true
-				escrow.Status = models.EscrowStatusApproved
-			} else {
-				escrow.RatingApproved = false
-				// Poor rating - keep in held status for manual review
-			}
+			err := paymentService.UpdateEscrowRating("escrow_rating", tc.rating, "reviewer_1")
+			require.NoError(t, err)
 
+			escrow, err := store.Get(context.Background(), "escrow_rating")
+			require.NoError(t, err)
+			assert.True(t, escrow.RatingReceived)
+			assert.Equal(t, tc.rating, escrow.ActualRating)
 			assert.Equal(t, tc.expectedApproved, escrow.RatingApproved)
 			assert.Equal(t, tc.expectedStatus, escrow.Status)
 		})
 	}
+
+	t.Run("should_not_reopen_released_escrow", func(t *testing.T) {
+		store := NewMemoryEscrowStore(&models.EscrowTransaction{
+			ID:                "escrow_released",
+			MinRatingRequired: 3.0,
+			Status:            models.EscrowStatusReleased,
+		})
+		paymentService := &PaymentService{escrowStore: store, ratingStore: NewMemoryRatingStore()}
+
+		require.NoError(t, paymentService.UpdateEscrowRating("escrow_released", 4.0, "reviewer_1"))
+
+		escrow, _ := store.Get(context.Background(), "escrow_released")
+		assert.Equal(t, models.EscrowStatusReleased, escrow.Status)
+	})
 }
 
 func TestAutoReleaseBusinessRules(t *testing.T) {
@@ -210,4 +342,527 @@ func TestAutoReleaseBusinessRules(t *testing.T) {
 		
 		assert.Equal(t, expectedReleaseTime, escrow.ReleaseEligibleAt)
 	})
-}
\ No newline at end of file
+}
+func TestApplyReleaseFailure(t *testing.T) {
+	now := time.Now()
+	releaseErr := fmt.Errorf("failed to release funds via Stripe: account restricted")
+
+	t.Run("should count failures below the limit", func(t *testing.T) {
+		escrow := &models.EscrowTransaction{ID: "escrow_1", Status: models.EscrowStatusHeld, ReleaseFailureCount: 1}
+
+		deadLettered := applyReleaseFailure(escrow, releaseErr, now, 3)
+
+		assert.False(t, deadLettered)
+		assert.Equal(t, models.EscrowStatusHeld, escrow.Status)
+		assert.Equal(t, 2, escrow.ReleaseFailureCount)
+		assert.Equal(t, releaseErr.Error(), escrow.LastReleaseError)
+		assert.Equal(t, now, *escrow.LastReleaseAttemptAt)
+		assert.Nil(t, escrow.DeadLetteredAt)
+	})
+
+	t.Run("should dead-letter when the limit is reached", func(t *testing.T) {
+		escrow := &models.EscrowTransaction{ID: "escrow_2", Status: models.EscrowStatusHeld, ReleaseFailureCount: 2}
+
+		deadLettered := applyReleaseFailure(escrow, releaseErr, now, 3)
+
+		assert.True(t, deadLettered)
+		assert.Equal(t, models.EscrowStatusReleaseFailed, escrow.Status)
+		assert.Equal(t, 3, escrow.ReleaseFailureCount)
+		assert.Equal(t, now, *escrow.DeadLetteredAt)
+	})
+
+	t.Run("should use the default limit when none is configured", func(t *testing.T) {
+		escrow := &models.EscrowTransaction{ID: "escrow_3", Status: models.EscrowStatusHeld, ReleaseFailureCount: models.MaxReleaseFailures - 2}
+
+		assert.False(t, applyReleaseFailure(escrow, releaseErr, now, 0))
+		assert.True(t, applyReleaseFailure(escrow, releaseErr, now, 0))
+	})
+
+	t.Run("should not report an already dead-lettered escrow again", func(t *testing.T) {
+		deadLetteredAt := now.Add(-1 * time.Hour)
+		escrow := &models.EscrowTransaction{
+			ID:                  "escrow_4",
+			Status:              models.EscrowStatusReleaseFailed,
+			ReleaseFailureCount: 5,
+			DeadLetteredAt:      &deadLetteredAt,
+		}
+
+		assert.False(t, applyReleaseFailure(escrow, releaseErr, now, 5))
+		assert.Equal(t, 6, escrow.ReleaseFailureCount)
+		assert.Equal(t, deadLetteredAt, *escrow.DeadLetteredAt)
+	})
+}
+
+func TestDeadLetterActionsConflict(t *testing.T) {
+	deadLettered := func() *models.EscrowTransaction {
+		deadLetteredAt := time.Now().Add(-1 * time.Hour)
+		return &models.EscrowTransaction{
+			ID:                  "escrow_dead",
+			PaymentID:           "payment_dead",
+			Amount:              24.0,
+			Status:              models.EscrowStatusReleaseFailed,
+			ReleaseEligibleAt:   time.Now().Add(-8 * 24 * time.Hour),
+			ReleaseFailureCount: models.MaxReleaseFailures,
+			DeadLetteredAt:      &deadLetteredAt,
+		}
+	}
+
+	t.Run("should reject a retry of an escrow already resolved", func(t *testing.T) {
+		service, store, _, _ := newReviewService(deadLettered())
+		releaser := &fakeFundsReleaser{}
+		service.fundsReleaser = releaser
+
+		_, err := service.ForceResolveEscrow("escrow_dead", models.EscrowStatusRefunded, "refunded in the dashboard", "admin_1")
+		require.NoError(t, err)
+
+		err = service.RetryDeadLetteredEscrow("escrow_dead", "admin_2")
+		assert.ErrorIs(t, err, ErrEscrowNotDeadLettered)
+
+		stored, err := store.Get(context.Background(), "escrow_dead")
+		require.NoError(t, err)
+		assert.Equal(t, models.EscrowStatusRefunded, stored.Status)
+		assert.Zero(t, releaser.calls["escrow_dead"], "a resolved escrow must not be paid out")
+	})
+
+	t.Run("should let only one of a concurrent retry and force-resolve go ahead", func(t *testing.T) {
+		service, store, _, _ := newReviewService(deadLettered())
+		store.Latency = 5 * time.Millisecond
+		releaser := &fakeFundsReleaser{}
+		service.fundsReleaser = releaser
+
+		var wg sync.WaitGroup
+		var retryErr, resolveErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			retryErr = service.RetryDeadLetteredEscrow("escrow_dead", "admin_1")
+		}()
+		go func() {
+			defer wg.Done()
+			_, resolveErr = service.ForceResolveEscrow("escrow_dead", models.EscrowStatusRefunded, "refunded in the dashboard", "admin_2")
+		}()
+		wg.Wait()
+
+		stored, err := store.Get(context.Background(), "escrow_dead")
+		require.NoError(t, err)
+		if retryErr == nil {
+			assert.ErrorIs(t, resolveErr, ErrEscrowNotDeadLettered)
+			assert.Equal(t, models.EscrowStatusReleased, stored.Status)
+			assert.Equal(t, 1, releaser.calls["escrow_dead"])
+		} else {
+			assert.ErrorIs(t, retryErr, ErrEscrowNotDeadLettered)
+			require.NoError(t, resolveErr)
+			assert.Equal(t, models.EscrowStatusRefunded, stored.Status)
+			assert.Zero(t, releaser.calls["escrow_dead"], "a resolved escrow must not be paid out")
+		}
+	})
+}
+
+// fakeFundsReleaser stands in for Stripe, failing releases for the listed escrow IDs
+type fakeFundsReleaser struct {
+	latency time.Duration
+	failFor map[string]bool
+
+	mu    sync.Mutex
+	calls map[string]int // Release attempts per escrow ID
+}
+
+func (f *fakeFundsReleaser) ReleaseEscrowFunds(escrow *models.EscrowTransaction) error {
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[escrow.ID]++
+	f.mu.Unlock()
+
+	time.Sleep(f.latency)
+	if f.failFor[escrow.ID] {
+		return fmt.Errorf("destination account restricted")
+	}
+	return nil
+}
+
+// seedReleasableEscrows creates held escrows that are past the no-rating grace period
+func seedReleasableEscrows(count int) []*models.EscrowTransaction {
+	eligibleAt := time.Now().Add(-(models.DefaultRatingDeadlineDays + 1) * 24 * time.Hour)
+	escrows := make([]*models.EscrowTransaction, count)
+	for i := range escrows {
+		escrows[i] = &models.EscrowTransaction{
+			ID:                fmt.Sprintf("escrow_%03d", i),
+			Amount:            10.0,
+			Status:            models.EscrowStatusHeld,
+			ReleaseEligibleAt: eligibleAt.Add(time.Duration(i) * time.Second),
+			MinRatingRequired: 3.0,
+		}
+	}
+	return escrows
+}
+
+func TestProcessAutomaticReleasesBatches(t *testing.T) {
+	t.Run("should page through all eligible escrows and batch status updates", func(t *testing.T) {
+		escrows := seedReleasableEscrows(25)
+		escrows[3].RatingReceived = true // Poor rating, moved to manual review
+		escrows[3].ActualRating = 1.0
+		store := NewMemoryEscrowStore(escrows...)
+
+		service := &PaymentService{
+			escrowStore:   store,
+			ratingStore:   NewMemoryRatingStore(),
+			fundsReleaser: &fakeFundsReleaser{failFor: map[string]bool{"escrow_007": true}},
+			releaseLimits: AutoReleaseLimits{BatchSize: 10, Concurrency: 4, MaxPerRun: 100},
+		}
+
+		processed, failed, errs, totalReleased, err := service.ProcessAutomaticReleases()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 23, processed)
+		assert.Equal(t, 1, failed)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, 230.0, totalReleased)
+
+		ctx := context.Background()
+		poorRating, _ := store.Get(ctx, "escrow_003")
+		assert.Equal(t, models.EscrowStatusUnderReview, poorRating.Status)
+
+		failedEscrow, _ := store.Get(ctx, "escrow_007")
+		assert.Equal(t, models.EscrowStatusHeld, failedEscrow.Status)
+		assert.Equal(t, 1, failedEscrow.ReleaseFailureCount)
+
+		released, _ := store.Get(ctx, "escrow_024")
+		assert.Equal(t, models.EscrowStatusReleased, released.Status)
+	})
+
+	t.Run("should stop at the per-run cap", func(t *testing.T) {
+		store := NewMemoryEscrowStore(seedReleasableEscrows(25)...)
+		service := &PaymentService{
+			escrowStore:   store,
+			ratingStore:   NewMemoryRatingStore(),
+			fundsReleaser: &fakeFundsReleaser{},
+			releaseLimits: AutoReleaseLimits{BatchSize: 10, Concurrency: 4, MaxPerRun: 15},
+		}
+
+		processed, failed, _, _, err := service.ProcessAutomaticReleases()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 15, processed)
+		assert.Equal(t, 0, failed)
+
+		remaining, err := store.ListEligibleForRelease(context.Background(), time.Now(), nil, 100)
+		assert.NoError(t, err)
+		assert.Len(t, remaining, 10)
+		assert.Equal(t, "escrow_015", remaining[0].ID, "oldest escrows should be released first")
+	})
+
+	t.Run("should re-evaluate pending_rating and approved escrows", func(t *testing.T) {
+		escrows := seedReleasableEscrows(4)
+		escrows[0].Status = models.EscrowStatusPendingRating // Past grace period, no rating
+		escrows[1].Status = models.EscrowStatusApproved
+		escrows[1].ReleaseEligibleAt = time.Now().Add(-1 * time.Minute)
+		escrows[2].Status = models.EscrowStatusPendingRating // Rating arrived after being parked
+		escrows[2].ReleaseEligibleAt = time.Now().Add(-2 * time.Hour)
+		escrows[2].RatingReceived = true
+		escrows[2].ActualRating = 4.0
+		escrows[3].Status = models.EscrowStatusPendingRating // Still within grace period
+		escrows[3].ReleaseEligibleAt = time.Now().Add(-2 * time.Hour)
+		store := NewMemoryEscrowStore(escrows...)
+
+		service := &PaymentService{escrowStore: store, ratingStore: NewMemoryRatingStore(), fundsReleaser: &fakeFundsReleaser{}}
+
+		processed, failed, _, _, err := service.ProcessAutomaticReleases()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, processed)
+		assert.Equal(t, 0, failed)
+
+		waiting, _ := store.Get(context.Background(), "escrow_003")
+		assert.Equal(t, models.EscrowStatusPendingRating, waiting.Status)
+	})
+
+	t.Run("should not count waiting escrows towards the per-run cap", func(t *testing.T) {
+		escrows := seedReleasableEscrows(10)
+		for _, escrow := range escrows[:5] {
+			escrow.Status = models.EscrowStatusPendingRating
+			escrow.RatingReceived = true
+			escrow.ActualRating = 1.0
+		}
+		store := NewMemoryEscrowStore(escrows...)
+
+		service := &PaymentService{
+			escrowStore:   store,
+			ratingStore:   NewMemoryRatingStore(),
+			fundsReleaser: &fakeFundsReleaser{},
+			releaseLimits: AutoReleaseLimits{BatchSize: 3, Concurrency: 2, MaxPerRun: 5},
+		}
+
+		processed, _, _, _, err := service.ProcessAutomaticReleases()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, processed)
+	})
+
+	t.Run("should leave escrows released since the batch was listed alone", func(t *testing.T) {
+		store := NewMemoryEscrowStore(seedReleasableEscrows(4)...)
+		service := &PaymentService{
+			escrowStore:   store,
+			fundsReleaser: &fakeFundsReleaser{},
+		}
+
+		ctx := context.Background()
+		batch, err := store.ListEligibleForRelease(ctx, time.Now(), nil, 10)
+		assert.NoError(t, err)
+		// An overlapping run releases half of the batch first
+		assert.NoError(t, service.ProcessEscrowRelease("escrow_000", "automatic_release"))
+		assert.NoError(t, service.ProcessEscrowRelease("escrow_001", "automatic_release"))
+
+		tally := &autoReleaseTally{}
+		service.processReleaseBatch(ctx, batch, 2, 10, tally)
+
+		assert.Equal(t, 2, tally.processed)
+		assert.Zero(t, tally.failed, "errors: %v", tally.errors)
+		for _, original := range batch {
+			escrow, err := store.Get(ctx, original.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, models.EscrowStatusReleased, escrow.Status, "the stale batch copy of %s must not be written back", escrow.ID)
+			assert.Zero(t, escrow.ReleaseFailureCount)
+		}
+	})
+
+	t.Run("should release each escrow once when overlapping runs both listed it", func(t *testing.T) {
+		store := NewMemoryEscrowStore(seedReleasableEscrows(3)...)
+		releaser := &fakeFundsReleaser{latency: 20 * time.Millisecond}
+		service := &PaymentService{
+			escrowStore:   store,
+			ratingStore:   NewMemoryRatingStore(),
+			fundsReleaser: releaser,
+		}
+
+		// Both runs read every escrow as held before either releases one
+		ctx := context.Background()
+		first, err := store.ListEligibleForRelease(ctx, time.Now(), nil, 10)
+		require.NoError(t, err)
+		second, err := store.ListEligibleForRelease(ctx, time.Now(), nil, 10)
+		require.NoError(t, err)
+
+		tallies := []*autoReleaseTally{{}, {}}
+		var wg sync.WaitGroup
+		for i, batch := range [][]*models.EscrowTransaction{first, second} {
+			wg.Add(1)
+			go func(batch []*models.EscrowTransaction, tally *autoReleaseTally) {
+				defer wg.Done()
+				service.processReleaseBatch(ctx, batch, 3, 10, tally)
+			}(batch, tallies[i])
+		}
+		wg.Wait()
+
+		assert.Equal(t, 3, tallies[0].processed+tallies[1].processed)
+		assert.Zero(t, tallies[0].failed+tallies[1].failed)
+		assert.Equal(t, map[string]int{"escrow_000": 1, "escrow_001": 1, "escrow_002": 1}, releaser.calls, "funds moved once per escrow")
+		released, err := store.CountByStatus(ctx, models.EscrowStatusReleased)
+		require.NoError(t, err)
+		assert.Equal(t, 3, released)
+	})
+
+	t.Run("should record a failure without overwriting changes made since the batch was listed", func(t *testing.T) {
+		store := NewMemoryEscrowStore(seedReleasableEscrows(1)...)
+		service := &PaymentService{
+			escrowStore:   store,
+			ratingStore:   NewMemoryRatingStore(),
+			fundsReleaser: &fakeFundsReleaser{failFor: map[string]bool{"escrow_000": true}},
+			notifications: &FakeNotifier{},
+		}
+
+		ctx := context.Background()
+		batch, err := store.ListEligibleForRelease(ctx, time.Now(), nil, 10)
+		require.NoError(t, err)
+		// A late rating lands after the batch was listed
+		_, err = store.Update(ctx, "escrow_000", func(escrow *models.EscrowTransaction) error {
+			escrow.RatingCount = 2
+			return nil
+		})
+		require.NoError(t, err)
+
+		tally := &autoReleaseTally{}
+		service.processReleaseBatch(ctx, batch, 1, 10, tally)
+
+		assert.Equal(t, 1, tally.failed)
+		escrow, err := store.Get(ctx, "escrow_000")
+		require.NoError(t, err)
+		assert.Equal(t, models.EscrowStatusHeld, escrow.Status, "the release claim is handed back")
+		assert.Equal(t, 1, escrow.ReleaseFailureCount)
+		assert.Equal(t, 2, escrow.RatingCount, "the rating update is kept")
+	})
+
+	t.Run("should store refreshed ratings without overwriting changes made since the batch was listed", func(t *testing.T) {
+		store := NewMemoryEscrowStore(&models.EscrowTransaction{
+			ID:                "escrow_rated",
+			GameID:            "game_rated",
+			OrganizerID:       "organizer_1",
+			Status:            models.EscrowStatusPendingRating,
+			ReleaseEligibleAt: time.Now().Add(-time.Hour),
+			MinRatingRequired: 3.0,
+		})
+		ratings := NewMemoryRatingStore()
+		ratings.SetPlayersPresent("game_rated", "player_1")
+		require.NoError(t, ratings.SaveRating(context.Background(), &models.RatingValidation{
+			ID: "game_rated_player_1", GameID: "game_rated", RatedPlayerID: "organizer_1", RaterID: "player_1", Rating: 4, CreatedAt: time.Now(),
+		}))
+		releaser := &fakeFundsReleaser{}
+		service := &PaymentService{escrowStore: store, ratingStore: ratings, fundsReleaser: releaser}
+
+		ctx := context.Background()
+		batch, err := store.ListEligibleForRelease(ctx, time.Now(), nil, 10)
+		require.NoError(t, err)
+		// A reviewer disputes the escrow after the batch was listed
+		_, err = store.Update(ctx, "escrow_rated", func(escrow *models.EscrowTransaction) error {
+			escrow.Status = models.EscrowStatusDisputed
+			return nil
+		})
+		require.NoError(t, err)
+
+		service.processReleaseBatch(ctx, batch, 1, 10, &autoReleaseTally{})
+
+		escrow, err := store.Get(ctx, "escrow_rated")
+		require.NoError(t, err)
+		assert.Equal(t, models.EscrowStatusDisputed, escrow.Status, "the dispute is kept")
+		assert.Equal(t, 4.0, escrow.ActualRating, "the refreshed rating is stored")
+		assert.Empty(t, releaser.calls)
+	})
+
+	t.Run("should keep a decision made since the batch was listed when the escrow is not eligible", func(t *testing.T) {
+		store := NewMemoryEscrowStore(&models.EscrowTransaction{
+			ID:                "escrow_waiting",
+			Status:            models.EscrowStatusHeld,
+			ReleaseEligibleAt: time.Now().Add(-time.Hour),
+		}, &models.EscrowTransaction{
+			ID:                "escrow_untouched",
+			Status:            models.EscrowStatusHeld,
+			ReleaseEligibleAt: time.Now().Add(-time.Hour),
+		})
+		service := &PaymentService{escrowStore: store, ratingStore: NewMemoryRatingStore(), fundsReleaser: &fakeFundsReleaser{}}
+
+		ctx := context.Background()
+		batch, err := store.ListEligibleForRelease(ctx, time.Now(), nil, 10)
+		require.NoError(t, err)
+		// A reviewer refunds one escrow after the batch was listed
+		_, err = store.Update(ctx, "escrow_waiting", func(escrow *models.EscrowTransaction) error {
+			escrow.Status = models.EscrowStatusRefunded
+			return nil
+		})
+		require.NoError(t, err)
+
+		service.processReleaseBatch(ctx, batch, 1, 10, &autoReleaseTally{})
+
+		refunded, err := store.Get(ctx, "escrow_waiting")
+		require.NoError(t, err)
+		assert.Equal(t, models.EscrowStatusRefunded, refunded.Status, "the refund is kept")
+
+		waiting, err := store.Get(ctx, "escrow_untouched")
+		require.NoError(t, err)
+		assert.Equal(t, models.EscrowStatusPendingRating, waiting.Status, "unchanged escrows still move to pending_rating")
+	})
+
+	t.Run("should stop between escrows when cancelled", func(t *testing.T) {
+		store := NewMemoryEscrowStore(seedReleasableEscrows(5)...)
+		service := &PaymentService{
+			escrowStore:   store,
+			ratingStore:   NewMemoryRatingStore(),
+			fundsReleaser: &fakeFundsReleaser{},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		processed, _, _, _, err := service.ProcessAutomaticReleasesContext(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, processed)
+	})
+}
+
+func TestProcessAutomaticReleasesFrom(t *testing.T) {
+	t.Run("should return a cursor when stopped early and resume after it", func(t *testing.T) {
+		store := NewMemoryEscrowStore(seedReleasableEscrows(10)...)
+		service := &PaymentService{
+			escrowStore:   store,
+			ratingStore:   NewMemoryRatingStore(),
+			fundsReleaser: &fakeFundsReleaser{},
+			releaseLimits: AutoReleaseLimits{BatchSize: 3, Concurrency: 2, MaxPerRun: 4},
+		}
+		ctx := context.Background()
+
+		processed, _, _, _, next, err := service.ProcessAutomaticReleasesFrom(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 4, processed)
+		require.NotNil(t, next)
+		assert.Equal(t, "escrow_003", next.ID)
+
+		processed, _, _, _, next, err = service.ProcessAutomaticReleasesFrom(ctx, next)
+		require.NoError(t, err)
+		assert.Equal(t, 4, processed)
+		require.NotNil(t, next)
+		assert.Equal(t, "escrow_007", next.ID)
+
+		processed, _, _, _, next, err = service.ProcessAutomaticReleasesFrom(ctx, next)
+		require.NoError(t, err)
+		assert.Equal(t, 2, processed)
+		assert.Nil(t, next, "a run that reaches the end should not leave a cursor")
+	})
+
+	t.Run("should skip escrows up to the cursor", func(t *testing.T) {
+		store := NewMemoryEscrowStore(seedReleasableEscrows(6)...)
+		service := &PaymentService{escrowStore: store, ratingStore: NewMemoryRatingStore(), fundsReleaser: &fakeFundsReleaser{}}
+		ctx := context.Background()
+
+		after, err := store.Get(ctx, "escrow_002")
+		require.NoError(t, err)
+
+		processed, _, _, _, next, err := service.ProcessAutomaticReleasesFrom(ctx, after)
+		require.NoError(t, err)
+		assert.Equal(t, 3, processed)
+		assert.Nil(t, next)
+
+		skipped, _ := store.Get(ctx, "escrow_000")
+		assert.Equal(t, models.EscrowStatusHeld, skipped.Status)
+	})
+
+	t.Run("should keep the cursor when interrupted", func(t *testing.T) {
+		store := NewMemoryEscrowStore(seedReleasableEscrows(5)...)
+		service := &PaymentService{escrowStore: store, ratingStore: NewMemoryRatingStore(), fundsReleaser: &fakeFundsReleaser{}}
+		after, err := store.Get(context.Background(), "escrow_001")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, _, _, next, err := service.ProcessAutomaticReleasesFrom(ctx, after)
+		assert.ErrorIs(t, err, context.Canceled)
+		require.NotNil(t, next)
+		assert.Equal(t, "escrow_001", next.ID)
+	})
+}
+
+func BenchmarkProcessAutomaticReleases(b *testing.B) {
+	const escrowCount = 100
+
+	for _, concurrency := range []int{1, 8} {
+		b.Run(fmt.Sprintf("concurrency_%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				store := NewMemoryEscrowStore(seedReleasableEscrows(escrowCount)...)
+				store.Latency = 500 * time.Microsecond
+				service := &PaymentService{
+					escrowStore:   store,
+					ratingStore:   NewMemoryRatingStore(),
+					fundsReleaser: &fakeFundsReleaser{latency: 1 * time.Millisecond},
+					releaseLimits: AutoReleaseLimits{BatchSize: 25, Concurrency: concurrency, MaxPerRun: escrowCount},
+				}
+				b.StartTimer()
+
+				if processed, _, _, _, err := service.ProcessAutomaticReleases(); err != nil || processed != escrowCount {
+					b.Fatalf("expected %d releases, got %d (err: %v)", escrowCount, processed, err)
+				}
+			}
+			b.ReportMetric(float64(escrowCount*b.N)/b.Elapsed().Seconds(), "escrows/s")
+		})
+	}
+}
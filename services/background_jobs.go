@@ -3,12 +3,18 @@ package services
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
+	"cloud.google.com/go/firestore"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/logging"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/iterator"
 )
 
@@ -20,6 +26,24 @@ type JobConfig struct {
 	RatingDeadlineDays       int           `json:"ratingDeadlineDays"`
 	MinRatingForAutoRelease  float64       `json:"minRatingForAutoRelease"`
 	DisputeEscalationHours   int           `json:"disputeEscalationHours"`
+	EscrowHoldPeriod         time.Duration `json:"escrowHoldPeriod"`
+	ReleaseGracePeriod       time.Duration `json:"releaseGracePeriod"`
+	RatingAggregation        string        `json:"ratingAggregation"`
+	MinRaters                int           `json:"minRaters"`
+	OrganizerPolicies        map[string]models.ReleasePolicyOverride `json:"organizerPolicies,omitempty"` // Keyed by organizer ID
+}
+
+// interval returns how often the job is scheduled
+func (c *JobConfig) interval(jobKey string) time.Duration {
+	switch jobKey {
+	case "rating_reminder":
+		return c.RatingReminderInterval
+	case "auto_release":
+		return c.AutoReleaseInterval
+	case "dispute_escalation":
+		return c.DisputeEscalationInterval
+	}
+	return 0
 }
 
 // BackgroundJobManager manages all background jobs
@@ -29,6 +53,18 @@ type BackgroundJobManager struct {
 	wg       sync.WaitGroup
 	running  bool
 	mu       sync.Mutex
+	ctx      context.Context    // cancelled on shutdown so running jobs can stop early
+	cancel   context.CancelFunc
+
+	checkpointStore JobCheckpointStore  // Defaults to Firestore when nil
+	maxReleases     int                 // Caps releases per auto_release run below the config when set
+	fundsReleaser   escrowFundsReleaser // Moves escrowed funds on auto-release; defaults to Stripe when nil
+	clock           Clock               // Tells the time for job windows and releases; defaults to the process clock when nil
+	cronBudget      time.Duration       // Time a cron invocation may spend running its job
+
+	scheduled  bool                 // Whether schedulers run in this process (false in cron mode)
+	beatMu     sync.Mutex
+	heartbeats map[string]time.Time // Last sign of life of each job's scheduler
 }
 
 // JobStatus represents the status of a background job
@@ -41,6 +77,7 @@ type JobStatus struct {
 	ErrorCount     int       `json:"errorCount"`
 	AverageRuntime string    `json:"averageRuntime"`
 	IsRunning      bool      `json:"isRunning"`
+	CurrentRunID   string    `json:"currentRunId,omitempty"`
 	Enabled        bool      `json:"enabled"`
 }
 
@@ -62,53 +99,135 @@ var (
 
 // StartBackgroundJobs initializes and starts all background jobs
 func StartBackgroundJobs() *BackgroundJobManager {
-	jobsConf := config.GetJobsConfig()
-	
-	config := &JobConfig{
-		RatingReminderInterval:    jobsConf.RatingReminderInterval,
-		AutoReleaseInterval:       jobsConf.AutoReleaseInterval,
-		DisputeEscalationInterval: jobsConf.DisputeEscalationInterval,
-		RatingDeadlineDays:        jobsConf.RatingDeadlineDays,
-		MinRatingForAutoRelease:   jobsConf.MinRatingForAutoRelease,
-		DisputeEscalationHours:    jobsConf.DisputeEscalationHours,
-	}
+	config := jobConfigFromEnv()
 
+	ctx, cancel := context.WithCancel(context.Background())
 	jobManager = &BackgroundJobManager{
 		config:   config,
 		shutdown: make(chan struct{}),
 		running:  true,
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 
 	// Initialize job statuses
 	initializeJobStatuses(config)
 
-	log.Printf("[BackgroundJobs] Starting job system with intervals: Rating=%v, Release=%v, Dispute=%v", 
-		config.RatingReminderInterval, config.AutoReleaseInterval, config.DisputeEscalationInterval)
+	slog.Info("Starting background jobs",
+		"rating_reminder_interval", config.RatingReminderInterval.String(),
+		"auto_release_interval", config.AutoReleaseInterval.String(),
+		"dispute_escalation_interval", config.DisputeEscalationInterval.String())
 
-	// Start each job in its own goroutine
-	jobManager.wg.Add(3)
-	go jobManager.runRatingReminderJob()
-	go jobManager.runAutoReleaseJob()
-	go jobManager.runDisputeEscalationJob()
+	// Start each job's scheduler in its own goroutine
+	jobManager.scheduled = true
+	jobManager.wg.Add(len(jobRegistry))
+	for _, job := range jobRegistry {
+		go jobManager.runScheduler(job, config.interval(job.Key))
+	}
 
-	log.Printf("[BackgroundJobs] All jobs started successfully")
+	slog.Info("All background jobs started")
 	return jobManager
 }
 
-// StopBackgroundJobs gracefully shuts down all background jobs
+// StopBackgroundJobs gracefully shuts down all background jobs, waiting for running jobs to finish
 func (jm *BackgroundJobManager) StopBackgroundJobs() {
-	jm.mu.Lock()
-	defer jm.mu.Unlock()
+	jm.Shutdown(context.Background())
+}
 
+// Shutdown stops the job tickers, cancels the context of running jobs and waits for them
+// to return until ctx expires. Runs still active at the deadline are recorded as aborted.
+func (jm *BackgroundJobManager) Shutdown(ctx context.Context) error {
+	jm.mu.Lock()
 	if !jm.running {
-		return
+		jm.mu.Unlock()
+		return nil
 	}
 
-	log.Printf("[BackgroundJobs] Shutting down all jobs...")
+	slog.Info("Shutting down background jobs")
 	jm.running = false
 	close(jm.shutdown)
-	jm.wg.Wait()
-	log.Printf("[BackgroundJobs] All jobs stopped")
+	if jm.cancel != nil {
+		jm.cancel()
+	}
+	jm.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		jm.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("All background jobs stopped")
+		return nil
+	case <-ctx.Done():
+		aborted := abortActiveJobRuns("Aborted: service shut down before the run completed")
+		slog.Warn("Shutdown deadline reached, runs recorded as aborted", "aborted_runs", aborted)
+		return fmt.Errorf("timed out waiting for jobs to stop: %w", ctx.Err())
+	}
+}
+
+// now returns the current time on the manager's clock
+func (jm *BackgroundJobManager) now() time.Time {
+	if jm.clock == nil {
+		return ProcessClock().Now()
+	}
+	return jm.clock.Now()
+}
+
+// jobContext returns the context running jobs should observe for cancellation
+func (jm *BackgroundJobManager) jobContext() context.Context {
+	if jm.ctx == nil {
+		return context.Background()
+	}
+	return jm.ctx
+}
+
+// startRunSpan starts the span of a job run. The returned context carries the run, so everything
+// logged during the run has its job_name and run_id, and every span is a child of the run's.
+func (jm *BackgroundJobManager) startRunSpan(run *JobRun) (context.Context, trace.Span) {
+	ctx := logging.WithJobRun(jm.jobContext(), run.JobKey, run.ID)
+	return tracing.Start(ctx, "job."+run.JobKey,
+		attribute.String(tracing.KeyJobName, run.JobKey),
+		attribute.String(tracing.KeyRunID, run.ID),
+		attribute.String("trigger", run.Trigger),
+	)
+}
+
+// endRunSpan records the run's result on its span and ends it
+func endRunSpan(span trace.Span, result string, hasError, aborted bool) {
+	span.SetAttributes(attribute.String("result", result), attribute.Bool("aborted", aborted))
+	if hasError {
+		span.SetStatus(codes.Error, result)
+	}
+	span.End()
+}
+
+// startManualRun runs a manually triggered job in the background, tracked by the
+// manager so shutdown waits for it like it does for scheduled runs
+func (jm *BackgroundJobManager) startManualRun(jobKey, triggeredBy string, runFunc func(*JobRun)) (string, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	if !jm.running {
+		return "", fmt.Errorf("job manager is shutting down")
+	}
+
+	run, err := beginJobRun(jobKey, JobTriggerManual)
+	if err != nil {
+		return "", err
+	}
+	statusMutex.Lock()
+	run.TriggeredBy = triggeredBy
+	statusMutex.Unlock()
+
+	jm.wg.Add(1)
+	go func() {
+		defer jm.wg.Done()
+		runFunc(run)
+	}()
+	return run.ID, nil
 }
 
 // GetJobStatuses returns current status of all jobs
@@ -155,10 +274,14 @@ func UpdateJobConfig(newConfig *JobConfig) error {
 		return fmt.Errorf("job manager not initialized")
 	}
 
+	if err := newConfig.ReleasePolicies().Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	jobManager.mu.Lock()
 	defer jobManager.mu.Unlock()
 
-	log.Printf("[Config] Updating job configuration...")
+	slog.Info("Updating job configuration")
 	jobManager.config = newConfig
 
 	// Update next scheduled times based on new intervals
@@ -174,7 +297,7 @@ func UpdateJobConfig(newConfig *JobConfig) error {
 	}
 	statusMutex.Unlock()
 
-	log.Printf("[Config] Job configuration updated successfully")
+	slog.Info("Job configuration updated")
 	return nil
 }
 
@@ -186,29 +309,18 @@ func GetJobConfig() *JobConfig {
 	return jobManager.config
 }
 
-// Trigger methods for manual job execution
-func TriggerRatingReminder() error {
-	if jobManager == nil {
-		return fmt.Errorf("job manager not initialized")
-	}
-	go jobManager.runRatingReminder()
-	return nil
+// Trigger methods for manual job execution. Each returns the ID of the started run,
+// or a *JobAlreadyRunningError if a run of the same job is still in progress.
+func TriggerRatingReminder(triggeredBy string) (string, error) {
+	return TriggerJob("rating-reminder", triggeredBy)
 }
 
-func TriggerAutoRelease() error {
-	if jobManager == nil {
-		return fmt.Errorf("job manager not initialized")
-	}
-	go jobManager.runAutoRelease()
-	return nil
+func TriggerAutoRelease(triggeredBy string) (string, error) {
+	return TriggerJob("auto-release", triggeredBy)
 }
 
-func TriggerDisputeEscalation() error {
-	if jobManager == nil {
-		return fmt.Errorf("job manager not initialized")
-	}
-	go jobManager.runDisputeEscalation()
-	return nil
+func TriggerDisputeEscalation(triggeredBy string) (string, error) {
+	return TriggerJob("dispute-escalation", triggeredBy)
 }
 
 // Internal job execution methods
@@ -255,122 +367,123 @@ func updateJobStatus(jobName string, result string, runTime time.Duration, hasEr
 
 		// Calculate next scheduled run
 		if jobManager != nil {
-			switch jobName {
-			case "rating_reminder":
-				status.NextScheduled = time.Now().Add(jobManager.config.RatingReminderInterval)
-			case "auto_release":
-				status.NextScheduled = time.Now().Add(jobManager.config.AutoReleaseInterval)
-			case "dispute_escalation":
-				status.NextScheduled = time.Now().Add(jobManager.config.DisputeEscalationInterval)
-			}
+			status.NextScheduled = time.Now().Add(jobManager.config.interval(jobName))
 		}
 	}
 }
 
-// Job runner methods (implement the actual job logic from original background_jobs.go)
-func (jm *BackgroundJobManager) runRatingReminderJob() {
-	defer jm.wg.Done()
-	
-	ticker := time.NewTicker(jm.config.RatingReminderInterval)
-	defer ticker.Stop()
-
-	log.Printf("[RatingReminderJob] Started (interval: %v)", jm.config.RatingReminderInterval)
+// schedulerHeartbeatInterval is how often an idle scheduler reports that it is alive
+const schedulerHeartbeatInterval = 30 * time.Second
 
-	for {
-		select {
-		case <-jm.shutdown:
-			log.Printf("[RatingReminderJob] Shutting down")
-			return
-		case <-ticker.C:
-			jm.runRatingReminder()
-		}
-	}
-}
-
-func (jm *BackgroundJobManager) runAutoReleaseJob() {
+// runScheduler runs the job on every tick of its interval until shutdown. Between runs it beats a
+// heartbeat, so readiness can tell a scheduler that died or got stuck from one that is idle.
+func (jm *BackgroundJobManager) runScheduler(job JobDefinition, interval time.Duration) {
 	defer jm.wg.Done()
-	
-	ticker := time.NewTicker(jm.config.AutoReleaseInterval)
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	heartbeat := time.NewTicker(schedulerHeartbeatInterval)
+	defer heartbeat.Stop()
 
-	log.Printf("[AutoReleaseJob] Started (interval: %v)", jm.config.AutoReleaseInterval)
+	slog.Info("Job scheduler started", "job_name", job.Key, "interval", interval.String())
+	jm.beat(job.Key)
 
 	for {
 		select {
 		case <-jm.shutdown:
-			log.Printf("[AutoReleaseJob] Shutting down")
+			slog.Info("Job scheduler stopped", "job_name", job.Key)
 			return
+		case <-heartbeat.C:
+			jm.beat(job.Key)
 		case <-ticker.C:
-			jm.runAutoRelease()
+			run, err := beginJobRun(job.Key, JobTriggerScheduled)
+			if err != nil {
+				slog.Warn("Skipping scheduled run", "job_name", job.Key, "error", err)
+				continue
+			}
+			job.run(jm, run)
+			jm.beat(job.Key)
 		}
 	}
 }
 
-func (jm *BackgroundJobManager) runDisputeEscalationJob() {
-	defer jm.wg.Done()
-	
-	ticker := time.NewTicker(jm.config.DisputeEscalationInterval)
-	defer ticker.Stop()
-
-	log.Printf("[DisputeEscalationJob] Started (interval: %v)", jm.config.DisputeEscalationInterval)
-
-	for {
-		select {
-		case <-jm.shutdown:
-			log.Printf("[DisputeEscalationJob] Shutting down")
-			return
-		case <-ticker.C:
-			jm.runDisputeEscalation()
-		}
+// beat records that the job's scheduler is alive
+func (jm *BackgroundJobManager) beat(jobKey string) {
+	jm.beatMu.Lock()
+	defer jm.beatMu.Unlock()
+	if jm.heartbeats == nil {
+		jm.heartbeats = make(map[string]time.Time)
 	}
+	jm.heartbeats[jobKey] = time.Now()
 }
 
-func (jm *BackgroundJobManager) runRatingReminder() {
+func (jm *BackgroundJobManager) runRatingReminder(run *JobRun) {
 	start := time.Now()
-	log.Printf("[RatingReminderJob] Starting execution")
-
-	statusMutex.Lock()
-	if status, exists := jobStatuses["rating_reminder"]; exists {
-		status.IsRunning = true
-	}
-	statusMutex.Unlock()
+	ctx, span := jm.startRunSpan(run)
+	slog.InfoContext(ctx, "Job run started", "trigger", run.Trigger)
 
 	var result string
 	var hasError bool
+	var aborted bool
 
+	// Runs after the run is recorded, so a slow Slack post doesn't hold up its status
+	defer sendDueDigest(context.WithoutCancel(ctx))
 	defer func() {
-		updateJobStatus("rating_reminder", result, time.Since(start), hasError)
+		if aborted {
+			abortJobRun(run, result, time.Since(start))
+			return
+		}
+		finishJobRun(run, result, time.Since(start), hasError)
 	}()
+	defer func() { endRunSpan(span, result, hasError, aborted) }()
 
 	// Check if Firestore client is available
 	firestoreClient := config.FirestoreClient()
 	if firestoreClient == nil {
-		log.Printf("[RatingReminderJob] Firestore client not available (test environment?)")
+		slog.WarnContext(ctx, "Firestore client not available, skipping run")
 		result = "Skipped - no Firestore client available"
 		
-		// Send Slack notification even when skipped
-		paymentService := NewPaymentService()
-		paymentService.SendSlackRatingJobNotification(0, 0, 0, time.Since(start))
+		// Report the run even when skipped
+		paymentService := NewJobPaymentService().WithContext(ctx)
+		paymentService.NotifyRatingJobSummary(0, 0, 0, time.Since(start))
 		return
 	}
 
-	ctx := context.Background()
-	
 	// Implementation from original background_jobs.go
-	sevenDaysAgo := time.Now().AddDate(0, 0, -jm.config.RatingDeadlineDays)
-	oneDayAgo := time.Now().Add(-24 * time.Hour)
+	now := jm.now()
+	sevenDaysAgo := now.AddDate(0, 0, -jm.config.RatingDeadlineDays)
+	oneDayAgo := now.Add(-24 * time.Hour)
 
 	query := firestoreClient.Collection("matches").
 		Where("status", "==", "completed").
 		Where("completedAt", ">=", sevenDaysAgo).
-		Where("completedAt", "<=", oneDayAgo)
+		Where("completedAt", "<=", oneDayAgo).
+		OrderBy("completedAt", firestore.Asc)
+
+	// Resume after the last match reminded by a run that stopped early
+	var lastCompletedAt *time.Time
+	if cursor := jm.loadCheckpoint(ctx, run.JobKey); cursor != "" {
+		if completedAt, err := time.Parse(time.RFC3339Nano, cursor); err == nil {
+			query = query.StartAfter(completedAt)
+			lastCompletedAt = &completedAt
+		}
+	}
 
 	iter := query.Documents(ctx)
+	defer iter.Stop()
 	remindersSent := 0
 	errors := 0
 	matchesChecked := 0
+	budgetSpent := false
 
 	for {
+		if ctx.Err() != nil {
+			budgetSpent = ctx.Err() == context.DeadlineExceeded
+			aborted = !budgetSpent
+			jm.saveCheckpoint(ctx, run, timeCursor(lastCompletedAt))
+			break
+		}
+
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
@@ -378,14 +491,14 @@ func (jm *BackgroundJobManager) runRatingReminder() {
 		matchesChecked++
 		
 		if err != nil {
-			log.Printf("[RatingReminderJob] Error iterating matches: %v", err)
+			slog.ErrorContext(ctx, "Error iterating matches", "error", err)
 			errors++
 			continue
 		}
 
 		var match models.Match
 		if err := doc.DataTo(&match); err != nil {
-			log.Printf("[RatingReminderJob] Failed to parse match: %v", err)
+			slog.ErrorContext(ctx, "Failed to parse match", "game_id", doc.Ref.ID, "error", err)
 			errors++
 			continue
 		}
@@ -397,9 +510,20 @@ func (jm *BackgroundJobManager) runRatingReminder() {
 				remindersSent++
 			}
 		}
+		lastCompletedAt = match.CompletedAt
 	}
 
-	log.Printf("[RatingReminderJob] Job summary: matchesChecked=%d, remindersSent=%d, errors=%d", matchesChecked, remindersSent, errors)
+	slog.InfoContext(ctx, "Job summary", "matches_checked", matchesChecked, "reminders_sent", remindersSent, "errors", errors)
+
+	if aborted {
+		result = fmt.Sprintf("Aborted: sent %d reminders for %d matches before shutdown", remindersSent, matchesChecked)
+		slog.WarnContext(ctx, result)
+		return
+	}
+
+	if !budgetSpent {
+		jm.saveCheckpoint(ctx, run, "")
+	}
 
 	if errors > 0 {
 		hasError = true
@@ -407,47 +531,88 @@ func (jm *BackgroundJobManager) runRatingReminder() {
 	} else {
 		result = fmt.Sprintf("Successfully sent %d rating reminders", remindersSent)
 	}
+	if budgetSpent {
+		result += ", stopped at the time budget and the next run resumes from the checkpoint"
+	}
 
-	// Send Slack notification with job summary
-	paymentService := NewPaymentService()
-	paymentService.SendSlackRatingJobNotification(matchesChecked, remindersSent, errors, time.Since(start))
+	// Report the job summary
+	paymentService := NewJobPaymentService().WithContext(ctx)
+	paymentService.NotifyRatingJobSummary(matchesChecked, remindersSent, errors, time.Since(start))
+	setJobRunAttempts(run, paymentService.RetryStats())
 
-	log.Printf("[RatingReminderJob] Completed: %s (runtime: %v)", result, time.Since(start))
+	slog.InfoContext(ctx, "Job run completed", "result", result, "runtime", time.Since(start).String())
 }
 
-func (jm *BackgroundJobManager) runAutoRelease() {
+func (jm *BackgroundJobManager) runAutoRelease(run *JobRun) {
 	start := time.Now()
-	log.Printf("[AutoReleaseJob] Starting execution")
-
-	statusMutex.Lock()
-	if status, exists := jobStatuses["auto_release"]; exists {
-		status.IsRunning = true
-	}
-	statusMutex.Unlock()
+	ctx, span := jm.startRunSpan(run)
+	slog.InfoContext(ctx, "Job run started", "trigger", run.Trigger)
 
 	var result string
 	var hasError bool
+	var aborted bool
 
+	// Runs after the run is recorded, so a slow Slack post doesn't hold up its status
+	defer sendDueDigest(context.WithoutCancel(ctx))
 	defer func() {
-		updateJobStatus("auto_release", result, time.Since(start), hasError)
+		if aborted {
+			abortJobRun(run, result, time.Since(start))
+			return
+		}
+		finishJobRun(run, result, time.Since(start), hasError)
 	}()
+	defer func() { endRunSpan(span, result, hasError, aborted) }()
 
 	// Check if Firestore client is available
 	firestoreClient := config.FirestoreClient()
 	if firestoreClient == nil {
-		log.Printf("[AutoReleaseJob] Firestore client not available (test environment?)")
+		slog.WarnContext(ctx, "Firestore client not available, skipping run")
 		result = "Skipped - no Firestore client available"
 		return
 	}
 
-	// Process automatic escrow releases
-	paymentService := NewPaymentService()
-	processed, failed, errors, totalReleased, err := paymentService.ProcessAutomaticReleases()
-	
-	if err != nil {
+	// Process automatic escrow releases, resuming after the escrow where the last run stopped
+	paymentService := NewJobPaymentService().WithContext(ctx)
+	if limits := paymentService.releaseLimits.withDefaults(); jm.maxReleases > 0 && jm.maxReleases < limits.MaxPerRun {
+		limits.MaxPerRun = jm.maxReleases
+		paymentService.releaseLimits = limits
+	}
+	if jm.fundsReleaser != nil {
+		paymentService.fundsReleaser = jm.fundsReleaser
+	}
+	paymentService.clock = jm.clock
+
+	var after *models.EscrowTransaction
+	if cursor := jm.loadCheckpoint(ctx, run.JobKey); cursor != "" {
+		escrow, err := paymentService.escrows().Get(ctx, cursor)
+		if err != nil {
+			slog.WarnContext(ctx, "Checkpoint escrow not found, starting from the beginning", "escrow_id", cursor, "error", err)
+		} else {
+			after = escrow
+		}
+	}
+
+	processed, failed, errors, totalReleased, next, err := paymentService.ProcessAutomaticReleasesFrom(ctx, after)
+	setJobRunAttempts(run, paymentService.RetryStats())
+
+	// A cron invocation that used up its time budget stopped between escrows like a shutdown
+	// does, but it is an expected pause rather than an abort
+	budgetSpent := ctx.Err() == context.DeadlineExceeded
+	if err == nil || ctx.Err() != nil {
+		jm.saveCheckpoint(ctx, run, escrowCursor(next))
+	}
+
+	if err != nil && ctx.Err() != nil && !budgetSpent {
+		aborted = true
+		result = fmt.Sprintf("Aborted: processed %d releases, %d failed before shutdown", processed, failed)
+		slog.WarnContext(ctx, result)
+		return
+	}
+
+	if err != nil && !budgetSpent {
 		hasError = true
 		result = fmt.Sprintf("Auto release failed: %v", err)
-		log.Printf("[AutoReleaseJob] Failed: %s", result)
+		slog.ErrorContext(ctx, "Job run failed", "error", err)
 		return
 	}
 
@@ -455,7 +620,7 @@ func (jm *BackgroundJobManager) runAutoRelease() {
 	// This should be the total eligible escrows found, not just processed + failed
 	validated := processed + failed
 	
-	log.Printf("[AutoReleaseJob] Job summary: validated=%d, processed=%d, failed=%d, totalReleased=€%.2f", validated, processed, failed, totalReleased)
+	slog.InfoContext(ctx, "Job summary", "validated", validated, "processed", processed, "failed", failed, "total_released", totalReleased)
 
 	if failed > 0 {
 		hasError = true
@@ -465,66 +630,104 @@ func (jm *BackgroundJobManager) runAutoRelease() {
 			if i >= 3 { // Limit to first 3 errors in logs
 				break
 			}
-			log.Printf("[AutoReleaseJob] Error: %s", errMsg)
+			slog.WarnContext(ctx, "Release error", "error", errMsg)
 		}
 	} else {
 		result = fmt.Sprintf("Successfully processed %d automatic releases", processed)
 	}
+	if next != nil {
+		result = fmt.Sprintf("%s, next run resumes after escrow %s", result, next.ID)
+	}
+
+	// Report the job summary
+	paymentService.NotifyAutoReleaseJobSummary(validated, processed, failed, totalReleased, time.Since(start))
+
+	if err := paymentService.RefreshEscrowMetrics(ctx); err != nil {
+		slog.WarnContext(ctx, "Failed to refresh escrow metrics", "error", err)
+	}
 
-	// Send Slack notification with job summary
-	paymentService.SendSlackJobSummaryNotification(validated, processed, failed, totalReleased, time.Since(start))
+	attempts := paymentService.RetryStats()
+	setJobRunAttempts(run, attempts)
+	if retries := TotalRetries(attempts); retries > 0 {
+		result = fmt.Sprintf("%s (%d retries)", result, retries)
+	}
 
-	log.Printf("[AutoReleaseJob] Completed: %s (runtime: %v)", result, time.Since(start))
+	slog.InfoContext(ctx, "Job run completed", "result", result, "runtime", time.Since(start).String())
 }
 
-func (jm *BackgroundJobManager) runDisputeEscalation() {
+func (jm *BackgroundJobManager) runDisputeEscalation(run *JobRun) {
 	start := time.Now()
-	log.Printf("[DisputeEscalationJob] Starting execution")
-
-	statusMutex.Lock()
-	if status, exists := jobStatuses["dispute_escalation"]; exists {
-		status.IsRunning = true
-	}
-	statusMutex.Unlock()
+	ctx, span := jm.startRunSpan(run)
+	slog.InfoContext(ctx, "Job run started", "trigger", run.Trigger)
 
 	var result string
 	var hasError bool
+	var aborted bool
 
+	// Runs after the run is recorded, so a slow Slack post doesn't hold up its status
+	defer sendDueDigest(context.WithoutCancel(ctx))
 	defer func() {
-		updateJobStatus("dispute_escalation", result, time.Since(start), hasError)
+		if aborted {
+			abortJobRun(run, result, time.Since(start))
+			return
+		}
+		finishJobRun(run, result, time.Since(start), hasError)
 	}()
+	defer func() { endRunSpan(span, result, hasError, aborted) }()
 
 	// Check if Firestore client is available
 	firestoreClient := config.FirestoreClient()
 	if firestoreClient == nil {
-		log.Printf("[DisputeEscalationJob] Firestore client not available (test environment?)")
+		slog.WarnContext(ctx, "Firestore client not available, skipping run")
 		result = "Skipped - no Firestore client available"
 		
-		// Send Slack notification even when skipped
-		paymentService := NewPaymentService()
-		paymentService.SendSlackDisputeJobNotification(0, 0, 0, time.Since(start))
+		// Report the run even when skipped
+		paymentService := NewJobPaymentService().WithContext(ctx)
+		paymentService.NotifyDisputeJobSummary(0, 0, 0, time.Since(start))
 		return
 	}
 
-	// TODO: Implement dispute escalation logic
-	// For now, simulate the result with placeholder values
-	disputesChecked := 0
-	escalated := 0
-	errors := 0
-	
-	log.Printf("[DisputeEscalationJob] Job summary: disputesChecked=%d, escalated=%d, errors=%d", disputesChecked, escalated, errors)
-	
-	result = "Dispute escalation job completed (implementation needed)"
-	
-	// Send Slack notification with job summary
-	paymentService := NewPaymentService()
-	paymentService.SendSlackDisputeJobNotification(disputesChecked, escalated, errors, time.Since(start))
-	
-	log.Printf("[DisputeEscalationJob] Completed: %s (runtime: %v)", result, time.Since(start))
+	// Escalate manual reviews that have passed their SLA
+	paymentService := NewJobPaymentService().WithContext(ctx)
+	paymentService.clock = jm.clock
+	reviewsChecked, escalated, errors, err := paymentService.EscalateOverdueReviews(ctx, jm.now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check overdue reviews", "error", err)
+		result = fmt.Sprintf("Dispute escalation failed: %v", err)
+		hasError = true
+		return
+	}
+	hasError = errors > 0
+
+	slog.InfoContext(ctx, "Job summary", "reviews_checked", reviewsChecked, "escalated", escalated, "errors", errors)
+
+	result = fmt.Sprintf("Checked %d reviews, escalated %d overdue (%d errors)", reviewsChecked, escalated, errors)
+
+	// Report the job summary
+	paymentService.NotifyDisputeJobSummary(reviewsChecked, escalated, errors, time.Since(start))
+	setJobRunAttempts(run, paymentService.RetryStats())
+
+	slog.InfoContext(ctx, "Job run completed", "result", result, "runtime", time.Since(start).String())
+}
+
+// escrowCursor returns the checkpoint cursor for resuming after escrow, "" when there is none
+func escrowCursor(escrow *models.EscrowTransaction) string {
+	if escrow == nil {
+		return ""
+	}
+	return escrow.ID
+}
+
+// timeCursor returns the checkpoint cursor for resuming after t, "" when there is none
+func timeCursor(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
 }
 
 // Helper functions
 func sendRatingReminder(playerID string, match *models.Match) {
-	log.Printf("[RatingReminder] Sending reminder to player %s for match %s", playerID, match.ID)
+	slog.Info("Sending rating reminder", "player_id", playerID, "game_id", match.ID)
 	// TODO: Implement notification sending
 }
\ No newline at end of file
@@ -194,15 +194,15 @@ func TestJobTriggers(t *testing.T) {
 	t.Run("should return error when job manager is nil", func(t *testing.T) {
 		jobManager = nil
 
-		err := TriggerRatingReminder()
+		_, err := TriggerRatingReminder("admin_1")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "job manager not initialized")
 
-		err = TriggerAutoRelease()
+		_, err = TriggerAutoRelease("admin_1")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "job manager not initialized")
 
-		err = TriggerDisputeEscalation()
+		_, err = TriggerDisputeEscalation("admin_1")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "job manager not initialized")
 	})
@@ -220,14 +220,17 @@ func TestJobTriggers(t *testing.T) {
 			running:  true,
 		}
 
-		err := TriggerRatingReminder()
+		runID, err := TriggerRatingReminder("admin_1")
 		assert.NoError(t, err)
+		assert.NotEmpty(t, runID)
 
-		err = TriggerAutoRelease()
+		runID, err = TriggerAutoRelease("admin_1")
 		assert.NoError(t, err)
+		assert.NotEmpty(t, runID)
 
-		err = TriggerDisputeEscalation()
+		runID, err = TriggerDisputeEscalation("admin_1")
 		assert.NoError(t, err)
+		assert.NotEmpty(t, runID)
 	})
 }
 
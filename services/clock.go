@@ -0,0 +1,118 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time to the escrow and job rules: holds, grace periods, review deadlines and
+// the rating reminder window. Elapsed times such as job runtimes and retry backoff use the wall
+// clock regardless.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// FakeClock is a clock that only moves when told to, for tests
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a fake clock stopped at start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// VirtualClock runs with its base clock but can be moved ahead of it, for sandboxes and scenario
+// runs where time must pass faster than it does
+type VirtualClock struct {
+	base   Clock
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+// NewVirtualClock creates a virtual clock that starts at the time of base
+func NewVirtualClock(base Clock) *VirtualClock {
+	return &VirtualClock{base: base}
+}
+
+func (c *VirtualClock) Now() time.Time {
+	return c.base.Now().Add(c.Offset())
+}
+
+// Advance moves the clock ahead by d. Virtual time never goes back, so d must be positive.
+func (c *VirtualClock) Advance(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset += d
+}
+
+// Offset returns how far the clock is ahead of its base
+func (c *VirtualClock) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// Reset brings the clock back to the time of its base
+func (c *VirtualClock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = 0
+}
+
+var (
+	clockMu      sync.RWMutex
+	processClock Clock = SystemClock{}
+)
+
+// ProcessClock returns the clock of services and jobs that weren't given their own
+func ProcessClock() Clock {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return processClock
+}
+
+// SetClock replaces the process clock and returns the previous one. A sandbox sets a
+// VirtualClock at startup so that time can be advanced through the admin API.
+func SetClock(clock Clock) Clock {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	previous := processClock
+	processClock = clock
+	return previous
+}
+
+// VirtualProcessClock returns the process clock when it is a VirtualClock
+func VirtualProcessClock() (*VirtualClock, bool) {
+	clock, ok := ProcessClock().(*VirtualClock)
+	return clock, ok
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	assert.Equal(t, start, clock.Now())
+
+	clock.Advance(90 * time.Minute)
+	assert.Equal(t, start.Add(90*time.Minute), clock.Now())
+
+	clock.Set(start)
+	assert.Equal(t, start, clock.Now())
+}
+
+func TestVirtualClock(t *testing.T) {
+	base := NewFakeClock(time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC))
+	clock := NewVirtualClock(base)
+	assert.Equal(t, base.Now(), clock.Now())
+
+	clock.Advance(24 * time.Hour)
+	clock.Advance(-time.Hour)
+	assert.Equal(t, 24*time.Hour, clock.Offset(), "virtual time never goes back")
+
+	base.Advance(time.Minute)
+	assert.Equal(t, base.Now().Add(24*time.Hour), clock.Now(), "virtual time keeps running with its base")
+
+	clock.Reset()
+	assert.Equal(t, base.Now(), clock.Now())
+}
+
+func TestSetClock(t *testing.T) {
+	clock := NewVirtualClock(SystemClock{})
+	previous := SetClock(clock)
+	defer SetClock(previous)
+
+	virtual, ok := VirtualProcessClock()
+	require.True(t, ok)
+	assert.Same(t, clock, virtual)
+
+	clock.Advance(48 * time.Hour)
+	service := &PaymentService{}
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), service.now(), time.Minute, "services without a clock use the process clock")
+
+	SetClock(SystemClock{})
+	_, ok = VirtualProcessClock()
+	assert.False(t, ok)
+}
+
+func TestAutoReleaseFollowsTheClock(t *testing.T) {
+	start := time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	store := NewMemoryEscrowStore(&models.EscrowTransaction{
+		ID:                "escrow_1",
+		GameID:            "game_1",
+		OrganizerID:       "organizer_1",
+		Amount:            14.0,
+		Status:            models.EscrowStatusHeld,
+		HeldAt:            start,
+		ReleaseEligibleAt: start.Add(24 * time.Hour),
+		MinRatingRequired: models.DefaultMinRatingRequired,
+		ReleasePolicy:     &models.ReleasePolicy{HoldPeriod: 24 * time.Hour, GracePeriod: 24 * time.Hour},
+	})
+	service := &PaymentService{
+		escrowStore:   store,
+		ratingStore:   NewMemoryRatingStore(),
+		fundsReleaser: &fakeFundsReleaser{},
+		clock:         clock,
+	}
+
+	steps := []struct {
+		advance time.Duration
+		status  string
+	}{
+		{0, models.EscrowStatusHeld},                       // Still in the hold
+		{25 * time.Hour, models.EscrowStatusPendingRating}, // Hold over, waiting for ratings
+		{24 * time.Hour, models.EscrowStatusReleased},      // Grace period over without ratings
+	}
+
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		_, _, _, _, err := service.ProcessAutomaticReleasesContext(context.Background())
+		require.NoError(t, err)
+
+		escrow, err := store.Get(context.Background(), "escrow_1")
+		require.NoError(t, err)
+		assert.Equal(t, step.status, escrow.Status, "at %s", clock.Now().Sub(start))
+	}
+
+	escrow, err := store.Get(context.Background(), "escrow_1")
+	require.NoError(t, err)
+	require.NotNil(t, escrow.ReleasedAt)
+	assert.Equal(t, clock.Now(), *escrow.ReleasedAt)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// DisputeStore persists escrow disputes
+type DisputeStore interface {
+	Get(ctx context.Context, disputeID string) (*models.EscrowDispute, error)
+	Put(ctx context.Context, dispute *models.EscrowDispute) error
+}
+
+// FirestoreDisputeStore stores disputes in the escrow_disputes collection
+type FirestoreDisputeStore struct{}
+
+func (FirestoreDisputeStore) client() (*firestore.Client, error) {
+	firestoreClient := config.FirestoreClient()
+	if firestoreClient == nil {
+		return nil, fmt.Errorf("firestore client not available")
+	}
+	return firestoreClient, nil
+}
+
+func (fs FirestoreDisputeStore) Get(ctx context.Context, disputeID string) (*models.EscrowDispute, error) {
+	client, err := fs.client()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := client.Collection("escrow_disputes").Doc(disputeID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dispute models.EscrowDispute
+	if err := doc.DataTo(&dispute); err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (fs FirestoreDisputeStore) Put(ctx context.Context, dispute *models.EscrowDispute) error {
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Collection("escrow_disputes").Doc(dispute.ID).Set(ctx, dispute)
+	return err
+}
+
+// MemoryDisputeStore keeps disputes in memory, for tests
+type MemoryDisputeStore struct {
+	mu       sync.RWMutex
+	disputes map[string]models.EscrowDispute
+}
+
+// NewMemoryDisputeStore creates an empty in-memory dispute store
+func NewMemoryDisputeStore() *MemoryDisputeStore {
+	return &MemoryDisputeStore{disputes: make(map[string]models.EscrowDispute)}
+}
+
+func (ms *MemoryDisputeStore) Get(ctx context.Context, disputeID string) (*models.EscrowDispute, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	dispute, exists := ms.disputes[disputeID]
+	if !exists {
+		return nil, fmt.Errorf("escrow dispute not found: %s", disputeID)
+	}
+	return &dispute, nil
+}
+
+func (ms *MemoryDisputeStore) Put(ctx context.Context, dispute *models.EscrowDispute) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.disputes[dispute.ID] = *dispute
+	return nil
+}
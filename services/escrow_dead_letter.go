@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrEscrowNotDeadLettered is returned when a dead-letter action targets an escrow in another state
+var ErrEscrowNotDeadLettered = errors.New("escrow is not dead-lettered")
+
+// applyReleaseFailure records a failed release attempt on the escrow and moves it to the
+// release_failed dead-letter state once maxFailures consecutive attempts have failed.
+// It returns true when this failure dead-lettered the escrow.
+func applyReleaseFailure(escrow *models.EscrowTransaction, releaseErr error, now time.Time, maxFailures int) bool {
+	if maxFailures <= 0 {
+		maxFailures = models.MaxReleaseFailures
+	}
+
+	escrow.ReleaseFailureCount++
+	escrow.LastReleaseError = releaseErr.Error()
+	escrow.LastReleaseAttemptAt = &now
+
+	if escrow.ReleaseFailureCount < maxFailures || escrow.Status == models.EscrowStatusReleaseFailed {
+		return false
+	}
+
+	escrow.Status = models.EscrowStatusReleaseFailed
+	escrow.DeadLetteredAt = &now
+	return true
+}
+
+// recordReleaseFailure stores a failed release attempt on the current copy of the escrow, keeping
+// changes made since the caller read it. It returns the updated escrow and whether it was
+// dead-lettered. Escrows settled in the meantime are left alone.
+func (s *PaymentService) recordReleaseFailure(escrowID string, releaseErr error) (*models.EscrowTransaction, bool) {
+	deadLettered := false
+	escrow, err := s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		if !slices.Contains(releaseCandidateStatuses, escrow.Status) && escrow.Status != models.EscrowStatusReleaseFailed {
+			return fmt.Errorf("%w, current status: %s", ErrEscrowNotReleasable, escrow.Status)
+		}
+		deadLettered = applyReleaseFailure(escrow, releaseErr, s.now(), s.maxReleaseFailures)
+		return nil
+	})
+	if errors.Is(err, ErrEscrowNotReleasable) {
+		slog.InfoContext(s.baseContext(), "Escrow settled since the failed release, not recording the failure", "escrow_id", escrowID, "error", err)
+		return nil, false
+	}
+	if err != nil {
+		slog.ErrorContext(s.baseContext(), "Failed to record release failure", "escrow_id", escrowID, "error", err)
+		return nil, false
+	}
+
+	if deadLettered {
+		slog.ErrorContext(s.baseContext(), "Escrow dead-lettered after repeated release failures",
+			"escrow_id", escrow.ID, "status", models.EscrowStatusReleaseFailed, "attempts", escrow.ReleaseFailureCount)
+	}
+	return escrow, deadLettered
+}
+
+// ListDeadLetteredEscrows returns the escrows that are parked in the release_failed state
+func (s *PaymentService) ListDeadLetteredEscrows() ([]*models.EscrowTransaction, error) {
+	return s.escrows().ListByStatus(s.baseContext(), models.EscrowStatusReleaseFailed)
+}
+
+// RetryDeadLetteredEscrow puts a dead-lettered escrow back into the held state and attempts the
+// release again straight away. A failed retry is recorded as a new failure.
+func (s *PaymentService) RetryDeadLetteredEscrow(escrowID, adminID string) (err error) {
+	s, span := s.startSpan("RetryDeadLetteredEscrow", tracing.EscrowID(escrowID))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Retrying dead-lettered escrow", "escrow_id", escrowID, "admin_id", adminID)
+
+	if _, err := s.takeDeadLettered(escrowID, func(escrow *models.EscrowTransaction) {
+		escrow.Status = models.EscrowStatusHeld
+		escrow.ReleaseFailureCount = 0
+		escrow.DeadLetteredAt = nil
+	}); err != nil {
+		return err
+	}
+
+	if err := s.ProcessEscrowRelease(escrowID, "dead_letter_retry"); err != nil {
+		s.recordReleaseFailure(escrowID, err)
+		return fmt.Errorf("retry failed: %w", err)
+	}
+
+	return nil
+}
+
+// ForceResolveEscrow closes a dead-lettered escrow without moving funds, for cases handled
+// outside this service (e.g. a transfer made manually in the Stripe dashboard)
+func (s *PaymentService) ForceResolveEscrow(escrowID, resolution, notes, adminID string) (escrow *models.EscrowTransaction, err error) {
+	s, span := s.startSpan("ForceResolveEscrow", tracing.EscrowID(escrowID), attribute.String("resolution", resolution))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Force-resolving dead-lettered escrow", "escrow_id", escrowID, "resolution", resolution, "admin_id", adminID)
+
+	switch resolution {
+	case models.EscrowStatusReleased, models.EscrowStatusRefunded, models.EscrowStatusResolved:
+	default:
+		return nil, fmt.Errorf("invalid resolution: %s", resolution)
+	}
+
+	now := s.now()
+	return s.takeDeadLettered(escrowID, func(escrow *models.EscrowTransaction) {
+		escrow.Status = resolution
+		escrow.ResolvedBy = adminID
+		escrow.ResolutionNotes = notes
+		if resolution == models.EscrowStatusReleased {
+			escrow.ReleasedAt = &now
+			escrow.ReleaseReason = "manual_resolution"
+		}
+	})
+}
+
+// takeDeadLettered applies an admin action to an escrow that is still dead-lettered, in one atomic
+// update, so a retry and a force-resolve of the same escrow cannot both go ahead. The action must
+// move the escrow out of release_failed.
+func (s *PaymentService) takeDeadLettered(escrowID string, action func(escrow *models.EscrowTransaction)) (*models.EscrowTransaction, error) {
+	escrow, err := s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		if escrow.Status != models.EscrowStatusReleaseFailed {
+			return fmt.Errorf("%w, current status: %s", ErrEscrowNotDeadLettered, escrow.Status)
+		}
+		action(escrow)
+		return nil
+	})
+	if errors.Is(err, ErrEscrowNotDeadLettered) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update escrow transaction: %w", err)
+	}
+	return escrow, nil
+}
+
+// notifyDeadLettered tells the team an escrow needs manual attention
+func (s *PaymentService) notifyDeadLettered(escrow *models.EscrowTransaction) {
+	s.notify(Notification{
+		Event:    EventReleaseFailed,
+		Severity: SeverityCritical,
+		Key:      escrow.ID,
+		Title:    fmt.Sprintf("Escrow %s moved to the dead-letter queue", escrow.ID),
+		Text: fmt.Sprintf("🪦 *Escrow Moved to Dead-Letter Queue*\n\nEscrow ID: %s\nAmount: €%.2f\nFailed Attempts: %d\nLast Error: %s\n\nAutomatic release has stopped. Retry or resolve it via the admin API.",
+			escrow.ID, escrow.Amount, escrow.ReleaseFailureCount, escrow.LastReleaseError),
+		Fields: map[string]interface{}{"escrowId": escrow.ID, "amount": escrow.Amount, "failedAttempts": escrow.ReleaseFailureCount, "error": escrow.LastReleaseError},
+	})
+}
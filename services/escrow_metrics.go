@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/metrics"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// escrowStatuses are the statuses reported by the escrows-by-status gauge
+var escrowStatuses = []string{
+	models.EscrowStatusHeld,
+	models.EscrowStatusPendingRating,
+	models.EscrowStatusApproved,
+	models.EscrowStatusUnderReview,
+	models.EscrowStatusDeciding,
+	models.EscrowStatusReleased,
+	models.EscrowStatusDisputed,
+	models.EscrowStatusResolved,
+	models.EscrowStatusRefunded,
+	models.EscrowStatusReleaseFailed,
+}
+
+// RefreshEscrowMetrics counts the escrows in each status for the escrows-by-status gauge. A
+// status that can't be counted keeps its previous value.
+func (s *PaymentService) RefreshEscrowMetrics(ctx context.Context) error {
+	var errs []error
+	for _, status := range escrowStatuses {
+		count, err := s.escrows().CountByStatus(ctx, status)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", status, err))
+			continue
+		}
+		metrics.EscrowsByStatus.WithLabelValues(status).Set(float64(count))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/metrics"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshEscrowMetrics(t *testing.T) {
+	service := &PaymentService{escrowStore: NewMemoryEscrowStore(
+		&models.EscrowTransaction{ID: "escrow_1", Status: models.EscrowStatusHeld},
+		&models.EscrowTransaction{ID: "escrow_2", Status: models.EscrowStatusHeld},
+		&models.EscrowTransaction{ID: "escrow_3", Status: models.EscrowStatusUnderReview},
+	)}
+
+	require.NoError(t, service.RefreshEscrowMetrics(context.Background()))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.EscrowsByStatus.WithLabelValues(models.EscrowStatusHeld)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.EscrowsByStatus.WithLabelValues(models.EscrowStatusUnderReview)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.EscrowsByStatus.WithLabelValues(models.EscrowStatusReleased)))
+}
+
+func TestReleaseMetrics(t *testing.T) {
+	releasesBefore := testutil.ToFloat64(metrics.EscrowReleases)
+	amountBefore := testutil.ToFloat64(metrics.EscrowReleasedAmount)
+
+	service := &PaymentService{
+		escrowStore:   NewMemoryEscrowStore(seedReleasableEscrows(3)...),
+		ratingStore:   NewMemoryRatingStore(),
+		fundsReleaser: &fakeFundsReleaser{},
+		notifications: &FakeNotifier{},
+		releaseLimits: AutoReleaseLimits{BatchSize: 10, Concurrency: 2, MaxPerRun: 10},
+	}
+
+	processed, _, _, totalReleased, err := service.ProcessAutomaticReleases()
+	require.NoError(t, err)
+
+	assert.Equal(t, releasesBefore+float64(processed), testutil.ToFloat64(metrics.EscrowReleases))
+	assert.InDelta(t, amountBefore+totalReleased, testutil.ToFloat64(metrics.EscrowReleasedAmount), 0.001)
+}
+
+func TestJobRunMetrics(t *testing.T) {
+	before := testutil.ToFloat64(metrics.JobRuns.WithLabelValues("metrics_test_job", JobRunStatusFailed))
+
+	run, err := beginJobRun("metrics_test_job", JobTriggerManual)
+	require.NoError(t, err)
+	finishJobRun(run, "failed on purpose", time.Second, true)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.JobRuns.WithLabelValues("metrics_test_job", JobRunStatusFailed)))
+}
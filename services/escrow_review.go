@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrEscrowNotUnderReview is returned when a review decision targets an escrow in another state
+var ErrEscrowNotUnderReview = errors.New("escrow is not under review")
+
+// errReviewAlreadyHandled stops an escalation of a review that was decided or escalated meanwhile
+var errReviewAlreadyHandled = errors.New("review already decided or escalated")
+
+// startReview moves a poorly rated escrow into the manual review queue, starting the review SLA
+// and sending the review alert. The alert goes out once per escrow.
+func (s *PaymentService) startReview(escrow *models.EscrowTransaction, now time.Time) {
+	escrow.Status = models.EscrowStatusUnderReview
+	if escrow.ReviewStartedAt == nil {
+		dueAt := now.Add(s.reviewPeriod())
+		escrow.ReviewStartedAt = &now
+		escrow.ReviewDueAt = &dueAt
+	}
+
+	if escrow.ReviewAlertSentAt == nil {
+		s.notifyReviewNeeded(escrow)
+		escrow.ReviewAlertSentAt = &now
+	}
+}
+
+// reviewPeriod returns how long a reviewer has to decide before the review is escalated
+func (s *PaymentService) reviewPeriod() time.Duration {
+	if s.reviewSLA <= 0 {
+		return models.DefaultReviewSLAHours * time.Hour
+	}
+	return s.reviewSLA
+}
+
+// ListEscrowsUnderReview returns the escrows waiting for a reviewer's decision
+func (s *PaymentService) ListEscrowsUnderReview() ([]*models.EscrowTransaction, error) {
+	return s.escrows().ListByStatus(s.baseContext(), models.EscrowStatusUnderReview)
+}
+
+// ApproveReviewedEscrow releases an escrow in the review queue to the organizer. If the release
+// fails the escrow stays approved and automatic release retries it on the next run.
+func (s *PaymentService) ApproveReviewedEscrow(escrowID, reviewerID, notes string) (escrow *models.EscrowTransaction, err error) {
+	s, span := s.startSpan("ApproveReviewedEscrow", tracing.EscrowID(escrowID))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Escrow review: release approved", "escrow_id", escrowID, "reviewer_id", reviewerID)
+
+	now := s.now()
+	if _, err := s.takeReview(escrowID, func(escrow *models.EscrowTransaction) error {
+		recordReviewDecision(escrow, models.ReviewDecisionApproveRelease, reviewerID, notes, now)
+		escrow.Status = models.EscrowStatusApproved
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.ProcessEscrowRelease(escrowID, "manual_review_approved"); err != nil {
+		return nil, fmt.Errorf("release approved but failed, automatic release will retry it: %w", err)
+	}
+
+	return s.getEscrowTransaction(escrowID)
+}
+
+// RefundReviewedEscrow refunds an escrow in the review queue to the players. An amount of zero or
+// the full escrow amount refunds everything; a smaller amount is a partial refund and the rest of
+// the escrow is released to the organizer.
+func (s *PaymentService) RefundReviewedEscrow(escrowID string, amount float64, reviewerID, notes string) (escrow *models.EscrowTransaction, err error) {
+	s, span := s.startSpan("RefundReviewedEscrow", tracing.EscrowID(escrowID), attribute.Float64("amount", amount))
+	defer func() { tracing.End(span, err) }()
+
+	var partial bool
+	escrow, err = s.takeReview(escrowID, func(escrow *models.EscrowTransaction) error {
+		if amount < 0 || amount > escrow.Amount {
+			return fmt.Errorf("refund amount must be between €0.00 and the escrow amount €%.2f, got €%.2f", escrow.Amount, amount)
+		}
+		partial = amount > 0 && amount < escrow.Amount
+		escrow.Status = models.EscrowStatusDeciding
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !partial {
+		amount = escrow.Amount
+	}
+
+	slog.InfoContext(s.baseContext(), "Escrow review: refunding", "escrow_id", escrowID, "payment_id", escrow.PaymentID, "amount", amount, "escrow_amount", escrow.Amount, "reviewer_id", reviewerID)
+
+	if err := s.refunder().ProcessEscrowRefund(escrowID, escrow.PaymentID, amount, "manual_review: "+notes); err != nil {
+		s.reopenReview(escrowID)
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	now := s.now()
+	escrow, err = s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		escrow.RefundedAmount = amount
+		if !partial {
+			recordReviewDecision(escrow, models.ReviewDecisionRefund, reviewerID, notes, now)
+			escrow.Status = models.EscrowStatusRefunded
+			return nil
+		}
+
+		// The organizer keeps what was not refunded
+		recordReviewDecision(escrow, models.ReviewDecisionPartialRefund, reviewerID, notes, now)
+		escrow.Amount -= amount
+		escrow.Status = models.EscrowStatusApproved
+		return nil
+	})
+	if err != nil {
+		// The escrow stays deciding, which no reviewer or release picks up, until it is resolved by hand
+		return nil, fmt.Errorf("refund issued but failed to update escrow transaction: %w", err)
+	}
+	if !partial {
+		return escrow, nil
+	}
+
+	if err := s.ProcessEscrowRelease(escrowID, "manual_review_partial_refund"); err != nil {
+		return nil, fmt.Errorf("refund issued but releasing the remainder failed, automatic release will retry it: %w", err)
+	}
+
+	return s.getEscrowTransaction(escrowID)
+}
+
+// DisputeReviewedEscrow opens an EscrowDispute for an escrow in the review queue, for cases that
+// need investigation before funds move
+func (s *PaymentService) DisputeReviewedEscrow(escrowID, requestedAction, reviewerID, notes string) (dispute *models.EscrowDispute, err error) {
+	s, span := s.startSpan("DisputeReviewedEscrow", tracing.EscrowID(escrowID), attribute.String("requested_action", requestedAction))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Escrow review: dispute opened", "escrow_id", escrowID, "reviewer_id", reviewerID)
+
+	switch requestedAction {
+	case models.DisputeActionRelease, models.DisputeActionRefund, models.DisputeActionPartialRefund:
+	default:
+		return nil, fmt.Errorf("invalid requested action: %s", requestedAction)
+	}
+
+	escrow, err := s.takeReview(escrowID, func(escrow *models.EscrowTransaction) error {
+		escrow.Status = models.EscrowStatusDeciding
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	dispute = &models.EscrowDispute{
+		ID:              uuid.NewString(),
+		EscrowID:        escrow.ID,
+		GameID:          escrow.GameID,
+		DisputerID:      reviewerID,
+		DisputerRole:    models.DisputeRoleAdmin,
+		DisputeReason:   notes,
+		RequestedAction: requestedAction,
+		Status:          models.DisputeStatusPending,
+		AdminID:         reviewerID,
+		CreatedAt:       now,
+	}
+
+	ctx := s.baseContext()
+	if err := s.retry.Do(ctx, "firestore.save_dispute", func() error {
+		return s.disputes().Put(ctx, dispute)
+	}); err != nil {
+		s.reopenReview(escrowID)
+		return nil, fmt.Errorf("failed to save escrow dispute: %w", err)
+	}
+
+	if _, err := s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		recordReviewDecision(escrow, models.ReviewDecisionDispute, reviewerID, notes, now)
+		escrow.Status = models.EscrowStatusDisputed
+		escrow.DisputeID = dispute.ID
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update escrow transaction: %w", err)
+	}
+
+	return dispute, nil
+}
+
+// EscalateOverdueReviews escalates reviews that have passed their SLA without a decision. Each
+// review is escalated once. It returns how many reviews were checked and escalated, and how many
+// could not be updated.
+func (s *PaymentService) EscalateOverdueReviews(ctx context.Context, now time.Time) (checked, escalated, failed int, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.EscalateOverdueReviews")
+	defer func() {
+		span.SetAttributes(attribute.Int("escalated", escalated), attribute.Int("failed", failed))
+		tracing.End(span, err)
+	}()
+	s = s.WithContext(ctx)
+
+	escrows, err := s.escrows().ListByStatus(ctx, models.EscrowStatusUnderReview)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to list escrows under review: %w", err)
+	}
+
+	for _, escrow := range escrows {
+		// Escalated reviews are skipped on later runs, so stopping early loses nothing
+		if ctx.Err() != nil {
+			break
+		}
+		if escrow.ReviewEscalatedAt != nil || escrow.ReviewDueAt == nil || now.Before(*escrow.ReviewDueAt) {
+			continue
+		}
+
+		// A reviewer may have decided since the list was read, so only escalate what is still waiting
+		escalatedEscrow, err := s.updateEscrowAtomically(escrow.ID, func(escrow *models.EscrowTransaction) error {
+			if escrow.Status != models.EscrowStatusUnderReview || escrow.ReviewEscalatedAt != nil {
+				return errReviewAlreadyHandled
+			}
+			escrow.ReviewEscalatedAt = &now
+			return nil
+		})
+		if errors.Is(err, errReviewAlreadyHandled) {
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(s.baseContext(), "Failed to escalate escrow review", "escrow_id", escrow.ID, "error", err)
+			failed++
+			continue
+		}
+		escrow = escalatedEscrow
+
+		slog.WarnContext(s.baseContext(), "Escalated overdue escrow review", "escrow_id", escrow.ID, "review_due_at", escrow.ReviewDueAt.Format(time.RFC3339))
+		s.notifyReviewOverdue(escrow, now)
+		escalated++
+	}
+
+	return len(escrows), escalated, failed, nil
+}
+
+// recordReviewDecision stamps the reviewer's decision on the escrow
+func recordReviewDecision(escrow *models.EscrowTransaction, decision, reviewerID, notes string, now time.Time) {
+	escrow.ReviewDecision = decision
+	escrow.ReviewedBy = reviewerID
+	escrow.ReviewNotes = notes
+	escrow.ReviewedAt = &now
+}
+
+// takeReview applies a reviewer's decision to an escrow that is still under review, in one atomic
+// update, so two decisions on the same escrow cannot both go ahead. decide moves the escrow out of
+// under_review; its errors are returned as they are.
+func (s *PaymentService) takeReview(escrowID string, decide func(escrow *models.EscrowTransaction) error) (*models.EscrowTransaction, error) {
+	var decideErr error
+	escrow, err := s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		if escrow.Status != models.EscrowStatusUnderReview {
+			decideErr = fmt.Errorf("%w, current status: %s", ErrEscrowNotUnderReview, escrow.Status)
+			return decideErr
+		}
+		decideErr = decide(escrow)
+		return decideErr
+	})
+	if decideErr != nil {
+		return nil, decideErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update escrow transaction: %w", err)
+	}
+	return escrow, nil
+}
+
+// reopenReview puts an escrow taken by a decision that failed before any funds moved back in the
+// review queue
+func (s *PaymentService) reopenReview(escrowID string) {
+	_, err := s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		if escrow.Status == models.EscrowStatusDeciding {
+			escrow.Status = models.EscrowStatusUnderReview
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(s.baseContext(), "Failed to reopen escrow review after a failed decision", "escrow_id", escrowID, "status", models.EscrowStatusDeciding, "error", err)
+	}
+}
+
+// notifyReviewOverdue tells the team a manual review is overdue
+func (s *PaymentService) notifyReviewOverdue(escrow *models.EscrowTransaction, now time.Time) {
+	overdueBy := now.Sub(*escrow.ReviewDueAt).Round(time.Minute)
+	s.notify(Notification{
+		Event:    EventReviewNeeded,
+		Severity: SeverityCritical,
+		Key:      escrow.ID,
+		Title:    fmt.Sprintf("Review of escrow %s is overdue", escrow.ID),
+		Text: fmt.Sprintf("⏰ *Escrow Review Overdue*\n\nEscrow ID: %s\nAmount: €%.2f\nActual Rating: %.1f\nIn Review Since: %s\nOverdue By: %v\n\nApprove, refund or dispute it via the admin API.",
+			escrow.ID, escrow.Amount, escrow.ActualRating, escrow.ReviewStartedAt.Format("2006-01-02 15:04 MST"), overdueBy),
+		Fields: map[string]interface{}{"escrowId": escrow.ID, "amount": escrow.Amount, "actualRating": escrow.ActualRating, "overdueBy": overdueBy.String()},
+	})
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRefunder records refunds instead of calling Stripe
+type fakeRefunder struct {
+	mu       sync.Mutex
+	refunds  map[string]float64 // keyed by payment ID
+	escrows  []string           // escrow of each refund, in order
+	err      error
+	onRefund func() // runs while the refund is in flight
+}
+
+func (f *fakeRefunder) ProcessEscrowRefund(escrowID, paymentID string, amount float64, reason string) error {
+	if f.onRefund != nil {
+		f.onRefund()
+	}
+	if f.err != nil {
+		return f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.refunds == nil {
+		f.refunds = make(map[string]float64)
+	}
+	f.refunds[paymentID] += amount
+	f.escrows = append(f.escrows, escrowID)
+	return nil
+}
+
+func newReviewService(escrows ...*models.EscrowTransaction) (*PaymentService, *MemoryEscrowStore, *fakeRefunder, *MemoryDisputeStore) {
+	store := NewMemoryEscrowStore(escrows...)
+	refunder := &fakeRefunder{}
+	disputes := NewMemoryDisputeStore()
+	service := &PaymentService{
+		escrowStore:   store,
+		ratingStore:   NewMemoryRatingStore(),
+		disputeStore:  disputes,
+		fundsReleaser: &fakeFundsReleaser{},
+		refundIssuer:  refunder,
+		reviewSLA:     48 * time.Hour,
+	}
+	return service, store, refunder, disputes
+}
+
+func escrowUnderReview(id string) *models.EscrowTransaction {
+	startedAt := time.Now().Add(-1 * time.Hour)
+	dueAt := startedAt.Add(48 * time.Hour)
+	return &models.EscrowTransaction{
+		ID:                id,
+		GameID:            "game_1",
+		OrganizerID:       "organizer_1",
+		PaymentID:         "payment_" + id,
+		Amount:            24.0,
+		Status:            models.EscrowStatusUnderReview,
+		ReleaseEligibleAt: startedAt,
+		RatingReceived:    true,
+		ActualRating:      1.5,
+		MinRatingRequired: 3.0,
+		ReviewStartedAt:   &startedAt,
+		ReviewDueAt:       &dueAt,
+	}
+}
+
+func TestPoorRatingStartsReviewOnce(t *testing.T) {
+	escrow := &models.EscrowTransaction{
+		ID:                "escrow_poor",
+		Status:            models.EscrowStatusHeld,
+		ReleaseEligibleAt: time.Now().Add(-1 * time.Hour),
+		RatingReceived:    true,
+		ActualRating:      2.0,
+		MinRatingRequired: 3.0,
+	}
+	service, store, _, _ := newReviewService(escrow)
+
+	_, _, _, _, err := service.ProcessAutomaticReleases()
+	require.NoError(t, err)
+
+	reviewed, _ := store.Get(context.Background(), "escrow_poor")
+	assert.Equal(t, models.EscrowStatusUnderReview, reviewed.Status)
+	require.NotNil(t, reviewed.ReviewAlertSentAt)
+	require.NotNil(t, reviewed.ReviewDueAt)
+	assert.WithinDuration(t, reviewed.ReviewStartedAt.Add(48*time.Hour), *reviewed.ReviewDueAt, time.Second)
+
+	// Escrows under review are no longer picked up by automatic release
+	eligible, err := store.ListEligibleForRelease(context.Background(), time.Now(), nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, eligible)
+}
+
+func TestApproveReviewedEscrow(t *testing.T) {
+	service, store, _, _ := newReviewService(escrowUnderReview("escrow_1"), &models.EscrowTransaction{ID: "escrow_held", Status: models.EscrowStatusHeld})
+
+	escrow, err := service.ApproveReviewedEscrow("escrow_1", "admin_1", "organizer provided evidence")
+	require.NoError(t, err)
+
+	assert.Equal(t, models.EscrowStatusReleased, escrow.Status)
+	assert.Equal(t, "manual_review_approved", escrow.ReleaseReason)
+	assert.Equal(t, models.ReviewDecisionApproveRelease, escrow.ReviewDecision)
+	assert.Equal(t, "admin_1", escrow.ReviewedBy)
+	assert.Equal(t, "organizer provided evidence", escrow.ReviewNotes)
+	assert.NotNil(t, escrow.ReviewedAt)
+
+	_, err = service.ApproveReviewedEscrow("escrow_held", "admin_1", "notes")
+	assert.ErrorIs(t, err, ErrEscrowNotUnderReview)
+
+	unchanged, _ := store.Get(context.Background(), "escrow_held")
+	assert.Equal(t, models.EscrowStatusHeld, unchanged.Status)
+}
+
+func TestRefundReviewedEscrow(t *testing.T) {
+	t.Run("should refund the full escrow amount", func(t *testing.T) {
+		service, _, refunder, _ := newReviewService(escrowUnderReview("escrow_1"))
+
+		escrow, err := service.RefundReviewedEscrow("escrow_1", 0, "admin_1", "game did not take place")
+		require.NoError(t, err)
+
+		assert.Equal(t, models.EscrowStatusRefunded, escrow.Status)
+		assert.Equal(t, models.ReviewDecisionRefund, escrow.ReviewDecision)
+		assert.Equal(t, 24.0, escrow.RefundedAmount)
+		assert.Equal(t, 24.0, refunder.refunds["payment_escrow_1"])
+		assert.Equal(t, []string{"escrow_1"}, refunder.escrows)
+	})
+
+	t.Run("should release the remainder of a partial refund", func(t *testing.T) {
+		service, _, refunder, _ := newReviewService(escrowUnderReview("escrow_1"))
+
+		escrow, err := service.RefundReviewedEscrow("escrow_1", 10, "admin_1", "game ended early")
+		require.NoError(t, err)
+
+		assert.Equal(t, models.EscrowStatusReleased, escrow.Status)
+		assert.Equal(t, models.ReviewDecisionPartialRefund, escrow.ReviewDecision)
+		assert.Equal(t, 10.0, escrow.RefundedAmount)
+		assert.Equal(t, 14.0, escrow.Amount)
+		assert.Equal(t, 10.0, refunder.refunds["payment_escrow_1"])
+	})
+
+	t.Run("should reject a refund above the escrow amount", func(t *testing.T) {
+		service, _, refunder, _ := newReviewService(escrowUnderReview("escrow_1"))
+
+		_, err := service.RefundReviewedEscrow("escrow_1", 30, "admin_1", "notes")
+		assert.Error(t, err)
+		assert.Empty(t, refunder.refunds)
+	})
+
+	t.Run("should keep the escrow under review when the refund fails", func(t *testing.T) {
+		service, store, refunder, _ := newReviewService(escrowUnderReview("escrow_1"))
+		refunder.err = fmt.Errorf("charge already refunded")
+
+		_, err := service.RefundReviewedEscrow("escrow_1", 0, "admin_1", "notes")
+		assert.Error(t, err)
+
+		escrow, _ := store.Get(context.Background(), "escrow_1")
+		assert.Equal(t, models.EscrowStatusUnderReview, escrow.Status)
+		assert.Empty(t, escrow.ReviewDecision)
+	})
+
+	t.Run("should refuse other decisions while the refund is in flight", func(t *testing.T) {
+		service, store, refunder, _ := newReviewService(escrowUnderReview("escrow_1"))
+		var approveErr, refundErr, disputeErr error
+		refunder.onRefund = func() {
+			refunder.onRefund = nil
+			_, approveErr = service.ApproveReviewedEscrow("escrow_1", "admin_2", "notes")
+			_, refundErr = service.RefundReviewedEscrow("escrow_1", 0, "admin_2", "notes")
+			_, disputeErr = service.DisputeReviewedEscrow("escrow_1", models.DisputeActionRefund, "admin_2", "notes")
+		}
+
+		_, err := service.RefundReviewedEscrow("escrow_1", 0, "admin_1", "game did not take place")
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, approveErr, ErrEscrowNotUnderReview)
+		assert.ErrorIs(t, refundErr, ErrEscrowNotUnderReview)
+		assert.ErrorIs(t, disputeErr, ErrEscrowNotUnderReview)
+		assert.Equal(t, []string{"escrow_1"}, refunder.escrows, "the payment is refunded once")
+
+		escrow, _ := store.Get(context.Background(), "escrow_1")
+		assert.Equal(t, models.EscrowStatusRefunded, escrow.Status)
+		assert.Equal(t, "admin_1", escrow.ReviewedBy)
+	})
+
+	t.Run("should refund once when two reviewers decide at the same time", func(t *testing.T) {
+		service, _, refunder, _ := newReviewService(escrowUnderReview("escrow_1"))
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = service.RefundReviewedEscrow("escrow_1", 0, fmt.Sprintf("admin_%d", i), "notes")
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, refunder.escrows, 1)
+		if errs[0] == nil {
+			assert.ErrorIs(t, errs[1], ErrEscrowNotUnderReview)
+		} else {
+			assert.ErrorIs(t, errs[0], ErrEscrowNotUnderReview)
+			assert.NoError(t, errs[1])
+		}
+	})
+}
+
+func TestDisputeReviewedEscrow(t *testing.T) {
+	service, store, _, disputes := newReviewService(escrowUnderReview("escrow_1"))
+
+	dispute, err := service.DisputeReviewedEscrow("escrow_1", models.DisputeActionPartialRefund, "admin_1", "conflicting reports from players")
+	require.NoError(t, err)
+
+	saved, err := disputes.Get(context.Background(), dispute.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "escrow_1", saved.EscrowID)
+	assert.Equal(t, models.DisputeRoleAdmin, saved.DisputerRole)
+	assert.Equal(t, models.DisputeStatusPending, saved.Status)
+	assert.Equal(t, "conflicting reports from players", saved.DisputeReason)
+
+	escrow, _ := store.Get(context.Background(), "escrow_1")
+	assert.Equal(t, models.EscrowStatusDisputed, escrow.Status)
+	assert.Equal(t, dispute.ID, escrow.DisputeID)
+	assert.Equal(t, models.ReviewDecisionDispute, escrow.ReviewDecision)
+
+	_, err = service.DisputeReviewedEscrow("escrow_1", "cancel", "admin_1", "notes")
+	assert.Error(t, err)
+}
+
+func TestEscalateOverdueReviews(t *testing.T) {
+	overdue := escrowUnderReview("escrow_overdue")
+	dueAt := time.Now().Add(-1 * time.Hour)
+	overdue.ReviewDueAt = &dueAt
+	service, store, _, _ := newReviewService(overdue, escrowUnderReview("escrow_recent"))
+
+	checked, escalated, failed, err := service.EscalateOverdueReviews(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, checked)
+	assert.Equal(t, 1, escalated)
+	assert.Equal(t, 0, failed)
+
+	escrow, _ := store.Get(context.Background(), "escrow_overdue")
+	require.NotNil(t, escrow.ReviewEscalatedAt)
+
+	_, escalated, _, err = service.EscalateOverdueReviews(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, escalated, "reviews are escalated once")
+}
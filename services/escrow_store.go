@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"google.golang.org/api/iterator"
+)
+
+// EscrowStore persists escrow transactions
+type EscrowStore interface {
+	Get(ctx context.Context, escrowID string) (*models.EscrowTransaction, error)
+	Put(ctx context.Context, escrow *models.EscrowTransaction) error
+	// Update reads the escrow, applies fn to it and writes the result, with no other write landing
+	// in between. When fn returns an error nothing is written and the error is returned. fn may run
+	// more than once if the write conflicts and must not call the store.
+	Update(ctx context.Context, escrowID string, fn func(escrow *models.EscrowTransaction) error) (*models.EscrowTransaction, error)
+	// ListEligibleForRelease returns up to limit escrows awaiting release (held, pending_rating or
+	// approved) whose release time has passed, ordered by release time and ID, starting after the
+	// given escrow (nil for the first page)
+	ListEligibleForRelease(ctx context.Context, now time.Time, after *models.EscrowTransaction, limit int) ([]*models.EscrowTransaction, error)
+	// ListByStatus returns every escrow in the given status
+	ListByStatus(ctx context.Context, status string) ([]*models.EscrowTransaction, error)
+	// CountByStatus returns how many escrows are in the given status
+	CountByStatus(ctx context.Context, status string) (int, error)
+}
+
+// releaseCandidateStatuses are the escrow statuses evaluated by automatic release
+var releaseCandidateStatuses = []string{
+	models.EscrowStatusHeld,
+	models.EscrowStatusPendingRating,
+	models.EscrowStatusApproved,
+}
+
+// FirestoreEscrowStore stores escrows in the escrow_transactions collection
+type FirestoreEscrowStore struct{}
+
+func (FirestoreEscrowStore) collection() (*firestore.CollectionRef, error) {
+	firestoreClient := config.FirestoreClient()
+	if firestoreClient == nil {
+		return nil, fmt.Errorf("firestore client not available")
+	}
+	return firestoreClient.Collection("escrow_transactions"), nil
+}
+
+func (fs FirestoreEscrowStore) Get(ctx context.Context, escrowID string) (*models.EscrowTransaction, error) {
+	collection, err := fs.collection()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := collection.Doc(escrowID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var escrow models.EscrowTransaction
+	if err := doc.DataTo(&escrow); err != nil {
+		return nil, err
+	}
+
+	return &escrow, nil
+}
+
+func (fs FirestoreEscrowStore) Put(ctx context.Context, escrow *models.EscrowTransaction) error {
+	collection, err := fs.collection()
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Doc(escrow.ID).Set(ctx, escrow)
+	return err
+}
+
+func (fs FirestoreEscrowStore) Update(ctx context.Context, escrowID string, fn func(escrow *models.EscrowTransaction) error) (*models.EscrowTransaction, error) {
+	collection, err := fs.collection()
+	if err != nil {
+		return nil, err
+	}
+
+	ref := collection.Doc(escrowID)
+	var escrow models.EscrowTransaction
+	err = config.FirestoreClient().RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+
+		escrow = models.EscrowTransaction{}
+		if err := doc.DataTo(&escrow); err != nil {
+			return err
+		}
+		if err := fn(&escrow); err != nil {
+			return err
+		}
+		return tx.Set(ref, &escrow)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &escrow, nil
+}
+
+func (fs FirestoreEscrowStore) ListEligibleForRelease(ctx context.Context, now time.Time, after *models.EscrowTransaction, limit int) ([]*models.EscrowTransaction, error) {
+	collection, err := fs.collection()
+	if err != nil {
+		return nil, err
+	}
+
+	query := collection.
+		Where("status", "in", releaseCandidateStatuses).
+		Where("releaseEligibleAt", "<=", now).
+		OrderBy("releaseEligibleAt", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc).
+		Limit(limit)
+	if after != nil {
+		query = query.StartAfter(after.ReleaseEligibleAt, after.ID)
+	}
+
+	return queryEscrows(ctx, query)
+}
+
+func (fs FirestoreEscrowStore) ListByStatus(ctx context.Context, status string) ([]*models.EscrowTransaction, error) {
+	collection, err := fs.collection()
+	if err != nil {
+		return nil, err
+	}
+
+	return queryEscrows(ctx, collection.Where("status", "==", status))
+}
+
+func (fs FirestoreEscrowStore) CountByStatus(ctx context.Context, status string) (int, error) {
+	collection, err := fs.collection()
+	if err != nil {
+		return 0, err
+	}
+
+	query := collection.Where("status", "==", status)
+	result, err := query.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s escrow transactions: %w", status, err)
+	}
+
+	count, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count result for %s escrow transactions: %v", status, result["count"])
+	}
+	return int(count.GetIntegerValue()), nil
+}
+
+// queryEscrows runs the query and parses every escrow it returns
+func queryEscrows(ctx context.Context, query firestore.Query) ([]*models.EscrowTransaction, error) {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var escrows []*models.EscrowTransaction
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate escrow transactions: %w", err)
+		}
+
+		var escrow models.EscrowTransaction
+		if err := doc.DataTo(&escrow); err != nil {
+			return nil, fmt.Errorf("failed to parse escrow transaction %s: %w", doc.Ref.ID, err)
+		}
+
+		escrows = append(escrows, &escrow)
+	}
+
+	return escrows, nil
+}
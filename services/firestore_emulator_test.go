@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreEmulatorTestSuite runs the background jobs end to end against the Firestore emulator.
+// Start it with `gcloud emulators firestore start --host-port=localhost:8080` and run
+// `FIRESTORE_EMULATOR_HOST=localhost:8080 go test ./services -run TestFirestoreEmulator`.
+type FirestoreEmulatorTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	client    *firestore.Client
+	previous  *firestore.Client
+	cleanup   *TestDataCleanup
+	releaser  *fakeFundsReleaser
+	organizer string
+}
+
+// SetupSuite connects to the emulator under a project of its own, so runs never see each
+// other's documents
+func (suite *FirestoreEmulatorTestSuite) SetupSuite() {
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		suite.T().Skip("Skipping Firestore emulator tests: FIRESTORE_EMULATOR_HOST not set")
+	}
+
+	suite.ctx = context.Background()
+	client, err := firestore.NewClient(suite.ctx, fmt.Sprintf("goalhero-it-%d", time.Now().UnixNano()))
+	suite.Require().NoError(err)
+	suite.client = client
+	suite.previous = config.FirestoreClient()
+	suite.organizer = "org_it_" + generateTestID()
+}
+
+// TearDownSuite closes the emulator client and restores the previous global client
+func (suite *FirestoreEmulatorTestSuite) TearDownSuite() {
+	if suite.client == nil {
+		return
+	}
+	config.SetFirestoreClient(suite.previous)
+	suite.client.Close()
+}
+
+// SetupTest installs the emulator client, which other tests may have replaced through
+// config.InitJobsConfig, and starts with a clean job history
+func (suite *FirestoreEmulatorTestSuite) SetupTest() {
+	config.SetFirestoreClient(suite.client)
+	resetJobRuns()
+	suite.cleanup = NewTestDataCleanup()
+	suite.releaser = &fakeFundsReleaser{failFor: make(map[string]bool)}
+}
+
+// TearDownTest deletes every document the test seeded or the jobs created
+func (suite *FirestoreEmulatorTestSuite) TearDownTest() {
+	suite.cleanup.AddCheckpointKey("auto_release")
+	suite.cleanup.AddCheckpointKey("rating_reminder")
+	suite.NoError(suite.cleanup.DeleteFromFirestore(suite.ctx, suite.client))
+}
+
+// newJobManager returns a manager that releases funds through the fake releaser
+func (suite *FirestoreEmulatorTestSuite) newJobManager() *BackgroundJobManager {
+	jm := newTestJobManager()
+	jm.config.RatingDeadlineDays = models.DefaultRatingDeadlineDays
+	jm.fundsReleaser = suite.releaser
+	return jm
+}
+
+// runJob runs the job like a manual trigger would and returns the finished run
+func (suite *FirestoreEmulatorTestSuite) runJob(jobKey string, run func(*BackgroundJobManager, *JobRun), jm *BackgroundJobManager) *JobRun {
+	started, err := beginJobRun(jobKey, JobTriggerManual)
+	suite.Require().NoError(err)
+	run(jm, started)
+
+	finished, err := GetJobRun(started.ID)
+	suite.Require().NoError(err)
+	return finished
+}
+
+// seedMatch writes a match the way the main API does, with lowercase field names
+func (suite *FirestoreEmulatorTestSuite) seedMatch(gameID, matchStatus string, completedAt time.Time, players ...string) {
+	_, err := suite.client.Collection("matches").Doc(gameID).Set(suite.ctx, map[string]interface{}{
+		"id":             gameID,
+		"status":         matchStatus,
+		"createdBy":      suite.organizer,
+		"completedAt":    completedAt,
+		"playersPresent": append([]string{suite.organizer}, players...),
+	})
+	suite.Require().NoError(err)
+	suite.cleanup.AddMatchID(gameID)
+}
+
+// seedRating writes an attendee's rating of the organizer for the game
+func (suite *FirestoreEmulatorTestSuite) seedRating(gameID, raterID string, rating float64) {
+	id := fmt.Sprintf("rating_%s_%s", gameID, raterID)
+	_, err := suite.client.Collection("rating_validations").Doc(id).Set(suite.ctx, &models.RatingValidation{
+		ID:            id,
+		GameID:        gameID,
+		RatedPlayerID: suite.organizer,
+		RaterID:       raterID,
+		Rating:        rating,
+		Status:        "pending",
+		CreatedAt:     time.Now(),
+	})
+	suite.Require().NoError(err)
+	suite.cleanup.AddRatingID(id)
+}
+
+// seedEscrow writes a confirmed payment and the escrow holding it for the game
+func (suite *FirestoreEmulatorTestSuite) seedEscrow(gameID, escrowStatus string, eligibleAt time.Time) *models.EscrowTransaction {
+	confirmedAt := eligibleAt.Add(-time.Duration(models.EscrowHoldHours) * time.Hour)
+	payment := &models.Payment{
+		ID:            "payment_" + gameID,
+		UserID:        "player_" + gameID,
+		GameID:        gameID,
+		ApplicationID: "application_" + gameID,
+		Amount:        15,
+		NetAmount:     14.4,
+		Currency:      string(models.DefaultCurrency),
+		Status:        models.PaymentStatusConfirmed,
+		PaymentMethod: models.PaymentMethodStripe,
+		CreatedAt:     confirmedAt,
+		ConfirmedAt:   &confirmedAt,
+	}
+	_, err := suite.client.Collection("payments").Doc(payment.ID).Set(suite.ctx, payment)
+	suite.Require().NoError(err)
+	suite.cleanup.AddPaymentID(payment.ID)
+
+	escrow := &models.EscrowTransaction{
+		ID:                "escrow_" + gameID,
+		GameID:            gameID,
+		OrganizerID:       suite.organizer,
+		PaymentID:         payment.ID,
+		Amount:            payment.NetAmount,
+		Status:            escrowStatus,
+		HeldAt:            confirmedAt,
+		ReleaseEligibleAt: eligibleAt,
+		MinRatingRequired: models.DefaultMinRatingRequired,
+	}
+	_, err = suite.client.Collection("escrow_transactions").Doc(escrow.ID).Set(suite.ctx, escrow)
+	suite.Require().NoError(err)
+	suite.cleanup.AddEscrowID(escrow.ID)
+	return escrow
+}
+
+// seedCheckpoint stores a checkpoint as if an earlier run had stopped at cursor
+func (suite *FirestoreEmulatorTestSuite) seedCheckpoint(jobKey, cursor string) {
+	_, err := suite.client.Collection("job_checkpoints").Doc(jobKey).Set(suite.ctx, &JobCheckpoint{
+		JobKey:    jobKey,
+		Cursor:    cursor,
+		RunID:     "run_previous",
+		UpdatedAt: time.Now(),
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *FirestoreEmulatorTestSuite) escrow(id string) *models.EscrowTransaction {
+	doc, err := suite.client.Collection("escrow_transactions").Doc(id).Get(suite.ctx)
+	suite.Require().NoError(err)
+	var escrow models.EscrowTransaction
+	suite.Require().NoError(doc.DataTo(&escrow))
+	return &escrow
+}
+
+func (suite *FirestoreEmulatorTestSuite) payment(id string) *models.Payment {
+	doc, err := suite.client.Collection("payments").Doc(id).Get(suite.ctx)
+	suite.Require().NoError(err)
+	var payment models.Payment
+	suite.Require().NoError(doc.DataTo(&payment))
+	return &payment
+}
+
+// checkpoint returns the stored checkpoint cursor, "" when there is none
+func (suite *FirestoreEmulatorTestSuite) checkpoint(jobKey string) string {
+	doc, err := suite.client.Collection("job_checkpoints").Doc(jobKey).Get(suite.ctx)
+	if status.Code(err) == codes.NotFound {
+		return ""
+	}
+	suite.Require().NoError(err)
+	var checkpoint JobCheckpoint
+	suite.Require().NoError(doc.DataTo(&checkpoint))
+	return checkpoint.Cursor
+}
+
+// Test that one auto-release run applies every release rule and persists the outcome
+func (suite *FirestoreEmulatorTestSuite) TestAutoReleaseAppliesReleaseRules() {
+	now := time.Now()
+	pastHold := now.Add(-time.Hour)
+	pastGrace := now.Add(-(models.DefaultRatingDeadlineDays + 1) * 24 * time.Hour)
+
+	suite.seedMatch("game_well_rated", models.MatchStatusCompleted, pastHold, "player_1", "player_2")
+	suite.seedRating("game_well_rated", "player_1", 5)
+	suite.seedRating("game_well_rated", "player_2", 4)
+	wellRated := suite.seedEscrow("game_well_rated", models.EscrowStatusHeld, pastHold)
+
+	suite.seedMatch("game_poorly_rated", models.MatchStatusCompleted, pastHold, "player_1")
+	suite.seedRating("game_poorly_rated", "player_1", 1)
+	poorlyRated := suite.seedEscrow("game_poorly_rated", models.EscrowStatusHeld, pastHold)
+
+	suite.seedMatch("game_unrated", models.MatchStatusCompleted, pastHold, "player_1")
+	unrated := suite.seedEscrow("game_unrated", models.EscrowStatusHeld, pastHold)
+
+	suite.seedMatch("game_grace_over", models.MatchStatusCompleted, pastGrace, "player_1")
+	graceOver := suite.seedEscrow("game_grace_over", models.EscrowStatusPendingRating, pastGrace)
+
+	approved := suite.seedEscrow("game_approved", models.EscrowStatusApproved, pastHold)
+	stillHeld := suite.seedEscrow("game_still_held", models.EscrowStatusHeld, now.Add(24*time.Hour))
+	disputed := suite.seedEscrow("game_disputed", models.EscrowStatusDisputed, pastGrace)
+
+	suite.seedMatch("game_release_fails", models.MatchStatusCompleted, pastGrace, "player_1")
+	releaseFails := suite.seedEscrow("game_release_fails", models.EscrowStatusHeld, pastGrace)
+	suite.releaser.failFor[releaseFails.ID] = true
+
+	run := suite.runJob("auto_release", (*BackgroundJobManager).runAutoRelease, suite.newJobManager())
+
+	suite.Equal(JobRunStatusFailed, run.Status)
+	suite.Equal("Processed 3 releases, 1 failed (errors: 1)", run.Result)
+
+	for _, escrow := range []*models.EscrowTransaction{wellRated, graceOver, approved} {
+		released := suite.escrow(escrow.ID)
+		suite.Equal(models.EscrowStatusReleased, released.Status, escrow.ID)
+		suite.Equal("automatic_release", released.ReleaseReason, escrow.ID)
+		suite.NotNil(released.ReleasedAt, escrow.ID)
+	}
+
+	rated := suite.escrow(wellRated.ID)
+	suite.Equal(4.5, rated.ActualRating)
+	suite.Equal(2, rated.RatingCount)
+
+	review := suite.escrow(poorlyRated.ID)
+	suite.Equal(models.EscrowStatusUnderReview, review.Status)
+	suite.Equal(1.0, review.ActualRating)
+	suite.NotNil(review.ReviewDueAt)
+
+	suite.Equal(models.EscrowStatusPendingRating, suite.escrow(unrated.ID).Status)
+	suite.Equal(models.EscrowStatusHeld, suite.escrow(stillHeld.ID).Status)
+	suite.Equal(models.EscrowStatusDisputed, suite.escrow(disputed.ID).Status)
+
+	failed := suite.escrow(releaseFails.ID)
+	suite.Equal(models.EscrowStatusHeld, failed.Status)
+	suite.Equal(1, failed.ReleaseFailureCount)
+	suite.Contains(failed.LastReleaseError, "destination account restricted")
+
+	// Releasing an escrow pays out the organizer; the attendee's payment stays confirmed
+	suite.Equal(models.PaymentStatusConfirmed, suite.payment(wellRated.PaymentID).Status)
+	suite.Empty(suite.checkpoint("auto_release"), "a complete run clears its checkpoint")
+}
+
+// Test that a capped auto-release run checkpoints and the next run resumes after it
+func (suite *FirestoreEmulatorTestSuite) TestAutoReleaseResumesFromCheckpoint() {
+	now := time.Now()
+	approvedFirst := suite.seedEscrow("game_approved_first", models.EscrowStatusApproved, now.Add(-2*time.Hour))
+	approvedSecond := suite.seedEscrow("game_approved_second", models.EscrowStatusApproved, now.Add(-time.Hour))
+
+	jm := suite.newJobManager()
+	jm.maxReleases = 1
+
+	run := suite.runJob("auto_release", (*BackgroundJobManager).runAutoRelease, jm)
+	suite.Equal(JobRunStatusCompleted, run.Status)
+	suite.Contains(run.Result, "next run resumes after escrow "+approvedFirst.ID)
+	suite.Equal(models.EscrowStatusReleased, suite.escrow(approvedFirst.ID).Status)
+	suite.Equal(models.EscrowStatusApproved, suite.escrow(approvedSecond.ID).Status)
+	suite.Equal(approvedFirst.ID, suite.checkpoint("auto_release"))
+
+	run = suite.runJob("auto_release", (*BackgroundJobManager).runAutoRelease, jm)
+	suite.Equal("Successfully processed 1 automatic releases", run.Result)
+	suite.Equal(models.EscrowStatusReleased, suite.escrow(approvedSecond.ID).Status)
+	suite.Empty(suite.checkpoint("auto_release"))
+}
+
+// Test that concurrent updates of one escrow each see the other's write, so only one claims it
+func (suite *FirestoreEmulatorTestSuite) TestEscrowUpdateClaimsOnce() {
+	escrow := suite.seedEscrow("game_claimed", models.EscrowStatusHeld, time.Now().Add(-time.Hour))
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		claims int
+	)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := FirestoreEscrowStore{}.Update(suite.ctx, escrow.ID, func(escrow *models.EscrowTransaction) error {
+				if escrow.Status != models.EscrowStatusHeld {
+					return ErrEscrowNotReleasable
+				}
+				escrow.Status = models.EscrowStatusReleasing
+				return nil
+			})
+			if err == nil {
+				mu.Lock()
+				claims++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	suite.Equal(1, claims)
+	suite.Equal(models.EscrowStatusReleasing, suite.escrow(escrow.ID).Status)
+}
+
+// Test that the rating reminder only reminds attendees of matches completed within the window
+func (suite *FirestoreEmulatorTestSuite) TestRatingReminderRemindsRecentMatches() {
+	now := time.Now()
+	suite.seedMatch("game_two_days_ago", models.MatchStatusCompleted, now.Add(-48*time.Hour), "player_1", "player_2")
+	suite.seedMatch("game_three_days_ago", models.MatchStatusCompleted, now.Add(-72*time.Hour), "player_1")
+	suite.seedMatch("game_too_recent", models.MatchStatusCompleted, now.Add(-2*time.Hour), "player_1")
+	suite.seedMatch("game_too_old", models.MatchStatusCompleted, now.Add(-10*24*time.Hour), "player_1")
+	suite.seedMatch("game_cancelled", models.MatchStatusCancelled, now.Add(-48*time.Hour), "player_1")
+
+	run := suite.runJob("rating_reminder", (*BackgroundJobManager).runRatingReminder, suite.newJobManager())
+
+	suite.Equal(JobRunStatusCompleted, run.Status)
+	suite.Equal("Successfully sent 3 rating reminders", run.Result)
+	suite.Empty(suite.checkpoint("rating_reminder"))
+}
+
+// Test that the rating reminder skips matches an earlier run already covered
+func (suite *FirestoreEmulatorTestSuite) TestRatingReminderResumesFromCheckpoint() {
+	now := time.Now()
+	covered := now.Add(-72 * time.Hour)
+	suite.seedMatch("game_covered", models.MatchStatusCompleted, covered, "player_1", "player_2")
+	suite.seedMatch("game_pending", models.MatchStatusCompleted, now.Add(-48*time.Hour), "player_1")
+	suite.seedCheckpoint("rating_reminder", timeCursor(&covered))
+
+	run := suite.runJob("rating_reminder", (*BackgroundJobManager).runRatingReminder, suite.newJobManager())
+
+	suite.Equal("Successfully sent 1 rating reminders", run.Result)
+	suite.Empty(suite.checkpoint("rating_reminder"), "the resumed run finished and cleared the checkpoint")
+}
+
+// Test runner for the Firestore emulator test suite
+func TestFirestoreEmulatorTestSuite(t *testing.T) {
+	suite.Run(t, new(FirestoreEmulatorTestSuite))
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// JobCheckpoint records where a job stopped before finishing its work, so the next run picks up
+// after it instead of starting over. Runs that reach the end of their work delete the checkpoint.
+type JobCheckpoint struct {
+	JobKey    string    `json:"jobKey" firestore:"jobKey"`
+	Cursor    string    `json:"cursor" firestore:"cursor"` // Last item handled: escrow ID for auto_release, completedAt (RFC 3339) for rating_reminder
+	RunID     string    `json:"runId" firestore:"runId"`   // Run that wrote the checkpoint
+	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// JobCheckpointStore persists job checkpoints, keyed by job
+type JobCheckpointStore interface {
+	// Get returns the job's checkpoint, or nil if it has none
+	Get(ctx context.Context, jobKey string) (*JobCheckpoint, error)
+	Put(ctx context.Context, checkpoint *JobCheckpoint) error
+	Delete(ctx context.Context, jobKey string) error
+}
+
+// FirestoreJobCheckpointStore stores checkpoints in the job_checkpoints collection. It outlives
+// the process, which matters in cron mode where every invocation may run on a fresh instance.
+type FirestoreJobCheckpointStore struct{}
+
+func (FirestoreJobCheckpointStore) collection() (*firestore.CollectionRef, error) {
+	firestoreClient := config.FirestoreClient()
+	if firestoreClient == nil {
+		return nil, fmt.Errorf("firestore client not available")
+	}
+	return firestoreClient.Collection("job_checkpoints"), nil
+}
+
+func (fs FirestoreJobCheckpointStore) Get(ctx context.Context, jobKey string) (*JobCheckpoint, error) {
+	collection, err := fs.collection()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := collection.Doc(jobKey).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint JobCheckpoint
+	if err := doc.DataTo(&checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint for %s: %w", jobKey, err)
+	}
+	return &checkpoint, nil
+}
+
+func (fs FirestoreJobCheckpointStore) Put(ctx context.Context, checkpoint *JobCheckpoint) error {
+	collection, err := fs.collection()
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Doc(checkpoint.JobKey).Set(ctx, checkpoint)
+	return err
+}
+
+func (fs FirestoreJobCheckpointStore) Delete(ctx context.Context, jobKey string) error {
+	collection, err := fs.collection()
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Doc(jobKey).Delete(ctx)
+	return err
+}
+
+// MemoryJobCheckpointStore keeps checkpoints in memory, for tests and local runs without Firestore
+type MemoryJobCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]JobCheckpoint
+}
+
+// NewMemoryJobCheckpointStore returns an empty in-memory checkpoint store
+func NewMemoryJobCheckpointStore() *MemoryJobCheckpointStore {
+	return &MemoryJobCheckpointStore{checkpoints: make(map[string]JobCheckpoint)}
+}
+
+func (ms *MemoryJobCheckpointStore) Get(ctx context.Context, jobKey string) (*JobCheckpoint, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	checkpoint, exists := ms.checkpoints[jobKey]
+	if !exists {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+func (ms *MemoryJobCheckpointStore) Put(ctx context.Context, checkpoint *JobCheckpoint) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.checkpoints[checkpoint.JobKey] = *checkpoint
+	return nil
+}
+
+func (ms *MemoryJobCheckpointStore) Delete(ctx context.Context, jobKey string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.checkpoints, jobKey)
+	return nil
+}
+
+// checkpoints returns the manager's checkpoint store, defaulting to Firestore
+func (jm *BackgroundJobManager) checkpoints() JobCheckpointStore {
+	if jm.checkpointStore != nil {
+		return jm.checkpointStore
+	}
+	return FirestoreJobCheckpointStore{}
+}
+
+// loadCheckpoint returns the cursor the run should resume after, or "" to start from the beginning
+func (jm *BackgroundJobManager) loadCheckpoint(ctx context.Context, jobKey string) string {
+	checkpoint, err := jm.checkpoints().Get(ctx, jobKey)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load job checkpoint, starting from the beginning", "error", err)
+		return ""
+	}
+	if checkpoint == nil {
+		return ""
+	}
+	slog.InfoContext(ctx, "Resuming from checkpoint", "cursor", checkpoint.Cursor, "checkpoint_run_id", checkpoint.RunID)
+	return checkpoint.Cursor
+}
+
+// saveCheckpoint records where the run stopped, or clears the checkpoint when cursor is "" because
+// the run got through all of its work
+func (jm *BackgroundJobManager) saveCheckpoint(ctx context.Context, run *JobRun, cursor string) {
+	// Write even when the run's context is done, as that is exactly when the checkpoint matters
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	if cursor == "" {
+		err = jm.checkpoints().Delete(ctx, run.JobKey)
+	} else {
+		err = jm.checkpoints().Put(ctx, &JobCheckpoint{JobKey: run.JobKey, Cursor: cursor, RunID: run.ID, UpdatedAt: time.Now()})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save job checkpoint", "cursor", cursor, "error", err)
+	}
+}
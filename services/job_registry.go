@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+)
+
+// ErrUnknownJob is returned for a job name that is not in the registry
+var ErrUnknownJob = errors.New("unknown job")
+
+// JobDefinition describes a background job: how it is addressed over HTTP, when Vercel Cron calls
+// it and what a run executes
+type JobDefinition struct {
+	Key      string // Key in job statuses and runs, e.g. auto_release
+	Slug     string // Name in the trigger and cron URLs, e.g. auto-release
+	Schedule string // Vercel Cron schedule (UTC)
+	run      func(jm *BackgroundJobManager, run *JobRun)
+}
+
+// jobRegistry lists every job. The cron schedules mirror the default intervals in config.
+var jobRegistry = []JobDefinition{
+	{Key: "rating_reminder", Slug: "rating-reminder", Schedule: "0 9 * * *", run: (*BackgroundJobManager).runRatingReminder},
+	{Key: "auto_release", Slug: "auto-release", Schedule: "0 * * * *", run: (*BackgroundJobManager).runAutoRelease},
+	{Key: "dispute_escalation", Slug: "dispute-escalation", Schedule: "0 10 * * *", run: (*BackgroundJobManager).runDisputeEscalation},
+}
+
+// JobRegistry returns the definitions of every job
+func JobRegistry() []JobDefinition {
+	return append([]JobDefinition(nil), jobRegistry...)
+}
+
+// JobSlugs returns the URL names of every job
+func JobSlugs() []string {
+	slugs := make([]string, 0, len(jobRegistry))
+	for _, job := range jobRegistry {
+		slugs = append(slugs, job.Slug)
+	}
+	return slugs
+}
+
+// LookupJob finds a job by its URL name
+func LookupJob(slug string) (JobDefinition, error) {
+	for _, job := range jobRegistry {
+		if job.Slug == slug {
+			return job, nil
+		}
+	}
+	return JobDefinition{}, fmt.Errorf("%w: %s", ErrUnknownJob, slug)
+}
+
+// TriggerJob starts a manual run of the job named by slug in the background
+func TriggerJob(slug, triggeredBy string) (string, error) {
+	job, err := LookupJob(slug)
+	if err != nil {
+		return "", err
+	}
+	if jobManager == nil {
+		return "", fmt.Errorf("job manager not initialized")
+	}
+	return jobManager.startManualRun(job.Key, triggeredBy, func(run *JobRun) { job.run(jobManager, run) })
+}
+
+// StartCronJobs initializes the job manager for cron mode. No tickers run: on serverless
+// platforms the function is frozen between requests, so jobs run inside the requests Vercel Cron
+// sends to the cron endpoint instead (see RunCronJob).
+func StartCronJobs() *BackgroundJobManager {
+	jobConfig := jobConfigFromEnv()
+	jobsConf := config.GetJobsConfig()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobManager = &BackgroundJobManager{
+		config:      jobConfig,
+		shutdown:    make(chan struct{}),
+		running:     true,
+		ctx:         ctx,
+		cancel:      cancel,
+		maxReleases: jobsConf.CronMaxReleases,
+		cronBudget:  jobsConf.CronTimeBudget,
+	}
+
+	initializeJobStatuses(jobConfig)
+	slog.Info("Job manager started in cron mode", "time_budget", jobsConf.CronTimeBudget.String(), "max_releases", jobsConf.CronMaxReleases)
+	return jobManager
+}
+
+// defaultCronBudget leaves headroom under Vercel's default 60s function limit
+const defaultCronBudget = 45 * time.Second
+
+// RunCronJob runs the job named by slug synchronously, for a Vercel Cron invocation. The run gets
+// the manager's time budget: once it is spent the job stops between items, records a checkpoint
+// and returns, and the next invocation resumes from the checkpoint. The finished run is returned.
+func RunCronJob(ctx context.Context, slug string) (*JobRun, error) {
+	job, err := LookupJob(slug)
+	if err != nil {
+		return nil, err
+	}
+	if jobManager == nil {
+		return nil, fmt.Errorf("job manager not initialized")
+	}
+	return jobManager.runCronJob(ctx, job)
+}
+
+func (jm *BackgroundJobManager) runCronJob(ctx context.Context, job JobDefinition) (*JobRun, error) {
+	jm.mu.Lock()
+	if !jm.running {
+		jm.mu.Unlock()
+		return nil, fmt.Errorf("job manager is shutting down")
+	}
+	// Registered under the lock so a shutdown in progress waits for the run
+	jm.wg.Add(1)
+	defer jm.wg.Done()
+	budget := jm.cronBudget
+	jm.mu.Unlock()
+	if budget <= 0 {
+		budget = defaultCronBudget
+	}
+
+	run, err := beginJobRun(job.Key, JobTriggerCron)
+	if err != nil {
+		return nil, err
+	}
+
+	// The run outlives a dropped cron request but not the budget or a shutdown
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), budget)
+	defer cancel()
+	stop := context.AfterFunc(jm.jobContext(), cancel)
+	defer stop()
+
+	cronRun := &BackgroundJobManager{
+		config:          jm.config,
+		running:         true,
+		ctx:             runCtx,
+		checkpointStore: jm.checkpointStore,
+		maxReleases:     jm.maxReleases,
+		fundsReleaser:   jm.fundsReleaser,
+		clock:           jm.clock,
+	}
+	job.run(cronRun, run)
+
+	return GetJobRun(run.ID)
+}
+
+// VercelCron is one entry of the crons list in vercel.json
+type VercelCron struct {
+	Path     string `json:"path"`
+	Schedule string `json:"schedule"`
+}
+
+// VercelCrons returns the Vercel Cron entries for every job in the registry
+func VercelCrons() []VercelCron {
+	crons := make([]VercelCron, 0, len(jobRegistry))
+	for _, job := range jobRegistry {
+		crons = append(crons, VercelCron{Path: "/api/jobs/cron/" + job.Slug, Schedule: job.Schedule})
+	}
+	return crons
+}
+
+// GenerateVercelConfig returns vercel.json with its crons replaced by VercelCrons. Only the crons
+// value is rewritten, or appended when missing, so every other setting keeps its order and
+// formatting. Run cmd/generate_vercel_config.go after changing the registry.
+func GenerateVercelConfig(current []byte) ([]byte, error) {
+	crons, err := json.MarshalIndent(VercelCrons(), "  ", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(current))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("failed to parse vercel.json: expected a JSON object")
+	}
+	lastValueEnd, empty := decoder.InputOffset(), true
+	for ; decoder.More(); empty = false {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse vercel.json: %w", err)
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to parse vercel.json: %w", err)
+		}
+		lastValueEnd = decoder.InputOffset()
+
+		if key == "crons" {
+			valueStart := lastValueEnd - int64(len(value))
+			return slices.Concat(current[:valueStart], crons, current[lastValueEnd:]), nil
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("failed to parse vercel.json: %w", err)
+	}
+
+	separator := ","
+	if empty {
+		separator = ""
+	}
+	entry := []byte(separator + "\n  \"crons\": ")
+	return slices.Concat(current[:lastValueEnd], entry, crons, current[lastValueEnd:]), nil
+}
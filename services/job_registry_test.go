@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVercelConfigMatchesRegistry(t *testing.T) {
+	current, err := os.ReadFile("../vercel.json")
+	require.NoError(t, err)
+
+	generated, err := GenerateVercelConfig(current)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(current), string(generated), "vercel.json is out of date, run: go run cmd/generate_vercel_config.go")
+}
+
+func TestGenerateVercelConfig(t *testing.T) {
+	t.Run("should replace the crons in place", func(t *testing.T) {
+		current := []byte("{\n  \"version\": 2,\n  \"crons\": [],\n  \"env\": {\"GO_ENV\": \"production\"}\n}\n")
+
+		generated, err := GenerateVercelConfig(current)
+		require.NoError(t, err)
+
+		crons, err := json.MarshalIndent(VercelCrons(), "  ", "  ")
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"version\": 2,\n  \"crons\": "+string(crons)+",\n  \"env\": {\"GO_ENV\": \"production\"}\n}\n", string(generated))
+	})
+
+	t.Run("should append missing crons after the other settings", func(t *testing.T) {
+		generated, err := GenerateVercelConfig([]byte("{\n  \"version\": 2\n}"))
+		require.NoError(t, err)
+
+		assert.Regexp(t, `^\{\n  "version": 2,\n  "crons": \[`, string(generated))
+		var parsed struct{ Crons []VercelCron }
+		require.NoError(t, json.Unmarshal(generated, &parsed))
+		assert.Equal(t, VercelCrons(), parsed.Crons)
+	})
+
+	t.Run("should reject a file that is not a JSON object", func(t *testing.T) {
+		_, err := GenerateVercelConfig([]byte("[]"))
+		assert.Error(t, err)
+	})
+}
+
+func TestLookupJob(t *testing.T) {
+	job, err := LookupJob("auto-release")
+	require.NoError(t, err)
+	assert.Equal(t, "auto_release", job.Key)
+
+	_, err = LookupJob("payroll")
+	assert.ErrorIs(t, err, ErrUnknownJob)
+	assert.Equal(t, []string{"rating-reminder", "auto-release", "dispute-escalation"}, JobSlugs())
+}
+
+func TestRunCronJob(t *testing.T) {
+	previous := jobManager
+	defer func() { jobManager = previous }()
+
+	t.Run("should run the job within the request", func(t *testing.T) {
+		resetJobRuns()
+		jobManager = newTestJobManager()
+		jobManager.checkpointStore = NewMemoryJobCheckpointStore()
+		jobManager.cronBudget = time.Second
+
+		run, err := RunCronJob(context.Background(), "dispute-escalation")
+
+		require.NoError(t, err)
+		assert.Equal(t, JobTriggerCron, run.Trigger)
+		assert.NotEqual(t, JobRunStatusRunning, run.Status)
+		assert.NotNil(t, run.FinishedAt)
+	})
+
+	t.Run("should reject unknown jobs", func(t *testing.T) {
+		jobManager = newTestJobManager()
+		_, err := RunCronJob(context.Background(), "payroll")
+		assert.ErrorIs(t, err, ErrUnknownJob)
+	})
+
+	t.Run("should refuse to run while the job is already running", func(t *testing.T) {
+		resetJobRuns()
+		jobManager = newTestJobManager()
+		active, err := beginJobRun("auto_release", JobTriggerScheduled)
+		require.NoError(t, err)
+
+		_, err = RunCronJob(context.Background(), "auto-release")
+
+		var alreadyRunning *JobAlreadyRunningError
+		require.ErrorAs(t, err, &alreadyRunning)
+		assert.Equal(t, active.ID, alreadyRunning.ActiveRunID)
+	})
+}
+
+func TestJobCheckpoints(t *testing.T) {
+	manager := newTestJobManager()
+	manager.checkpointStore = NewMemoryJobCheckpointStore()
+	ctx := context.Background()
+	run := &JobRun{ID: "run_1", JobKey: "auto_release"}
+
+	assert.Empty(t, manager.loadCheckpoint(ctx, "auto_release"))
+
+	manager.saveCheckpoint(ctx, run, "escrow_042")
+	assert.Equal(t, "escrow_042", manager.loadCheckpoint(ctx, "auto_release"))
+	assert.Empty(t, manager.loadCheckpoint(ctx, "rating_reminder"))
+
+	manager.saveCheckpoint(ctx, run, "")
+	assert.Empty(t, manager.loadCheckpoint(ctx, "auto_release"))
+}
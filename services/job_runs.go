@@ -0,0 +1,176 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/metrics"
+)
+
+// JobRun represents a single execution of a background job
+type JobRun struct {
+	ID          string                       `json:"id"`
+	JobKey      string                       `json:"jobKey"`
+	Trigger     string                       `json:"trigger"`               // scheduled, manual, cron
+	TriggeredBy string                       `json:"triggeredBy,omitempty"` // Admin UID, or "service:<issuer>", for manual runs
+	Status      string                       `json:"status"`                // running, completed, failed, aborted
+	StartedAt   time.Time                    `json:"startedAt"`
+	FinishedAt  *time.Time                   `json:"finishedAt,omitempty"`
+	Result      string                       `json:"result,omitempty"`
+	Attempts    map[string]OperationAttempts `json:"attempts,omitempty"` // Per-operation attempt counts, including retries
+}
+
+// Job run constants
+const (
+	// Run Triggers
+	JobTriggerScheduled = "scheduled"
+	JobTriggerManual    = "manual"
+	JobTriggerCron      = "cron"
+
+	// Run Status
+	JobRunStatusRunning   = "running"
+	JobRunStatusCompleted = "completed"
+	JobRunStatusFailed    = "failed"
+	JobRunStatusAborted   = "aborted"
+
+	// Number of finished runs kept in memory for polling
+	maxJobRunHistory = 100
+)
+
+// JobAlreadyRunningError is returned when a job is triggered while a run of the same job is active
+type JobAlreadyRunningError struct {
+	JobKey      string
+	ActiveRunID string
+}
+
+func (e *JobAlreadyRunningError) Error() string {
+	return fmt.Sprintf("job %s is already running (run %s)", e.JobKey, e.ActiveRunID)
+}
+
+var (
+	activeRuns  = make(map[string]*JobRun) // keyed by job key
+	jobRuns     = make(map[string]*JobRun) // keyed by run ID
+	jobRunOrder []string
+)
+
+// beginJobRun registers a new run for the job, refusing to start if one is already active.
+// Guarded by statusMutex so the check and the registration happen atomically.
+func beginJobRun(jobKey, trigger string) (*JobRun, error) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+
+	if active, exists := activeRuns[jobKey]; exists {
+		return nil, &JobAlreadyRunningError{JobKey: jobKey, ActiveRunID: active.ID}
+	}
+
+	run := &JobRun{
+		ID:        uuid.NewString(),
+		JobKey:    jobKey,
+		Trigger:   trigger,
+		Status:    JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+
+	activeRuns[jobKey] = run
+	jobRuns[run.ID] = run
+	jobRunOrder = append(jobRunOrder, run.ID)
+
+	// Drop the oldest finished runs once history is full
+	for len(jobRunOrder) > maxJobRunHistory {
+		oldest := jobRunOrder[0]
+		if r, exists := jobRuns[oldest]; exists && r.Status == JobRunStatusRunning {
+			break
+		}
+		delete(jobRuns, oldest)
+		jobRunOrder = jobRunOrder[1:]
+	}
+
+	if status, exists := jobStatuses[jobKey]; exists {
+		status.IsRunning = true
+		status.CurrentRunID = run.ID
+	}
+
+	return run, nil
+}
+
+// finishJobRun marks the run as finished and records the result on the job status
+func finishJobRun(run *JobRun, result string, runTime time.Duration, hasError bool) {
+	status := JobRunStatusCompleted
+	if hasError {
+		status = JobRunStatusFailed
+	}
+	if completeJobRun(run, status, result) {
+		updateJobStatus(run.JobKey, result, runTime, hasError)
+	}
+}
+
+// abortJobRun records a run that stopped early because the job context was cancelled
+func abortJobRun(run *JobRun, result string, runTime time.Duration) {
+	if completeJobRun(run, JobRunStatusAborted, result) {
+		updateJobStatus(run.JobKey, result, runTime, false)
+	}
+}
+
+// abortActiveJobRuns marks every run that is still active as aborted and returns how many were
+// marked. Used when shutdown cannot wait any longer for running jobs to return.
+func abortActiveJobRuns(result string) int {
+	statusMutex.RLock()
+	runs := make([]*JobRun, 0, len(activeRuns))
+	for _, run := range activeRuns {
+		runs = append(runs, run)
+	}
+	statusMutex.RUnlock()
+
+	for _, run := range runs {
+		abortJobRun(run, result, time.Since(run.StartedAt))
+	}
+	return len(runs)
+}
+
+// completeJobRun moves a running run into its final state. It returns false if the run had
+// already been completed, so a job returning after being force-aborted does not overwrite it.
+func completeJobRun(run *JobRun, finalStatus, result string) bool {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+
+	if run.Status != JobRunStatusRunning {
+		return false
+	}
+
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Result = result
+	run.Status = finalStatus
+	metrics.ObserveJobRun(run.JobKey, finalStatus, now.Sub(run.StartedAt))
+
+	if active, exists := activeRuns[run.JobKey]; exists && active.ID == run.ID {
+		delete(activeRuns, run.JobKey)
+	}
+	if status, exists := jobStatuses[run.JobKey]; exists && status.CurrentRunID == run.ID {
+		status.CurrentRunID = ""
+	}
+	return true
+}
+
+// setJobRunAttempts records the attempt counts gathered by the run's retry policy
+func setJobRunAttempts(run *JobRun, attempts map[string]OperationAttempts) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+
+	run.Attempts = attempts
+}
+
+// GetJobRun returns a copy of the run with the given ID
+func GetJobRun(runID string) (*JobRun, error) {
+	statusMutex.RLock()
+	defer statusMutex.RUnlock()
+
+	run, exists := jobRuns[runID]
+	if !exists {
+		return nil, fmt.Errorf("job run not found: %s", runID)
+	}
+
+	runCopy := *run
+	return &runCopy, nil
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetJobRuns() {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+
+	activeRuns = make(map[string]*JobRun)
+	jobRuns = make(map[string]*JobRun)
+	jobRunOrder = nil
+	jobStatuses = map[string]*JobStatus{
+		"auto_release": {JobName: "Auto Release"},
+	}
+}
+
+func TestBeginJobRun(t *testing.T) {
+	t.Run("should reject a second run while one is active", func(t *testing.T) {
+		resetJobRuns()
+
+		first, err := beginJobRun("auto_release", JobTriggerScheduled)
+		require.NoError(t, err)
+		assert.Equal(t, JobRunStatusRunning, first.Status)
+
+		statuses := GetJobStatuses()
+		assert.True(t, statuses["auto_release"].IsRunning)
+		assert.Equal(t, first.ID, statuses["auto_release"].CurrentRunID)
+
+		_, err = beginJobRun("auto_release", JobTriggerManual)
+		require.Error(t, err)
+
+		var alreadyRunning *JobAlreadyRunningError
+		require.True(t, errors.As(err, &alreadyRunning))
+		assert.Equal(t, first.ID, alreadyRunning.ActiveRunID)
+	})
+
+	t.Run("should allow a new run once the active run finishes", func(t *testing.T) {
+		resetJobRuns()
+
+		first, err := beginJobRun("auto_release", JobTriggerScheduled)
+		require.NoError(t, err)
+		finishJobRun(first, "Successfully processed 0 automatic releases", 10*time.Millisecond, false)
+
+		second, err := beginJobRun("auto_release", JobTriggerManual)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.ID, second.ID)
+	})
+
+	t.Run("should not block other jobs", func(t *testing.T) {
+		resetJobRuns()
+
+		_, err := beginJobRun("auto_release", JobTriggerScheduled)
+		require.NoError(t, err)
+
+		_, err = beginJobRun("rating_reminder", JobTriggerManual)
+		assert.NoError(t, err)
+	})
+}
+
+func TestGetJobRun(t *testing.T) {
+	t.Run("should return finished run by ID", func(t *testing.T) {
+		resetJobRuns()
+
+		run, err := beginJobRun("auto_release", JobTriggerManual)
+		require.NoError(t, err)
+		finishJobRun(run, "Auto release failed: boom", time.Second, true)
+
+		fetched, err := GetJobRun(run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobRunStatusFailed, fetched.Status)
+		assert.Equal(t, "Auto release failed: boom", fetched.Result)
+		assert.NotNil(t, fetched.FinishedAt)
+
+		statuses := GetJobStatuses()
+		assert.False(t, statuses["auto_release"].IsRunning)
+		assert.Empty(t, statuses["auto_release"].CurrentRunID)
+		assert.Equal(t, 1, statuses["auto_release"].ErrorCount)
+	})
+
+	t.Run("should return error for unknown run", func(t *testing.T) {
+		resetJobRuns()
+
+		_, err := GetJobRun("missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("should cap run history", func(t *testing.T) {
+		resetJobRuns()
+
+		var firstID string
+		for i := 0; i < maxJobRunHistory+5; i++ {
+			run, err := beginJobRun("auto_release", JobTriggerScheduled)
+			require.NoError(t, err)
+			if i == 0 {
+				firstID = run.ID
+			}
+			finishJobRun(run, "ok", time.Millisecond, false)
+		}
+
+		_, err := GetJobRun(firstID)
+		assert.Error(t, err)
+		assert.Len(t, jobRunOrder, maxJobRunHistory)
+	})
+}
+
+func newTestJobManager() *BackgroundJobManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BackgroundJobManager{
+		config:   &JobConfig{AutoReleaseInterval: time.Hour},
+		shutdown: make(chan struct{}),
+		running:  true,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Run("should cancel running jobs and record them as aborted", func(t *testing.T) {
+		resetJobRuns()
+		manager := newTestJobManager()
+
+		started := make(chan struct{})
+		runID, err := manager.startManualRun("auto_release", "admin_1", func(run *JobRun) {
+			close(started)
+			<-manager.jobContext().Done()
+			abortJobRun(run, "Aborted: processed 2 releases, 0 failed before shutdown", time.Since(run.StartedAt))
+		})
+		require.NoError(t, err)
+		<-started
+
+		err = manager.Shutdown(context.Background())
+		require.NoError(t, err)
+
+		run, err := GetJobRun(runID)
+		require.NoError(t, err)
+		assert.Equal(t, JobRunStatusAborted, run.Status)
+		assert.Contains(t, run.Result, "Aborted")
+		assert.Equal(t, "admin_1", run.TriggeredBy)
+	})
+
+	t.Run("should abort runs still active at the deadline", func(t *testing.T) {
+		resetJobRuns()
+		manager := newTestJobManager()
+
+		release := make(chan struct{})
+		defer close(release)
+		runID, err := manager.startManualRun("auto_release", "admin_1", func(run *JobRun) {
+			<-release // ignores cancellation
+			finishJobRun(run, "Successfully processed 1 automatic releases", time.Since(run.StartedAt), false)
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err = manager.Shutdown(ctx)
+		require.Error(t, err)
+
+		run, err := GetJobRun(runID)
+		require.NoError(t, err)
+		assert.Equal(t, JobRunStatusAborted, run.Status)
+		assert.False(t, GetJobStatuses()["auto_release"].IsRunning)
+	})
+
+	t.Run("should refuse manual triggers after shutdown", func(t *testing.T) {
+		resetJobRuns()
+		manager := newTestJobManager()
+		require.NoError(t, manager.Shutdown(context.Background()))
+
+		_, err := manager.startManualRun("auto_release", "admin_1", func(run *JobRun) {})
+		assert.Error(t, err)
+	})
+}
+
+func TestFlushNotifications(t *testing.T) {
+	t.Run("should return once no notifications are pending", func(t *testing.T) {
+		err := FlushNotifications(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("should time out while a notification is in flight", func(t *testing.T) {
+		pendingNotifications.Add(1)
+		defer pendingNotifications.Add(-1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := FlushNotifications(ctx)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// Operations measured by a load test
+const (
+	LoadTestOpCreatePayment  = "create_payment"  // CreateGamePayment
+	LoadTestOpConfirmPayment = "confirm_payment" // ConfirmGamePayment after the card was charged
+	LoadTestOpAutoRelease    = "auto_release"    // One ProcessAutomaticReleases run
+)
+
+const (
+	loadTestPaymentMethod = "pm_card_visa"
+	loadTestMaxErrors     = 5 // Distinct errors kept per operation
+)
+
+// LoadTestConfig configures a load test: ConcurrentUsers each pay PaymentsPerUser games, then
+// AutoReleaseRuns auto-release runs compete for the resulting escrows
+type LoadTestConfig struct {
+	PerformanceTestConfig
+	AutoReleaseRuns int     `json:"autoReleaseRuns"`
+	Amount          float64 `json:"amount"` // Game price in euros
+	OrganizerID     string  `json:"organizerId"`
+}
+
+// withDefaults fills unset settings from the default performance configuration
+func (cfg LoadTestConfig) withDefaults() LoadTestConfig {
+	defaults := NewTestUtilities().GetDefaultPerformanceConfig()
+	if cfg.ConcurrentUsers <= 0 {
+		cfg.ConcurrentUsers = defaults.ConcurrentUsers
+	}
+	if cfg.PaymentsPerUser <= 0 {
+		cfg.PaymentsPerUser = defaults.PaymentsPerUser
+	}
+	if cfg.TestDurationSeconds <= 0 {
+		cfg.TestDurationSeconds = defaults.TestDurationSeconds
+	}
+	if cfg.MaxAcceptableLatencyMs <= 0 {
+		cfg.MaxAcceptableLatencyMs = defaults.MaxAcceptableLatencyMs
+	}
+	if cfg.AutoReleaseRuns <= 0 {
+		cfg.AutoReleaseRuns = 3
+	}
+	if cfg.Amount <= 0 {
+		cfg.Amount = 10.0
+	}
+	if cfg.OrganizerID == "" {
+		cfg.OrganizerID = defaultScenarioOrganizer
+	}
+	return cfg
+}
+
+// LoadTestReport is the outcome of a load test. It fails when an operation's p95 latency is
+// above MaxAcceptableLatencyMs or any request failed.
+type LoadTestReport struct {
+	Config      LoadTestConfig      `json:"config"`
+	StartedAt   time.Time           `json:"startedAt"`
+	Duration    string              `json:"duration"`
+	TimedOut    bool                `json:"timedOut"` // The test duration ran out before every payment was made
+	Operations  []LoadTestOperation `json:"operations"`
+	Escrows     map[string]int      `json:"escrows"` // Escrows in the store per status after the run
+	Regressions []string            `json:"regressions,omitempty"`
+	Passed      bool                `json:"passed"`
+}
+
+// LoadTestOperation holds the metrics of one measured operation
+type LoadTestOperation struct {
+	Name string `json:"name"`
+	LoadTestResult
+	Errors []string `json:"errors,omitempty"`
+}
+
+// LoadTester drives concurrent payment flows and auto-release runs through a payment service.
+// Payments go through Stripe, so the service must be in test mode; point STRIPE_API_BASE at a
+// stand-in to measure the service rather than the network.
+type LoadTester struct {
+	service *PaymentService
+}
+
+// NewLoadTester creates a load tester for the given payment service
+func NewLoadTester(service *PaymentService) *LoadTester {
+	return &LoadTester{service: service}
+}
+
+// NewInMemoryPaymentService returns a payment service that keeps its payments, escrows, ratings
+// and disputes in memory and logs its notifications, for scenario runs and load tests
+func NewInMemoryPaymentService() *PaymentService {
+	service := NewPaymentService()
+	service.paymentStore = NewMemoryPaymentStore()
+	service.escrowStore = NewMemoryEscrowStore()
+	service.ratingStore = NewMemoryRatingStore()
+	service.disputeStore = NewMemoryDisputeStore()
+	service.notifications = LogNotifier{}
+	return service
+}
+
+// Run makes the configured payments concurrently, then moves the run's clock past every hold and
+// grace period and starts the auto-release runs at once. Payments stop being started when
+// TestDurationSeconds runs out; payments in flight are finished.
+func (lt *LoadTester) Run(ctx context.Context, cfg LoadTestConfig) *LoadTestReport {
+	cfg = cfg.withDefaults()
+	s := lt.service.WithContext(ctx)
+	base := s.clock
+	if base == nil {
+		base = ProcessClock()
+	}
+	clock := NewVirtualClock(base)
+	s.clock = clock
+
+	report := &LoadTestReport{Config: cfg, StartedAt: time.Now()}
+	deadline := report.StartedAt.Add(time.Duration(cfg.TestDurationSeconds) * time.Second)
+	slog.InfoContext(ctx, "Starting payment load test",
+		"users", cfg.ConcurrentUsers, "payments_per_user", cfg.PaymentsPerUser, "auto_release_runs", cfg.AutoReleaseRuns)
+
+	created, confirmed, released := &latencyRecorder{}, &latencyRecorder{}, &latencyRecorder{}
+	var (
+		wg       sync.WaitGroup
+		timedOut sync.Once
+	)
+	for user := 0; user < cfg.ConcurrentUsers; user++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			userID := "load_user_" + uuid.New().String()[:8]
+			for i := 0; i < cfg.PaymentsPerUser; i++ {
+				if ctx.Err() != nil || time.Now().After(deadline) {
+					timedOut.Do(func() { report.TimedOut = true })
+					return
+				}
+				lt.payGame(s, cfg, userID, created, confirmed)
+			}
+		}()
+	}
+	wg.Wait()
+	paymentsDuration := time.Since(report.StartedAt)
+
+	// A year is past the hold and grace period of any release policy
+	clock.Advance(365 * 24 * time.Hour)
+
+	releaseStart := time.Now()
+	for run := 0; run < cfg.AutoReleaseRuns; run++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			_, failed, _, _, err := s.ProcessAutomaticReleasesContext(ctx)
+			if err == nil && failed > 0 {
+				err = fmt.Errorf("%d releases failed", failed)
+			}
+			released.record(start, err)
+		}()
+	}
+	wg.Wait()
+	releaseDuration := time.Since(releaseStart)
+
+	report.Operations = []LoadTestOperation{
+		created.operation(LoadTestOpCreatePayment, paymentsDuration),
+		confirmed.operation(LoadTestOpConfirmPayment, paymentsDuration),
+		released.operation(LoadTestOpAutoRelease, releaseDuration),
+	}
+	report.Escrows = lt.countEscrows(ctx, s)
+	report.Regressions = report.findRegressions()
+	report.Passed = len(report.Regressions) == 0
+	report.Duration = time.Since(report.StartedAt).String()
+
+	slog.InfoContext(ctx, "Payment load test finished", "passed", report.Passed, "duration", report.Duration, "regressions", len(report.Regressions))
+	return report
+}
+
+// payGame creates a payment for a fresh game, pays it with a test card and confirms it
+func (lt *LoadTester) payGame(s *PaymentService, cfg LoadTestConfig, userID string, created, confirmed *latencyRecorder) {
+	gameID := "load_game_" + uuid.New().String()[:8]
+	applicationID := "load_app_" + uuid.New().String()[:8]
+
+	start := time.Now()
+	payment, _, err := s.CreateGamePayment(userID, gameID, applicationID, cfg.OrganizerID, cfg.Amount)
+	created.record(start, err)
+	if err != nil {
+		return
+	}
+
+	// Paying is the frontend's part of the flow, so it isn't measured
+	if _, err := s.stripeService.ConfirmTestPaymentIntent(payment.StripePaymentID, loadTestPaymentMethod); err != nil {
+		confirmed.fail(fmt.Errorf("test card payment failed: %w", err))
+		return
+	}
+
+	start = time.Now()
+	_, _, err = s.ConfirmGamePayment(payment.ID)
+	confirmed.record(start, err)
+}
+
+// countEscrows counts the escrows in the store per status, leaving out empty statuses
+func (lt *LoadTester) countEscrows(ctx context.Context, s *PaymentService) map[string]int {
+	statuses := []string{
+		models.EscrowStatusHeld,
+		models.EscrowStatusPendingRating,
+		models.EscrowStatusReleased,
+		models.EscrowStatusReleaseFailed,
+		models.EscrowStatusUnderReview,
+	}
+
+	counts := make(map[string]int, len(statuses))
+	for _, status := range statuses {
+		count, err := s.escrows().CountByStatus(ctx, status)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to count escrows", "status", status, "error", err)
+			continue
+		}
+		if count > 0 {
+			counts[status] = count
+		}
+	}
+	return counts
+}
+
+// findRegressions lists the operations that were too slow or had failures
+func (r *LoadTestReport) findRegressions() []string {
+	var regressions []string
+	for _, op := range r.Operations {
+		if op.TotalRequests == 0 {
+			regressions = append(regressions, fmt.Sprintf("%s: no requests were made", op.Name))
+			continue
+		}
+		if op.P95LatencyMs > r.Config.MaxAcceptableLatencyMs {
+			regressions = append(regressions, fmt.Sprintf("%s: p95 latency %dms exceeds the %dms limit",
+				op.Name, op.P95LatencyMs, r.Config.MaxAcceptableLatencyMs))
+		}
+		if op.FailedRequests > 0 {
+			regressions = append(regressions, fmt.Sprintf("%s: %d of %d requests failed",
+				op.Name, op.FailedRequests, op.TotalRequests))
+		}
+	}
+	return regressions
+}
+
+// Markdown renders the report for a CI summary or a pull request comment
+func (r *LoadTestReport) Markdown() string {
+	var b strings.Builder
+
+	result := "✅ Passed"
+	if !r.Passed {
+		result = "❌ Failed"
+	}
+	fmt.Fprintf(&b, "# Payment Load Test\n\n")
+	fmt.Fprintf(&b, "**Result:** %s  \n", result)
+	fmt.Fprintf(&b, "**Started:** %s  \n", r.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "**Duration:** %s  \n", r.Duration)
+	fmt.Fprintf(&b, "**Load:** %d users × %d payments, %d concurrent auto-release runs  \n",
+		r.Config.ConcurrentUsers, r.Config.PaymentsPerUser, r.Config.AutoReleaseRuns)
+	fmt.Fprintf(&b, "**Latency limit (p95):** %dms\n", r.Config.MaxAcceptableLatencyMs)
+	if r.TimedOut {
+		fmt.Fprintf(&b, "\n⚠️ The test duration of %ds ran out before every payment was made.\n", r.Config.TestDurationSeconds)
+	}
+
+	fmt.Fprintf(&b, "\n## Latency\n\n")
+	fmt.Fprintf(&b, "| Operation | Requests | Failed | Min | p50 | p90 | p95 | p99 | Max | Avg | Req/s |\n")
+	fmt.Fprintf(&b, "|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, op := range r.Operations {
+		fmt.Fprintf(&b, "| %s | %d | %d | %dms | %dms | %dms | %dms | %dms | %dms | %dms | %.1f |\n",
+			op.Name, op.TotalRequests, op.FailedRequests, op.MinLatencyMs, op.P50LatencyMs, op.P90LatencyMs,
+			op.P95LatencyMs, op.P99LatencyMs, op.MaxLatencyMs, op.AverageLatencyMs, op.RequestsPerSecond)
+	}
+
+	if len(r.Escrows) > 0 {
+		fmt.Fprintf(&b, "\n## Escrows\n\n| Status | Count |\n|---|---:|\n")
+		statuses := make([]string, 0, len(r.Escrows))
+		for status := range r.Escrows {
+			statuses = append(statuses, status)
+		}
+		slices.Sort(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(&b, "| %s | %d |\n", status, r.Escrows[status])
+		}
+	}
+
+	if len(r.Regressions) > 0 {
+		fmt.Fprintf(&b, "\n## Regressions\n\n")
+		for _, regression := range r.Regressions {
+			fmt.Fprintf(&b, "- %s\n", regression)
+		}
+	}
+
+	for _, op := range r.Operations {
+		if len(op.Errors) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s errors\n\n", op.Name)
+		for _, err := range op.Errors {
+			fmt.Fprintf(&b, "- `%s`\n", err)
+		}
+	}
+
+	return b.String()
+}
+
+// latencyRecorder collects the outcome of one operation across workers
+type latencyRecorder struct {
+	mu        sync.Mutex
+	total     int
+	succeeded int
+	latencies []time.Duration
+	errors    []string
+}
+
+// record adds a request that started at start
+func (lr *latencyRecorder) record(start time.Time, err error) {
+	latency := time.Since(start)
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.total++
+	lr.latencies = append(lr.latencies, latency)
+	if err != nil {
+		lr.addError(err)
+		return
+	}
+	lr.succeeded++
+}
+
+// fail adds a request that couldn't be made, without a latency
+func (lr *latencyRecorder) fail(err error) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.total++
+	lr.addError(err)
+}
+
+func (lr *latencyRecorder) addError(err error) {
+	if len(lr.errors) < loadTestMaxErrors && !slices.Contains(lr.errors, err.Error()) {
+		lr.errors = append(lr.errors, err.Error())
+	}
+}
+
+func (lr *latencyRecorder) operation(name string, duration time.Duration) LoadTestOperation {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	metrics := NewTestUtilities().CalculateLoadTestMetrics(lr.total, lr.succeeded, lr.latencies, duration)
+	return LoadTestOperation{Name: name, LoadTestResult: *metrics, Errors: lr.errors}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateLoadTestMetrics(t *testing.T) {
+	latencies := make([]time.Duration, 0, 100)
+	for ms := 100; ms >= 1; ms-- {
+		latencies = append(latencies, time.Duration(ms)*time.Millisecond)
+	}
+
+	result := NewTestUtilities().CalculateLoadTestMetrics(110, 100, latencies, 10*time.Second)
+
+	assert.Equal(t, int64(1), result.MinLatencyMs)
+	assert.Equal(t, int64(100), result.MaxLatencyMs)
+	assert.Equal(t, int64(50), result.AverageLatencyMs)
+	assert.Equal(t, int64(50), result.P50LatencyMs)
+	assert.Equal(t, int64(90), result.P90LatencyMs)
+	assert.Equal(t, int64(95), result.P95LatencyMs)
+	assert.Equal(t, int64(99), result.P99LatencyMs)
+	assert.Equal(t, 10, result.FailedRequests)
+	assert.Equal(t, 11.0, result.RequestsPerSecond)
+	assert.InDelta(t, 9.09, result.ErrorRate, 0.01)
+	assert.Equal(t, 100*time.Millisecond, latencies[0], "the caller's latencies are left in order")
+
+	single := NewTestUtilities().CalculateLoadTestMetrics(1, 1, []time.Duration{7 * time.Millisecond}, time.Second)
+	assert.Equal(t, int64(7), single.P50LatencyMs)
+	assert.Equal(t, int64(7), single.P99LatencyMs)
+}
+
+func TestLoadTester(t *testing.T) {
+	server := useStripeStandIn(t)
+	server.AddAccount(defaultScenarioOrganizer)
+	tester := NewLoadTester(NewInMemoryPaymentService())
+
+	cfg := LoadTestConfig{
+		PerformanceTestConfig: PerformanceTestConfig{
+			ConcurrentUsers:        4,
+			PaymentsPerUser:        3,
+			TestDurationSeconds:    30,
+			MaxAcceptableLatencyMs: 5000,
+		},
+		AutoReleaseRuns: 3,
+	}
+
+	t.Run("should measure every operation", func(t *testing.T) {
+		report := tester.Run(context.Background(), cfg)
+
+		assert.True(t, report.Passed, "regressions: %v", report.Regressions)
+		assert.False(t, report.TimedOut)
+		require.Len(t, report.Operations, 3)
+
+		requests := map[string]int{}
+		for _, op := range report.Operations {
+			requests[op.Name] = op.TotalRequests
+			assert.Zero(t, op.FailedRequests, "%s errors: %v", op.Name, op.Errors)
+			assert.LessOrEqual(t, op.P50LatencyMs, op.P95LatencyMs)
+		}
+		assert.Equal(t, map[string]int{LoadTestOpCreatePayment: 12, LoadTestOpConfirmPayment: 12, LoadTestOpAutoRelease: 3}, requests)
+
+		total := 0
+		for _, count := range report.Escrows {
+			total += count
+		}
+		assert.Equal(t, 12, total, "one escrow per confirmed payment: %v", report.Escrows)
+		assert.Zero(t, report.Escrows[models.EscrowStatusHeld], "every hold is over")
+
+		encoded, err := json.Marshal(report)
+		require.NoError(t, err)
+		assert.Contains(t, string(encoded), `"p95LatencyMs"`)
+		assert.Contains(t, report.Markdown(), "| create_payment | 12 | 0 |")
+	})
+
+	t.Run("should fail on a latency regression", func(t *testing.T) {
+		server.Latency = 20 * time.Millisecond
+		defer func() { server.Latency = 0 }()
+
+		slow := cfg
+		slow.ConcurrentUsers, slow.PaymentsPerUser, slow.AutoReleaseRuns = 2, 1, 1
+		slow.MaxAcceptableLatencyMs = 10
+		report := tester.Run(context.Background(), slow)
+
+		assert.False(t, report.Passed)
+		assert.Contains(t, report.Regressions, fmt.Sprintf("create_payment: p95 latency %dms exceeds the 10ms limit",
+			report.Operations[0].P95LatencyMs))
+		assert.Contains(t, report.Markdown(), "## Regressions")
+	})
+}
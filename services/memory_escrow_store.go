@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// MemoryEscrowStore keeps escrows in memory, for tests and benchmarks. Latency, when set, is
+// added to every call to approximate a remote database.
+type MemoryEscrowStore struct {
+	Latency time.Duration
+
+	mu      sync.RWMutex
+	escrows map[string]models.EscrowTransaction
+}
+
+// NewMemoryEscrowStore creates an in-memory store seeded with the given escrows
+func NewMemoryEscrowStore(escrows ...*models.EscrowTransaction) *MemoryEscrowStore {
+	store := &MemoryEscrowStore{escrows: make(map[string]models.EscrowTransaction, len(escrows))}
+	for _, escrow := range escrows {
+		store.escrows[escrow.ID] = *escrow
+	}
+	return store
+}
+
+func (ms *MemoryEscrowStore) Get(ctx context.Context, escrowID string) (*models.EscrowTransaction, error) {
+	if err := ms.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	escrow, exists := ms.escrows[escrowID]
+	if !exists {
+		return nil, fmt.Errorf("escrow transaction not found: %s", escrowID)
+	}
+	return &escrow, nil
+}
+
+func (ms *MemoryEscrowStore) Put(ctx context.Context, escrow *models.EscrowTransaction) error {
+	if err := ms.wait(ctx); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.escrows[escrow.ID] = *escrow
+	return nil
+}
+
+func (ms *MemoryEscrowStore) Update(ctx context.Context, escrowID string, fn func(escrow *models.EscrowTransaction) error) (*models.EscrowTransaction, error) {
+	if err := ms.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	escrow, exists := ms.escrows[escrowID]
+	if !exists {
+		return nil, fmt.Errorf("escrow transaction not found: %s", escrowID)
+	}
+	if err := fn(&escrow); err != nil {
+		return nil, err
+	}
+	ms.escrows[escrowID] = escrow
+
+	updated := escrow
+	return &updated, nil
+}
+
+func (ms *MemoryEscrowStore) ListEligibleForRelease(ctx context.Context, now time.Time, after *models.EscrowTransaction, limit int) ([]*models.EscrowTransaction, error) {
+	if err := ms.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	ms.mu.RLock()
+	var eligible []*models.EscrowTransaction
+	for _, escrow := range ms.escrows {
+		if slices.Contains(releaseCandidateStatuses, escrow.Status) && !escrow.ReleaseEligibleAt.After(now) {
+			escrowCopy := escrow
+			eligible = append(eligible, &escrowCopy)
+		}
+	}
+	ms.mu.RUnlock()
+
+	sort.Slice(eligible, func(i, j int) bool {
+		return releaseOrderLess(eligible[i], eligible[j])
+	})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(eligible), func(i int) bool {
+			return releaseOrderLess(after, eligible[i])
+		})
+	}
+	end := min(start+limit, len(eligible))
+
+	return eligible[start:end], nil
+}
+
+func (ms *MemoryEscrowStore) ListByStatus(ctx context.Context, status string) ([]*models.EscrowTransaction, error) {
+	if err := ms.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var escrows []*models.EscrowTransaction
+	for _, escrow := range ms.escrows {
+		if escrow.Status == status {
+			escrowCopy := escrow
+			escrows = append(escrows, &escrowCopy)
+		}
+	}
+	sort.Slice(escrows, func(i, j int) bool { return escrows[i].ID < escrows[j].ID })
+	return escrows, nil
+}
+
+func (ms *MemoryEscrowStore) CountByStatus(ctx context.Context, status string) (int, error) {
+	escrows, err := ms.ListByStatus(ctx, status)
+	return len(escrows), err
+}
+
+func (ms *MemoryEscrowStore) wait(ctx context.Context) error {
+	if ms.Latency <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(ms.Latency)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// releaseOrderLess orders escrows by release time, then ID, matching the Firestore query
+func releaseOrderLess(a, b *models.EscrowTransaction) bool {
+	if !a.ReleaseEligibleAt.Equal(b.ReleaseEligibleAt) {
+		return a.ReleaseEligibleAt.Before(b.ReleaseEligibleAt)
+	}
+	return a.ID < b.ID
+}
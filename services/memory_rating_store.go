@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// MemoryRatingStore keeps attendance and ratings in memory, for tests
+type MemoryRatingStore struct {
+	mu             sync.RWMutex
+	playersPresent map[string][]string // keyed by game ID
+	ratings        map[string]models.RatingValidation
+}
+
+// NewMemoryRatingStore creates an empty in-memory rating store
+func NewMemoryRatingStore() *MemoryRatingStore {
+	return &MemoryRatingStore{
+		playersPresent: make(map[string][]string),
+		ratings:        make(map[string]models.RatingValidation),
+	}
+}
+
+// SetPlayersPresent records who attended the game
+func (ms *MemoryRatingStore) SetPlayersPresent(gameID string, playerIDs ...string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.playersPresent[gameID] = playerIDs
+}
+
+func (ms *MemoryRatingStore) RecordAttendance(ctx context.Context, gameID string, playerIDs []string) error {
+	ms.SetPlayersPresent(gameID, playerIDs...)
+	return nil
+}
+
+func (ms *MemoryRatingStore) PlayersPresent(ctx context.Context, gameID string) ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.playersPresent[gameID], nil
+}
+
+func (ms *MemoryRatingStore) ListGameRatings(ctx context.Context, gameID, ratedPlayerID string) ([]*models.RatingValidation, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var ratings []*models.RatingValidation
+	for _, rating := range ms.ratings {
+		if rating.GameID == gameID && rating.RatedPlayerID == ratedPlayerID {
+			ratingCopy := rating
+			ratings = append(ratings, &ratingCopy)
+		}
+	}
+	return ratings, nil
+}
+
+func (ms *MemoryRatingStore) SaveRating(ctx context.Context, rating *models.RatingValidation) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.ratings[rating.ID] = *rating
+	return nil
+}
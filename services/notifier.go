@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+)
+
+// Notification event types, used to route notifications to channels
+const (
+	EventReleaseSucceeded = "release_succeeded"
+	EventReleaseFailed    = "release_failed"
+	EventReviewNeeded     = "review_needed"
+	EventJobSummary       = "job_summary"
+)
+
+// Notification severities; a route keyed by severity adds channels on top of the event's route
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Notification channel names used in NOTIFY_ROUTES
+const (
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelLog     = "log"
+)
+
+// RouteDefault is the route used for events without a route of their own
+const RouteDefault = "default"
+
+// defaultNotifyTimeout bounds each delivery attempt when NOTIFY_TIMEOUT is not set
+const defaultNotifyTimeout = 10 * time.Second
+
+// Notification is an alert or report for the team, delivered by a Notifier
+type Notification struct {
+	Event    string
+	Severity string
+	Key      string                 // Entity the notification is about, e.g. the escrow ID; used for deduplication
+	Idle     bool                   // Job summary of a run that had nothing to do
+	Title    string                 // Plain-text summary, used as the email subject
+	Text     string                 // Slack mrkdwn body, also sent as-is by the other channels
+	Blocks   []SlackBlock           // Optional Block Kit layout; only the Slack channel uses it
+	Fields   map[string]interface{} // Structured details for webhook receivers and logs
+}
+
+// Notifier delivers notifications to one or more channels
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// NotificationRouter sends each notification to the channels routed for its event type, or the
+// default route, plus the channels routed for its severity
+type NotificationRouter struct {
+	Channels map[string]Notifier
+	Routes   map[string][]string   // Event type, severity or RouteDefault -> channel names
+	Throttle *NotificationThrottle // Optional deduplication, rate limits and digest
+}
+
+// Notify delivers the notification to every routed channel, returning the joined channel errors
+func (r *NotificationRouter) Notify(ctx context.Context, notification Notification) error {
+	return r.notifyAt(ctx, notification, time.Now())
+}
+
+func (r *NotificationRouter) notifyAt(ctx context.Context, notification Notification, now time.Time) error {
+	if r.Throttle == nil {
+		return r.deliver(ctx, notification, now)
+	}
+
+	var errs []error
+	if digest, due := r.Throttle.takeDigest(now, false); due {
+		errs = append(errs, r.deliver(ctx, digest, now))
+	}
+	if r.Throttle.admit(notification, now) && !r.Throttle.holdForDigest(notification, now) {
+		errs = append(errs, r.deliver(ctx, notification, now))
+	}
+	return errors.Join(errs...)
+}
+
+// FlushDigest sends the pending release digest, if any, without waiting for its period to end
+func (r *NotificationRouter) FlushDigest(ctx context.Context) error {
+	return r.sendDigest(ctx, time.Now(), true)
+}
+
+// SendDueDigest sends the pending release digest once its period has ended. Notify only checks
+// the digest when another notification comes in, so job runs call this to send a digest that
+// fell due after the last release.
+func (r *NotificationRouter) SendDueDigest(ctx context.Context) error {
+	return r.sendDigest(ctx, time.Now(), false)
+}
+
+func (r *NotificationRouter) sendDigest(ctx context.Context, now time.Time, force bool) error {
+	if r.Throttle == nil {
+		return nil
+	}
+	if digest, pending := r.Throttle.takeDigest(now, force); pending {
+		return r.deliver(ctx, digest, now)
+	}
+	return nil
+}
+
+func (r *NotificationRouter) deliver(ctx context.Context, notification Notification, now time.Time) error {
+	var errs []error
+	for _, name := range r.channelsFor(notification) {
+		if r.Throttle != nil && !r.Throttle.allowChannel(name, notification, now) {
+			continue
+		}
+		if err := r.Channels[name].Notify(ctx, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *NotificationRouter) channelsFor(notification Notification) []string {
+	names, ok := r.Routes[notification.Event]
+	if !ok {
+		names = r.Routes[RouteDefault]
+	}
+	names = append(names[:len(names):len(names)], r.Routes[notification.Severity]...)
+
+	seen := make(map[string]bool, len(names))
+	var channels []string
+	for _, name := range names {
+		if !seen[name] && r.Channels[name] != nil {
+			seen[name] = true
+			channels = append(channels, name)
+		}
+	}
+	return channels
+}
+
+// Validate checks every route uses a known key and a configured channel
+func (r *NotificationRouter) Validate() error {
+	var errs []error
+	for key, names := range r.Routes {
+		switch key {
+		case RouteDefault, EventReleaseSucceeded, EventReleaseFailed, EventReviewNeeded, EventJobSummary,
+			SeverityInfo, SeverityWarning, SeverityCritical:
+		default:
+			errs = append(errs, fmt.Errorf("unknown notification route %q", key))
+			continue
+		}
+		for _, name := range names {
+			if r.Channels[name] == nil {
+				errs = append(errs, fmt.Errorf("notification route %q uses unconfigured channel %q", key, name))
+			}
+		}
+	}
+	if r.Throttle != nil {
+		for name := range r.Throttle.RateLimits {
+			if r.Channels[name] == nil {
+				errs = append(errs, fmt.Errorf("notification rate limit set for unconfigured channel %q", name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewNotifier builds the notification router from the jobs config. Every configured channel is
+// available to NOTIFY_ROUTES; events without a route go to Slack when it is configured and are
+// logged otherwise. The router gets its own throttle built from the NOTIFY_* throttling settings.
+func NewNotifier(conf *config.JobsConfig, retry *RetryPolicy) (*NotificationRouter, error) {
+	timeout := conf.NotifyTimeout
+	if timeout <= 0 {
+		timeout = defaultNotifyTimeout
+	}
+
+	router := &NotificationRouter{
+		Channels: map[string]Notifier{ChannelLog: LogNotifier{}},
+		Routes:   map[string][]string{RouteDefault: {ChannelLog}},
+	}
+	if conf.SlackWebhookURL != "" {
+		router.Channels[ChannelSlack] = NewSlackNotifier(conf.SlackWebhookURL, timeout, retry)
+		router.Routes[RouteDefault] = []string{ChannelSlack}
+	}
+	if conf.NotifyWebhookURL != "" {
+		router.Channels[ChannelWebhook] = NewWebhookNotifier(conf.NotifyWebhookURL, timeout, retry)
+	}
+	if conf.SMTPAddr != "" && len(conf.NotifyEmailTo) > 0 {
+		router.Channels[ChannelEmail] = &EmailNotifier{
+			Addr:     conf.SMTPAddr,
+			Username: conf.SMTPUsername,
+			Password: conf.SMTPPassword,
+			From:     conf.SMTPFrom,
+			To:       conf.NotifyEmailTo,
+			Timeout:  timeout,
+		}
+	}
+
+	for key, value := range conf.NotifyRoutes {
+		var names []string
+		for _, name := range strings.Split(value, "|") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		router.Routes[key] = names
+	}
+
+	throttle, err := newNotificationThrottle(conf)
+	if err != nil {
+		return nil, err
+	}
+	router.Throttle = throttle
+
+	if err := router.Validate(); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// configuredNotifier builds the notifier from the jobs config, falling back to notifierFromEnv
+// when the routing is invalid so alerts still go out. Throttling state is shared across services.
+func configuredNotifier(conf *config.JobsConfig, retry *RetryPolicy) Notifier {
+	router, err := NewNotifier(conf, retry)
+	if err != nil {
+		slog.Warn("Invalid notification routing, using the default route", "error", err)
+		return notifierFromEnv(retry)
+	}
+	router.Throttle = sharedNotificationThrottle(conf)
+	return router
+}
+
+// notifierFromEnv is the notifier of a PaymentService built without one: Slack when
+// SLACK_ESCROW_WEBHOOK_URL is set, logging otherwise
+func notifierFromEnv(retry *RetryPolicy) Notifier {
+	webhookURL := os.Getenv("SLACK_ESCROW_WEBHOOK_URL")
+	if webhookURL == "" {
+		return LogNotifier{}
+	}
+	return NewSlackNotifier(webhookURL, defaultNotifyTimeout, retry)
+}
+
+// notifier returns the notifier used for alerts and job reports
+func (s *PaymentService) notifier() Notifier {
+	if s.notifications == nil {
+		return notifierFromEnv(s.retry)
+	}
+	return s.notifications
+}
+
+// notify delivers a notification, logging failures so an alert never fails the operation that
+// raised it. In-flight notifications are tracked for FlushNotifications.
+func (s *PaymentService) notify(notification Notification) {
+	pendingNotifications.Add(1)
+	defer pendingNotifications.Add(-1)
+
+	if err := s.notifier().Notify(s.baseContext(), notification); err != nil {
+		slog.ErrorContext(s.baseContext(), "Failed to deliver notification", "event", notification.Event, "error", err)
+	}
+}
+
+// FakeNotifier records notifications instead of delivering them, for tests
+type FakeNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+	Err  error // Returned from every Notify call when set
+}
+
+// Notify records the notification
+func (f *FakeNotifier) Notify(ctx context.Context, notification Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, notification)
+	return f.Err
+}
+
+// Sent returns every notification recorded so far
+func (f *FakeNotifier) Sent() []Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Notification(nil), f.sent...)
+}
+
+// SentFor returns the recorded notifications of one event type
+func (f *FakeNotifier) SentFor(event string) []Notification {
+	var matched []Notification
+	for _, notification := range f.Sent() {
+		if notification.Event == event {
+			matched = append(matched, notification)
+		}
+	}
+	return matched
+}
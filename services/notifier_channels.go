@@ -0,0 +1,281 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/metrics"
+)
+
+// SlackNotifier posts notifications to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+	Client     *http.Client
+	Retry      *RetryPolicy // Nil sends once
+}
+
+// NewSlackNotifier creates a Slack notifier whose requests time out after timeout
+func NewSlackNotifier(webhookURL string, timeout time.Duration, retry *RetryPolicy) *SlackNotifier {
+	return &SlackNotifier{
+		WebhookURL: webhookURL,
+		Client:     &http.Client{Timeout: timeout},
+		Retry:      retry,
+	}
+}
+
+// Notify posts the notification text, and its blocks if any, to the webhook
+func (n *SlackNotifier) Notify(ctx context.Context, notification Notification) error {
+	err := n.post(ctx, SlackMessage{Text: notification.Text, Blocks: notification.Blocks})
+	metrics.ObserveNotification(ChannelSlack, err)
+	return err
+}
+
+func (n *SlackNotifier) post(ctx context.Context, message SlackMessage) error {
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %w", err)
+	}
+
+	return n.Retry.Do(ctx, "slack.post_message", func() error {
+		return postSlackMessage(ctx, n.client(), jsonData, n.WebhookURL)
+	})
+}
+
+func (n *SlackNotifier) client() *http.Client {
+	if n.Client == nil {
+		return &http.Client{Timeout: defaultNotifyTimeout}
+	}
+	return n.Client
+}
+
+// postSlackMessage makes a single webhook POST, returning a *SlackHTTPError for non-200 responses
+func postSlackMessage(ctx context.Context, client *http.Client, jsonData []byte, webhookURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("invalid Slack webhook URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("network error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Try to read response body for more details
+		body := make([]byte, 512)
+		if n, err := resp.Body.Read(body); n > 0 && (err == nil || err == io.EOF) {
+			slog.WarnContext(ctx, "Slack error response", "status", resp.StatusCode, "body", string(body[:n]))
+		}
+		return &SlackHTTPError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a generic HTTP endpoint
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+	Retry  *RetryPolicy // Nil sends once
+}
+
+// webhookPayload is the JSON body posted by WebhookNotifier
+type webhookPayload struct {
+	Event    string                 `json:"event"`
+	Severity string                 `json:"severity"`
+	Title    string                 `json:"title"`
+	Text     string                 `json:"text"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+	SentAt   time.Time              `json:"sentAt"`
+}
+
+// NewWebhookNotifier creates a webhook notifier whose requests time out after timeout
+func NewWebhookNotifier(url string, timeout time.Duration, retry *RetryPolicy) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: timeout},
+		Retry:  retry,
+	}
+}
+
+// Notify posts the notification to the webhook, expecting a 2xx response
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	jsonData, err := json.Marshal(webhookPayload{
+		Event:    notification.Event,
+		Severity: notification.Severity,
+		Title:    notification.Title,
+		Text:     notification.Text,
+		Fields:   notification.Fields,
+		SentAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultNotifyTimeout}
+	}
+
+	err = n.Retry.Do(ctx, "webhook.post_notification", func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(jsonData))
+		if err != nil {
+			return fmt.Errorf("invalid notification webhook URL: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("network error: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return &WebhookHTTPError{StatusCode: resp.StatusCode}
+		}
+		return nil
+	})
+	metrics.ObserveNotification(ChannelWebhook, err)
+	return err
+}
+
+// EmailNotifier sends notifications as plain-text email over SMTP. STARTTLS is used when the
+// server offers it, and authentication when a username is set.
+type EmailNotifier struct {
+	Addr     string // host:port of the SMTP server
+	Username string
+	Password string
+	From     string
+	To       []string
+	Timeout  time.Duration // Bounds the whole SMTP conversation
+}
+
+// Notify emails the notification to every recipient
+func (n *EmailNotifier) Notify(ctx context.Context, notification Notification) error {
+	err := n.send(ctx, notification)
+	metrics.ObserveNotification(ChannelEmail, err)
+	return err
+}
+
+func (n *EmailNotifier) send(ctx context.Context, notification Notification) error {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = defaultNotifyTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", n.Addr, err)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+	if n.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.Username, n.Password, host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.From); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	for _, recipient := range n.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s failed: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := writer.Write(n.message(notification)); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected the email: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// message renders the notification as an RFC 5322 email
+func (n *EmailNotifier) message(notification Notification) []byte {
+	subject := notification.Title
+	if subject == "" {
+		subject = notification.Event
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", n.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&body, "Subject: [GoalHero Payments][%s] %s\r\n", notification.Severity, subject)
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(notification.Text, "\n", "\r\n"))
+	body.WriteString("\r\n")
+	return []byte(body.String())
+}
+
+// LogNotifier writes notifications to the service log, for local development and as the
+// fallback when no other channel is configured
+type LogNotifier struct{}
+
+// Notify logs the notification title and fields
+func (LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	keys := make([]string, 0, len(notification.Fields))
+	for key := range notification.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]any, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, slog.Any(key, notification.Fields[key]))
+	}
+
+	slog.InfoContext(ctx, notification.Title,
+		"event", notification.Event,
+		"severity", notification.Severity,
+		"key", notification.Key,
+		slog.Group("fields", fields...),
+	)
+	return nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationRouter(t *testing.T) {
+	slack, email, log := &FakeNotifier{}, &FakeNotifier{}, &FakeNotifier{}
+	router := &NotificationRouter{
+		Channels: map[string]Notifier{ChannelSlack: slack, ChannelEmail: email, ChannelLog: log},
+		Routes: map[string][]string{
+			RouteDefault:     {ChannelSlack},
+			EventJobSummary:  {ChannelLog},
+			SeverityCritical: {ChannelEmail, ChannelSlack},
+		},
+	}
+	require.NoError(t, router.Validate())
+
+	require.NoError(t, router.Notify(context.Background(), Notification{Event: EventJobSummary, Severity: SeverityInfo}))
+	require.NoError(t, router.Notify(context.Background(), Notification{Event: EventReleaseSucceeded, Severity: SeverityInfo}))
+	require.NoError(t, router.Notify(context.Background(), Notification{Event: EventReleaseFailed, Severity: SeverityCritical}))
+
+	assert.Len(t, log.SentFor(EventJobSummary), 1, "job summaries use their own route")
+	assert.Empty(t, slack.SentFor(EventJobSummary))
+	assert.Len(t, slack.SentFor(EventReleaseSucceeded), 1, "unrouted events use the default route")
+	assert.Len(t, slack.SentFor(EventReleaseFailed), 1, "a channel on both routes is notified once")
+	assert.Len(t, email.SentFor(EventReleaseFailed), 1, "critical notifications also go to the severity route")
+	assert.Len(t, email.Sent(), 1)
+}
+
+func TestNotificationRouterJoinsChannelErrors(t *testing.T) {
+	working := &FakeNotifier{}
+	router := &NotificationRouter{
+		Channels: map[string]Notifier{ChannelSlack: &FakeNotifier{Err: errors.New("slack down")}, ChannelLog: working},
+		Routes:   map[string][]string{RouteDefault: {ChannelSlack, ChannelLog}},
+	}
+
+	err := router.Notify(context.Background(), Notification{Event: EventReleaseFailed})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "slack: slack down")
+	assert.Len(t, working.Sent(), 1, "a failing channel does not stop the others")
+}
+
+func TestNewNotifier(t *testing.T) {
+	t.Run("should route to Slack by default when configured", func(t *testing.T) {
+		router, err := NewNotifier(&config.JobsConfig{SlackWebhookURL: "https://hooks.slack.com/services/T/B/X"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{ChannelSlack}, router.Routes[RouteDefault])
+	})
+
+	t.Run("should log by default without Slack", func(t *testing.T) {
+		router, err := NewNotifier(&config.JobsConfig{}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{ChannelLog}, router.Routes[RouteDefault])
+	})
+
+	t.Run("should parse routes", func(t *testing.T) {
+		router, err := NewNotifier(&config.JobsConfig{
+			SlackWebhookURL: "https://hooks.slack.com/services/T/B/X",
+			SMTPAddr:        "smtp.example.com:587",
+			NotifyEmailTo:   []string{"ops@example.com"},
+			NotifyRoutes:    map[string]string{EventReviewNeeded: "slack | email", SeverityCritical: "email"},
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{ChannelSlack, ChannelEmail}, router.Routes[EventReviewNeeded])
+		assert.Equal(t, []string{ChannelEmail}, router.Routes[SeverityCritical])
+	})
+
+	t.Run("should reject unknown routes and unconfigured channels", func(t *testing.T) {
+		_, err := NewNotifier(&config.JobsConfig{
+			NotifyRoutes: map[string]string{"payment_created": "log", EventJobSummary: "webhook"},
+		}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown notification route "payment_created"`)
+		assert.Contains(t, err.Error(), `unconfigured channel "webhook"`)
+	})
+}
+
+func TestSlackNotifierTimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL, 20*time.Millisecond, nil)
+	start := time.Now()
+	err := notifier.Notify(context.Background(), Notification{Text: "slow"})
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL, time.Second, nil)
+	err := notifier.Notify(context.Background(), Notification{
+		Event:    EventReleaseFailed,
+		Severity: SeverityWarning,
+		Title:    "Escrow escrow_1 release failed",
+		Text:     "❌ *Escrow Payment Processing Failed*",
+		Fields:   map[string]interface{}{"escrowId": "escrow_1"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, EventReleaseFailed, payload.Event)
+	assert.Equal(t, SeverityWarning, payload.Severity)
+	assert.Equal(t, "Escrow escrow_1 release failed", payload.Title)
+	assert.Equal(t, "escrow_1", payload.Fields["escrowId"])
+	assert.False(t, payload.SentAt.IsZero())
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	err = NewWebhookNotifier(failing.URL, time.Second, nil).Notify(context.Background(), Notification{})
+	var httpErr *WebhookHTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadGateway, httpErr.StatusCode)
+}
+
+func TestEmailNotifierMessage(t *testing.T) {
+	notifier := &EmailNotifier{From: "payments@goalhero.app", To: []string{"ops@example.com", "finance@example.com"}}
+
+	message := string(notifier.message(Notification{
+		Event:    EventReviewNeeded,
+		Severity: SeverityCritical,
+		Title:    "Review of escrow escrow_1 is overdue",
+		Text:     "⏰ *Escrow Review Overdue*\n\nEscrow ID: escrow_1",
+	}))
+
+	headers, body, found := strings.Cut(message, "\r\n\r\n")
+	require.True(t, found)
+	assert.Contains(t, headers, "To: ops@example.com, finance@example.com")
+	assert.Contains(t, headers, "Subject: [GoalHero Payments][critical] Review of escrow escrow_1 is overdue")
+	assert.Contains(t, body, "Escrow ID: escrow_1")
+	assert.NotContains(t, strings.ReplaceAll(body, "\r\n", ""), "\n", "lines end in CRLF")
+}
+
+func TestEmailNotifierConnectionFailure(t *testing.T) {
+	notifier := &EmailNotifier{Addr: "127.0.0.1:1", From: "payments@goalhero.app", To: []string{"ops@example.com"}, Timeout: time.Second}
+
+	err := notifier.Notify(context.Background(), Notification{Event: EventJobSummary})
+	assert.ErrorContains(t, err, "failed to connect to SMTP server")
+}
+
+func TestPaymentServiceNotifications(t *testing.T) {
+	notifier := &FakeNotifier{}
+	clock := NewFakeClock(time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC))
+	service := &PaymentService{notifications: notifier, clock: clock}
+
+	service.notifyReleaseSucceeded("escrow_1", 24, "automatic_release")
+	service.notifyDeadLettered(&models.EscrowTransaction{ID: "escrow_2", Amount: 12, ReleaseFailureCount: 5, LastReleaseError: "account restricted"})
+	service.notifyReviewNeeded(&models.EscrowTransaction{ID: "escrow_3", Amount: 30, ActualRating: 1.5, MinRatingRequired: 3})
+	service.NotifyAutoReleaseJobSummary(3, 2, 1, 48, time.Minute)
+
+	released := notifier.SentFor(EventReleaseSucceeded)
+	require.Len(t, released, 1)
+	assert.Equal(t, SeverityInfo, released[0].Severity)
+	assert.Equal(t, "escrow_1", released[0].Fields["escrowId"])
+
+	failed := notifier.SentFor(EventReleaseFailed)
+	require.Len(t, failed, 1)
+	assert.Equal(t, SeverityCritical, failed[0].Severity)
+	assert.Contains(t, failed[0].Text, "Dead-Letter Queue")
+
+	review := notifier.SentFor(EventReviewNeeded)
+	require.Len(t, review, 1)
+	assert.NotEmpty(t, review[0].Blocks, "review alerts keep their Slack buttons")
+
+	summaries := notifier.SentFor(EventJobSummary)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, SeverityWarning, summaries[0].Severity, "summaries with failures are warnings")
+	assert.Contains(t, summaries[0].Text, "2025-01-15 10:30:00 UTC", "summaries are stamped with the service clock")
+}
+
+func TestJobSummaryWithShortWebhookURL(t *testing.T) {
+	// Short webhook URLs used to panic when the summaries logged a prefix of the URL
+	t.Setenv("SLACK_ESCROW_WEBHOOK_URL", "http://127.0.0.1:1")
+	service := &PaymentService{}
+
+	assert.NotPanics(t, func() {
+		service.NotifyRatingJobSummary(1, 1, 0, time.Second)
+		service.NotifyDisputeJobSummary(1, 0, 0, time.Second)
+		service.NotifyAutoReleaseJobSummary(1, 1, 0, 10, time.Second)
+	})
+}
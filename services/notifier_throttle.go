@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+)
+
+// Digest modes for NOTIFY_DIGEST
+const (
+	DigestOff    = "off"
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// rateLimitWindow is the window NOTIFY_RATE_LIMITS are counted over
+const rateLimitWindow = time.Hour
+
+// digestListLimit caps the escrow IDs listed in a digest message
+const digestListLimit = 10
+
+// NotificationThrottle keeps channels readable: it drops repeats of the same event for the same
+// entity, caps deliveries per channel, skips idle job summaries and rolls release successes into a
+// periodic digest. Its state is shared by every PaymentService in the process.
+type NotificationThrottle struct {
+	DedupWindow   time.Duration  // Repeats of an event for the same Key within the window are dropped
+	QuietWhenIdle bool           // Skip job summaries of runs that did nothing
+	RateLimits    map[string]int // Channel -> deliveries per hour; critical notifications are never limited
+	DigestPeriod  time.Duration  // Roll release successes into one message per period; 0 sends them individually
+
+	mu           sync.Mutex
+	lastSent     map[string]time.Time
+	channelSends map[string][]time.Time
+	digest       []Notification
+	digestStart  time.Time
+}
+
+// newNotificationThrottle builds the throttle from the jobs config
+func newNotificationThrottle(conf *config.JobsConfig) (*NotificationThrottle, error) {
+	throttle := &NotificationThrottle{
+		DedupWindow:   conf.NotifyDedupWindow,
+		QuietWhenIdle: conf.NotifyQuietWhenIdle,
+		RateLimits:    make(map[string]int),
+	}
+
+	switch conf.NotifyDigest {
+	case "", DigestOff:
+	case DigestHourly:
+		throttle.DigestPeriod = time.Hour
+	case DigestDaily:
+		throttle.DigestPeriod = 24 * time.Hour
+	default:
+		return nil, fmt.Errorf("invalid notification digest %q, expected off, hourly or daily", conf.NotifyDigest)
+	}
+
+	for channel, value := range conf.NotifyRateLimits {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid notification rate limit %q for channel %q", value, channel)
+		}
+		throttle.RateLimits[channel] = limit
+	}
+
+	return throttle, nil
+}
+
+var (
+	sharedThrottleOnce sync.Once
+	sharedThrottle     *NotificationThrottle
+)
+
+// sharedNotificationThrottle returns the process-wide throttle, built from the jobs config on first
+// use. It is nil when the throttle settings are invalid.
+func sharedNotificationThrottle(conf *config.JobsConfig) *NotificationThrottle {
+	sharedThrottleOnce.Do(func() {
+		throttle, err := newNotificationThrottle(conf)
+		if err != nil {
+			slog.Warn("Invalid notification throttling, notifications are not throttled", "error", err)
+			return
+		}
+		sharedThrottle = throttle
+	})
+	return sharedThrottle
+}
+
+// admit reports whether a notification should be sent at all, recording it for deduplication
+func (t *NotificationThrottle) admit(notification Notification, now time.Time) bool {
+	if t.QuietWhenIdle && notification.Idle {
+		return false
+	}
+	if t.DedupWindow <= 0 || notification.Key == "" {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := notification.Event + "|" + notification.Severity + "|" + notification.Key
+	if last, ok := t.lastSent[key]; ok && now.Sub(last) < t.DedupWindow {
+		slog.Debug("Dropped duplicate notification", "event", notification.Event, "key", notification.Key)
+		return false
+	}
+	if t.lastSent == nil {
+		t.lastSent = make(map[string]time.Time)
+	}
+	for seenKey, seenAt := range t.lastSent {
+		if now.Sub(seenAt) >= t.DedupWindow {
+			delete(t.lastSent, seenKey)
+		}
+	}
+	t.lastSent[key] = now
+	return true
+}
+
+// allowChannel reports whether the channel is under its rate limit, counting the delivery if so
+func (t *NotificationThrottle) allowChannel(channel string, notification Notification, now time.Time) bool {
+	limit, limited := t.RateLimits[channel]
+	if !limited || notification.Severity == SeverityCritical {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sends := t.channelSends[channel]
+	for len(sends) > 0 && now.Sub(sends[0]) >= rateLimitWindow {
+		sends = sends[1:]
+	}
+	if len(sends) >= limit {
+		t.channelSends[channel] = sends
+		slog.Warn("Notification rate limit reached, dropped notification", "channel", channel, "limit_per_hour", limit, "event", notification.Event)
+		return false
+	}
+
+	if t.channelSends == nil {
+		t.channelSends = make(map[string][]time.Time)
+	}
+	t.channelSends[channel] = append(sends, now)
+	return true
+}
+
+// holdForDigest buffers release successes while digest mode is on, reporting whether it did
+func (t *NotificationThrottle) holdForDigest(notification Notification, now time.Time) bool {
+	if t.DigestPeriod <= 0 || notification.Event != EventReleaseSucceeded {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.digest) == 0 {
+		t.digestStart = now
+	}
+	t.digest = append(t.digest, notification)
+	return true
+}
+
+// takeDigest returns the digest of the buffered release successes once the period has passed, or
+// straight away when force is set, and empties the buffer
+func (t *NotificationThrottle) takeDigest(now time.Time, force bool) (Notification, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.digest) == 0 || (!force && now.Sub(t.digestStart) < t.DigestPeriod) {
+		return Notification{}, false
+	}
+
+	digest := releaseDigest(t.digest, t.digestStart, now)
+	t.digest = nil
+	return digest, true
+}
+
+// releaseDigest rolls release success notifications into one message with totals
+func releaseDigest(released []Notification, from, to time.Time) Notification {
+	var total float64
+	escrowIDs := make([]string, 0, len(released))
+	for _, notification := range released {
+		if amount, ok := notification.Fields["amount"].(float64); ok {
+			total += amount
+		}
+		escrowIDs = append(escrowIDs, notification.Key)
+	}
+	sort.Strings(escrowIDs)
+
+	listed := escrowIDs
+	more := ""
+	if len(listed) > digestListLimit {
+		more = fmt.Sprintf(" (+%d more)", len(listed)-digestListLimit)
+		listed = listed[:digestListLimit]
+	}
+
+	return Notification{
+		Event:    EventReleaseSucceeded,
+		Severity: SeverityInfo,
+		Title:    fmt.Sprintf("%d escrow(s) released", len(released)),
+		Text: fmt.Sprintf("📦 *Escrow Release Digest*\n\nReleased: %d escrow(s)\nTotal Amount: €%.2f\nPeriod: %s – %s\nEscrows: %s%s",
+			len(released), total, from.Format("2006-01-02 15:04"), to.Format("2006-01-02 15:04 MST"), strings.Join(listed, ", "), more),
+		Fields: map[string]interface{}{"released": len(released), "totalAmount": total, "escrowIds": escrowIDs},
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func throttledRouter(throttle *NotificationThrottle) (*NotificationRouter, *FakeNotifier, *FakeNotifier) {
+	slack, email := &FakeNotifier{}, &FakeNotifier{}
+	return &NotificationRouter{
+		Channels: map[string]Notifier{ChannelSlack: slack, ChannelEmail: email},
+		Routes:   map[string][]string{RouteDefault: {ChannelSlack}, SeverityCritical: {ChannelEmail}},
+		Throttle: throttle,
+	}, slack, email
+}
+
+func TestNotificationDeduplication(t *testing.T) {
+	router, slack, _ := throttledRouter(&NotificationThrottle{DedupWindow: time.Hour})
+	ctx := context.Background()
+	now := time.Now()
+
+	failure := Notification{Event: EventReleaseFailed, Severity: SeverityWarning, Key: "escrow_1"}
+	require.NoError(t, router.notifyAt(ctx, failure, now))
+	require.NoError(t, router.notifyAt(ctx, failure, now.Add(10*time.Minute)))
+	assert.Len(t, slack.Sent(), 1, "repeats within the window are dropped")
+
+	other := Notification{Event: EventReleaseFailed, Severity: SeverityWarning, Key: "escrow_2"}
+	require.NoError(t, router.notifyAt(ctx, other, now.Add(10*time.Minute)))
+	assert.Len(t, slack.Sent(), 2, "other escrows are not deduplicated")
+
+	require.NoError(t, router.notifyAt(ctx, failure, now.Add(61*time.Minute)))
+	assert.Len(t, slack.Sent(), 3, "the event is sent again after the window")
+
+	summary := Notification{Event: EventJobSummary, Severity: SeverityInfo}
+	require.NoError(t, router.notifyAt(ctx, summary, now))
+	require.NoError(t, router.notifyAt(ctx, summary, now))
+	assert.Len(t, slack.SentFor(EventJobSummary), 2, "notifications without a key are never deduplicated")
+}
+
+func TestNotificationRateLimit(t *testing.T) {
+	router, slack, email := throttledRouter(&NotificationThrottle{RateLimits: map[string]int{ChannelSlack: 2}})
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, router.notifyAt(ctx, Notification{Event: EventReleaseSucceeded, Severity: SeverityInfo, Key: fmt.Sprintf("escrow_%d", i)}, now))
+	}
+	assert.Len(t, slack.Sent(), 2)
+
+	require.NoError(t, router.notifyAt(ctx, Notification{Event: EventReleaseFailed, Severity: SeverityCritical, Key: "escrow_dead"}, now))
+	assert.Len(t, slack.SentFor(EventReleaseFailed), 1, "critical notifications are never rate limited")
+	assert.Len(t, email.Sent(), 1, "other channels have their own limit")
+
+	require.NoError(t, router.notifyAt(ctx, Notification{Event: EventReleaseSucceeded, Severity: SeverityInfo, Key: "escrow_later"}, now.Add(time.Hour)))
+	assert.Len(t, slack.SentFor(EventReleaseSucceeded), 3, "the limit resets as the window moves")
+}
+
+func TestQuietWhenIdle(t *testing.T) {
+	router, slack, _ := throttledRouter(&NotificationThrottle{QuietWhenIdle: true})
+	service := &PaymentService{notifications: router}
+
+	service.NotifyAutoReleaseJobSummary(0, 0, 0, 0, time.Second)
+	service.NotifyRatingJobSummary(4, 0, 0, time.Second)
+	service.NotifyDisputeJobSummary(2, 0, 0, time.Second)
+	assert.Empty(t, slack.Sent(), "idle job summaries are skipped")
+
+	service.NotifyAutoReleaseJobSummary(1, 0, 1, 0, time.Second)
+	service.NotifyRatingJobSummary(4, 3, 0, time.Second)
+	assert.Len(t, slack.SentFor(EventJobSummary), 2)
+}
+
+func TestReleaseDigest(t *testing.T) {
+	router, slack, _ := throttledRouter(&NotificationThrottle{DigestPeriod: time.Hour})
+	service := &PaymentService{notifications: router}
+	ctx := context.Background()
+	start := time.Now()
+
+	for i, amount := range []float64{10, 20.5, 4.25} {
+		require.NoError(t, router.notifyAt(ctx, Notification{
+			Event:    EventReleaseSucceeded,
+			Severity: SeverityInfo,
+			Key:      fmt.Sprintf("escrow_%d", i),
+			Fields:   map[string]interface{}{"amount": amount},
+		}, start.Add(time.Duration(i)*time.Minute)))
+	}
+	assert.Empty(t, slack.Sent(), "release successes are held for the digest")
+
+	// Failures are still sent straight away
+	service.notifyReleaseFailed("escrow_9", 15, "card_declined")
+	assert.Len(t, slack.SentFor(EventReleaseFailed), 1)
+
+	// The digest goes out with the first notification after the period
+	require.NoError(t, router.notifyAt(ctx, Notification{Event: EventJobSummary, Severity: SeverityInfo}, start.Add(61*time.Minute)))
+	digests := slack.SentFor(EventReleaseSucceeded)
+	require.Len(t, digests, 1)
+	assert.Contains(t, digests[0].Text, "*Escrow Release Digest*")
+	assert.Contains(t, digests[0].Text, "Released: 3 escrow(s)")
+	assert.Contains(t, digests[0].Text, "Total Amount: €34.75")
+	assert.Contains(t, digests[0].Text, "escrow_0, escrow_1, escrow_2")
+
+	// FlushDigest sends what is pending without waiting
+	require.NoError(t, router.notifyAt(ctx, Notification{Event: EventReleaseSucceeded, Severity: SeverityInfo, Key: "escrow_late"}, start.Add(62*time.Minute)))
+	require.NoError(t, router.FlushDigest(ctx))
+	require.NoError(t, router.FlushDigest(ctx))
+	assert.Len(t, slack.SentFor(EventReleaseSucceeded), 2)
+}
+
+func TestDueReleaseDigestSentAtEndOfJobRun(t *testing.T) {
+	router, slack, _ := throttledRouter(&NotificationThrottle{DigestPeriod: time.Hour})
+	ctx := context.Background()
+	start := time.Now()
+
+	require.NoError(t, router.notifyAt(ctx, Notification{Event: EventReleaseSucceeded, Severity: SeverityInfo, Key: "escrow_1"}, start))
+
+	// A job run ending before the period is over leaves the digest pending
+	require.NoError(t, router.sendDigest(ctx, start.Add(30*time.Minute), false))
+	assert.Empty(t, slack.Sent())
+
+	// The first run after the period sends it, with no further notification needed
+	require.NoError(t, router.sendDigest(ctx, start.Add(61*time.Minute), false))
+	require.Len(t, slack.SentFor(EventReleaseSucceeded), 1)
+
+	require.NoError(t, router.SendDueDigest(ctx))
+	assert.Len(t, slack.SentFor(EventReleaseSucceeded), 1, "a digest is sent once")
+}
+
+func TestReleaseDigestListsAtMostTenEscrows(t *testing.T) {
+	var released []Notification
+	for i := 0; i < 12; i++ {
+		released = append(released, Notification{Key: fmt.Sprintf("escrow_%02d", i), Fields: map[string]interface{}{"amount": 1.0}})
+	}
+
+	digest := releaseDigest(released, time.Now(), time.Now())
+	assert.Contains(t, digest.Text, "escrow_09 (+2 more)")
+	assert.Equal(t, 12, digest.Fields["released"])
+}
+
+func TestNewNotificationThrottle(t *testing.T) {
+	throttle, err := newNotificationThrottle(&config.JobsConfig{
+		NotifyDigest:     DigestDaily,
+		NotifyRateLimits: map[string]string{ChannelSlack: "30"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, throttle.DigestPeriod)
+	assert.Equal(t, 30, throttle.RateLimits[ChannelSlack])
+
+	_, err = newNotificationThrottle(&config.JobsConfig{NotifyDigest: "weekly"})
+	assert.Error(t, err)
+
+	_, err = newNotificationThrottle(&config.JobsConfig{NotifyRateLimits: map[string]string{ChannelSlack: "0"}})
+	assert.Error(t, err)
+
+	_, err = NewNotifier(&config.JobsConfig{NotifyRateLimits: map[string]string{ChannelEmail: "5"}}, nil)
+	assert.ErrorContains(t, err, `rate limit set for unconfigured channel "email"`)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,6 +42,7 @@ func (suite *PaymentIntegrationTestSuite) SetupSuite() {
 	os.Setenv("STRIPE_TEST_MODE", "true")
 	
 	suite.paymentService = NewPaymentService()
+	config.InitJobsConfig()
 	suite.stripeService = NewStripeConnectService()
 	
 	// Verify test mode is enabled
@@ -1,36 +1,208 @@
 package services
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"net/http"
-	"os"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/metrics"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
-	"google.golang.org/api/iterator"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrEscrowNotReleasable is returned when a release targets an escrow that was already released,
+// refunded or sent to review
+var ErrEscrowNotReleasable = errors.New("escrow cannot be released")
+
 // PaymentService handles payment business logic with Stripe Connect
 type PaymentService struct {
-	stripeService *StripeConnectService
+	stripeService      *StripeConnectService
+	retry              *RetryPolicy
+	maxReleaseFailures int // Failed auto-release attempts before an escrow is dead-lettered
+	releaseLimits      AutoReleaseLimits
+	releasePolicies    ReleasePolicies
+	reviewSLA          time.Duration       // Time a reviewer has to decide on an under_review escrow
+	paymentStore       PaymentStore        // Defaults to Firestore when nil
+	escrowStore        EscrowStore         // Defaults to Firestore when nil
+	ratingStore        RatingStore         // Defaults to Firestore when nil
+	disputeStore       DisputeStore        // Defaults to Firestore when nil
+	fundsReleaser      escrowFundsReleaser // Defaults to stripeService when nil
+	refundIssuer       paymentRefunder     // Defaults to the service itself when nil
+	notifications      Notifier            // Defaults to Slack from SLACK_ESCROW_WEBHOOK_URL when nil
+	clock              Clock               // Tells the time for holds and deadlines; defaults to the process clock when nil
+	ctx                context.Context     // Carries request and job run IDs; defaults to context.Background()
+}
+
+// AutoReleaseLimits bounds the work done by one automatic release run
+type AutoReleaseLimits struct {
+	BatchSize   int // Escrows fetched per page
+	Concurrency int // Releases processed in parallel
+	MaxPerRun   int // Escrows considered per run; the rest wait for the next run
+}
+
+// escrowFundsReleaser moves escrowed funds to the organizer, implemented by StripeConnectService
+type escrowFundsReleaser interface {
+	ReleaseEscrowFunds(escrow *models.EscrowTransaction) error
+}
+
+// paymentRefunder refunds the confirmed payment held by an escrow, implemented by PaymentService
+// via Stripe
+type paymentRefunder interface {
+	ProcessEscrowRefund(escrowID, paymentID string, amount float64, reason string) error
 }
 
-// NewPaymentService creates a new payment service
+// NewPaymentService creates a payment service for HTTP request paths, whose retries back off for
+// milliseconds so the request is never held for long
 func NewPaymentService() *PaymentService {
+	return newPaymentService(NewRequestRetryPolicy())
+}
+
+// NewJobPaymentService creates a payment service for background jobs, whose retries use the
+// configured MAX_RETRIES and RETRY_DELAY backoff
+func NewJobPaymentService() *PaymentService {
+	return newPaymentService(NewRetryPolicy())
+}
+
+func newPaymentService(retry *RetryPolicy) *PaymentService {
+	stripeService := newStripeConnectService(retry)
+	jobsConf := config.GetJobsConfig()
+
 	return &PaymentService{
-		stripeService: NewStripeConnectService(),
+		stripeService:      stripeService,
+		retry:              stripeService.retry, // Shared so attempt counts cover Stripe, Firestore and Slack
+		maxReleaseFailures: jobsConf.MaxReleaseFailures,
+		releaseLimits: AutoReleaseLimits{
+			BatchSize:   jobsConf.AutoReleaseBatchSize,
+			Concurrency: jobsConf.AutoReleaseConcurrency,
+			MaxPerRun:   jobsConf.AutoReleaseMaxPerRun,
+		},
+		releasePolicies: currentReleasePolicies(),
+		reviewSLA:       time.Duration(jobsConf.ReviewSLAHours) * time.Hour,
+		notifications:   configuredNotifier(jobsConf, stripeService.retry),
+	}
+}
+
+// WithContext returns a copy of the service whose logs, Stripe calls and Firestore calls carry the
+// correlation IDs of ctx. Cancellation is not inherited, so a client disconnecting halfway through
+// a request can't abort a release between the transfer and the Firestore update.
+func (s *PaymentService) WithContext(ctx context.Context) *PaymentService {
+	ctx = context.WithoutCancel(ctx)
+
+	scoped := *s
+	scoped.ctx = ctx
+	if s.stripeService != nil {
+		stripeService := *s.stripeService
+		stripeService.ctx = ctx
+		scoped.stripeService = &stripeService
+	}
+	return &scoped
+}
+
+// baseContext returns the context the service was scoped to with WithContext
+func (s *PaymentService) baseContext() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// now returns the current time on the service's clock
+func (s *PaymentService) now() time.Time {
+	if s.clock == nil {
+		return ProcessClock().Now()
+	}
+	return s.clock.Now()
+}
+
+// startSpan starts a span for a PaymentService operation and returns a copy of the service scoped
+// to it, so the Firestore and Stripe calls made during the operation become its children
+func (s *PaymentService) startSpan(operation string, attrs ...attribute.KeyValue) (*PaymentService, trace.Span) {
+	ctx, span := tracing.Start(s.baseContext(), "PaymentService."+operation, attrs...)
+	return s.WithContext(ctx), span
+}
+
+// payments returns the store used for payments
+func (s *PaymentService) payments() PaymentStore {
+	if s.paymentStore == nil {
+		return tracedPaymentStore{FirestorePaymentStore{}}
 	}
+	return s.paymentStore
+}
+
+// escrows returns the store used for escrow transactions
+func (s *PaymentService) escrows() EscrowStore {
+	if s.escrowStore == nil {
+		return tracedEscrowStore{FirestoreEscrowStore{}}
+	}
+	return s.escrowStore
+}
+
+// ratings returns the store used for attendance and ratings
+func (s *PaymentService) ratings() RatingStore {
+	if s.ratingStore == nil {
+		return tracedRatingStore{FirestoreRatingStore{}}
+	}
+	return s.ratingStore
+}
+
+// disputes returns the store used for escrow disputes
+func (s *PaymentService) disputes() DisputeStore {
+	if s.disputeStore == nil {
+		return tracedDisputeStore{FirestoreDisputeStore{}}
+	}
+	return s.disputeStore
+}
+
+// refunder returns what refunds payments for review decisions
+func (s *PaymentService) refunder() paymentRefunder {
+	if s.refundIssuer == nil {
+		return s
+	}
+	return s.refundIssuer
+}
+
+// releaser returns what moves escrowed funds on release
+func (s *PaymentService) releaser() escrowFundsReleaser {
+	if s.fundsReleaser == nil {
+		return s.stripeService
+	}
+	return s.fundsReleaser
+}
+
+// withDefaults fills in unset limits
+func (l AutoReleaseLimits) withDefaults() AutoReleaseLimits {
+	if l.BatchSize <= 0 {
+		l.BatchSize = 100
+	}
+	if l.Concurrency <= 0 {
+		l.Concurrency = 1
+	}
+	if l.MaxPerRun <= 0 {
+		l.MaxPerRun = 1000
+	}
+	return l
+}
+
+// RetryStats returns the attempts made per operation by this service instance
+func (s *PaymentService) RetryStats() map[string]OperationAttempts {
+	return s.retry.Stats()
 }
 
 // CreateGamePayment creates a payment for a game with escrow
-func (s *PaymentService) CreateGamePayment(userID, gameID, applicationID, organizerID string, amount float64) (*models.Payment, *PaymentResult, error) {
-	log.Printf("[PaymentService] Creating game payment: User=%s, Game=%s, Amount=€%.2f", userID, gameID, amount)
+func (s *PaymentService) CreateGamePayment(userID, gameID, applicationID, organizerID string, amount float64) (payment *models.Payment, result *PaymentResult, err error) {
+	s, span := s.startSpan("CreateGamePayment", tracing.GameID(gameID))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Creating game payment", "user_id", userID, "game_id", gameID, "amount", amount)
 
 	// Validate payment amount
 	if err := s.validatePaymentAmount(amount); err != nil {
@@ -41,7 +213,7 @@ func (s *PaymentService) CreateGamePayment(userID, gameID, applicationID, organi
 	platformFee, stripeFee, netAmount := s.stripeService.CalculateFees(amount)
 
 	// Create payment record
-	payment := &models.Payment{
+	payment = &models.Payment{
 		ID:            uuid.NewString(),
 		UserID:        userID,
 		GameID:        gameID,
@@ -53,7 +225,7 @@ func (s *PaymentService) CreateGamePayment(userID, gameID, applicationID, organi
 		Currency:      models.DefaultCurrency,
 		Status:        models.PaymentStatusPending,
 		PaymentMethod: models.PaymentMethodStripe,
-		CreatedAt:     time.Now(),
+		CreatedAt:     s.now(),
 		Metadata: map[string]interface{}{
 			"userID":        userID,
 			"gameID":        gameID,
@@ -62,8 +234,10 @@ func (s *PaymentService) CreateGamePayment(userID, gameID, applicationID, organi
 		},
 	}
 
+	span.SetAttributes(tracing.PaymentID(payment.ID))
+
 	// Create Stripe payment intent with escrow
-	result, err := s.stripeService.CreateEscrowPaymentIntent(payment, organizerID)
+	result, err = s.stripeService.CreateEscrowPaymentIntent(payment, organizerID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create payment intent: %w", err)
 	}
@@ -74,24 +248,28 @@ func (s *PaymentService) CreateGamePayment(userID, gameID, applicationID, organi
 
 	// Save payment to Firestore
 	if err := s.savePayment(payment); err != nil {
-		log.Printf("[PaymentService] Failed to save payment: %v", err)
+		slog.ErrorContext(s.baseContext(), "Failed to save payment", "payment_id", payment.ID, "game_id", gameID, "error", err)
 		// Note: In production, you'd want to cancel the Stripe payment intent here
 		return nil, nil, fmt.Errorf("failed to save payment: %w", err)
 	}
 
-	log.Printf("[PaymentService] Payment created successfully: %s", payment.ID)
+	slog.InfoContext(s.baseContext(), "Payment created", "payment_id", payment.ID, "game_id", gameID)
 	return payment, result, nil
 }
 
 // ConfirmGamePayment confirms a payment and creates escrow transaction
-func (s *PaymentService) ConfirmGamePayment(paymentID string) (*models.Payment, *models.EscrowTransaction, error) {
-	log.Printf("[PaymentService] Confirming payment: %s", paymentID)
+func (s *PaymentService) ConfirmGamePayment(paymentID string) (payment *models.Payment, escrow *models.EscrowTransaction, err error) {
+	s, span := s.startSpan("ConfirmGamePayment", tracing.PaymentID(paymentID))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Confirming payment", "payment_id", paymentID)
 
 	// Get payment from database
-	payment, err := s.getPayment(paymentID)
+	payment, err = s.getPayment(paymentID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get payment: %w", err)
 	}
+	span.SetAttributes(tracing.GameID(payment.GameID))
 
 	// Confirm with Stripe
 	result, err := s.stripeService.ConfirmPaymentIntent(payment.StripePaymentID)
@@ -100,39 +278,43 @@ func (s *PaymentService) ConfirmGamePayment(paymentID string) (*models.Payment,
 	}
 
 	// Update payment status
-	now := time.Now()
+	now := s.now()
 	payment.ConfirmedAt = &now
 
 	if result.Status == "succeeded" {
 		payment.Status = models.PaymentStatusConfirmed
 
-		// Create escrow transaction
-		escrow := &models.EscrowTransaction{
+		// Create escrow transaction under the release policy for this organizer
+		organizerID := payment.Metadata["organizerID"].(string)
+		policy := s.releasePolicies.For(organizerID)
+		escrow = &models.EscrowTransaction{
 			ID:                uuid.NewString(),
 			GameID:            payment.GameID,
-			OrganizerID:       payment.Metadata["organizerID"].(string),
+			OrganizerID:       organizerID,
 			PaymentID:         payment.ID,
 			Amount:            payment.NetAmount,
 			Status:            models.EscrowStatusHeld,
 			HeldAt:            now,
-			ReleaseEligibleAt: now.Add(time.Duration(models.EscrowHoldHours) * time.Hour),
+			ReleaseEligibleAt: now.Add(policy.HoldPeriod),
 			RatingReceived:    false,
 			RatingApproved:    false,
-			MinRatingRequired: 3.0, // Minimum rating for auto-release
+			MinRatingRequired: policy.MinRating, // Minimum rating for auto-release
+			ReleasePolicy:     &policy,
 		}
+		span.SetAttributes(tracing.EscrowID(escrow.ID))
 
 		// Save escrow transaction
 		if err := s.saveEscrowTransaction(escrow); err != nil {
-			log.Printf("[PaymentService] Failed to save escrow transaction: %v", err)
+			slog.ErrorContext(s.baseContext(), "Failed to save escrow transaction", "payment_id", payment.ID, "escrow_id", escrow.ID, "error", err)
 			return nil, nil, fmt.Errorf("failed to save escrow transaction: %w", err)
 		}
 
 		// Update payment
 		if err := s.updatePayment(payment); err != nil {
-			log.Printf("[PaymentService] Failed to update payment: %v", err)
+			slog.ErrorContext(s.baseContext(), "Failed to update payment", "payment_id", payment.ID, "error", err)
 		}
 
-		log.Printf("[PaymentService] Payment confirmed and escrow created: %s", escrow.ID)
+		slog.InfoContext(s.baseContext(), "Payment confirmed and escrow created", "payment_id", payment.ID, "escrow_id", escrow.ID, "game_id", payment.GameID)
 		return payment, escrow, nil
 	} else {
 		payment.Status = models.PaymentStatusFailed
@@ -141,52 +323,92 @@ func (s *PaymentService) ConfirmGamePayment(paymentID string) (*models.Payment,
 		}
 
 		if err := s.updatePayment(payment); err != nil {
-			log.Printf("[PaymentService] Failed to update payment: %v", err)
+			slog.ErrorContext(s.baseContext(), "Failed to update payment", "payment_id", payment.ID, "error", err)
 		}
 
-		log.Printf("[PaymentService] Payment failed: %s", payment.ID)
+		slog.WarnContext(s.baseContext(), "Payment failed", "payment_id", payment.ID, "game_id", payment.GameID)
 		return payment, nil, fmt.Errorf("payment failed: %s", payment.FailureReason)
 	}
 }
 
 // ProcessEscrowRelease processes the release of escrowed funds
-func (s *PaymentService) ProcessEscrowRelease(escrowID, releaseReason string) error {
-	log.Printf("[PaymentService] Processing escrow release: %s", escrowID)
-
-	// Get escrow transaction
-	escrow, err := s.getEscrowTransaction(escrowID)
-	if err != nil {
-		return fmt.Errorf("failed to get escrow transaction: %w", err)
+func (s *PaymentService) ProcessEscrowRelease(escrowID, releaseReason string) (err error) {
+	s, span := s.startSpan("ProcessEscrowRelease", tracing.EscrowID(escrowID), attribute.String("release_reason", releaseReason))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Processing escrow release", "escrow_id", escrowID, "reason", releaseReason)
+
+	// Claim the escrow before moving funds, so an overlapping run or a manual release that read
+	// the same escrow finds it no longer releasable
+	var previousStatus string
+	escrow, err := s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		if !slices.Contains(releaseCandidateStatuses, escrow.Status) {
+			return fmt.Errorf("%w, current status: %s", ErrEscrowNotReleasable, escrow.Status)
+		}
+		previousStatus = escrow.Status
+		escrow.Status = models.EscrowStatusReleasing
+		return nil
+	})
+	if errors.Is(err, ErrEscrowNotReleasable) {
+		return err
 	}
-
-	if escrow.Status != models.EscrowStatusHeld && escrow.Status != models.EscrowStatusApproved {
-		return fmt.Errorf("escrow cannot be released, current status: %s", escrow.Status)
+	if err != nil {
+		return fmt.Errorf("failed to claim escrow transaction: %w", err)
 	}
+	span.SetAttributes(tracing.PaymentID(escrow.PaymentID), tracing.GameID(escrow.GameID))
 
 	// Release funds via Stripe
-	if err := s.stripeService.ReleaseEscrowFunds(escrow); err != nil {
+	if err := s.releaser().ReleaseEscrowFunds(escrow); err != nil {
+		s.unclaimEscrow(escrowID, previousStatus)
 		return fmt.Errorf("failed to release funds via Stripe: %w", err)
 	}
 
 	// Update escrow status
-	now := time.Now()
-	escrow.Status = models.EscrowStatusReleased
-	escrow.ReleasedAt = &now
-	escrow.ReleaseReason = releaseReason
-
-	// Save updated escrow transaction
-	if err := s.updateEscrowTransaction(escrow); err != nil {
-		return fmt.Errorf("failed to update escrow transaction: %w", err)
+	now := s.now()
+	escrow, err = s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		escrow.Status = models.EscrowStatusReleased
+		escrow.ReleasedAt = &now
+		escrow.ReleaseReason = releaseReason
+		return nil
+	})
+	if err != nil {
+		// The escrow stays releasing, which no release picks up again, until it is resolved by hand
+		return fmt.Errorf("funds released but failed to update escrow transaction: %w", err)
 	}
 
-	log.Printf("[PaymentService] Escrow released successfully: %s", escrowID)
-	s.sendSlackSuccessNotification(escrowID, escrow.Amount, releaseReason)
+	slog.InfoContext(s.baseContext(), "Escrow released", "escrow_id", escrowID)
+	metrics.EscrowReleases.Inc()
+	metrics.EscrowReleasedAmount.Add(escrow.Amount)
+	s.notifyReleaseSucceeded(escrowID, escrow.Amount, releaseReason)
 	return nil
 }
 
 // ProcessRefund processes a payment refund
-func (s *PaymentService) ProcessRefund(paymentID string, amount float64, reason string) error {
-	log.Printf("[PaymentService] Processing refund: %s, Amount: €%.2f", paymentID, amount)
+func (s *PaymentService) ProcessRefund(paymentID string, amount float64, reason string) (err error) {
+	s, span := s.startSpan("ProcessRefund", tracing.PaymentID(paymentID), attribute.Float64("amount", amount))
+	defer func() { tracing.End(span, err) }()
+
+	return s.refundPayment(paymentID, amount, func(paymentIntentID string) error {
+		_, err := s.stripeService.CreateRefund(paymentIntentID, amount, reason)
+		return err
+	})
+}
+
+// ProcessEscrowRefund refunds the payment held by an escrow. The Stripe refund is keyed to the
+// escrow, so repeating it after a failure part way through never refunds twice.
+func (s *PaymentService) ProcessEscrowRefund(escrowID, paymentID string, amount float64, reason string) (err error) {
+	s, span := s.startSpan("ProcessEscrowRefund", tracing.EscrowID(escrowID), tracing.PaymentID(paymentID), attribute.Float64("amount", amount))
+	defer func() { tracing.End(span, err) }()
+
+	return s.refundPayment(paymentID, amount, func(paymentIntentID string) error {
+		_, err := s.stripeService.CreateEscrowRefund(escrowID, paymentIntentID, amount, reason)
+		return err
+	})
+}
+
+// refundPayment refunds a confirmed payment through createRefund and marks it refunded
+func (s *PaymentService) refundPayment(paymentID string, amount float64, createRefund func(paymentIntentID string) error) error {
+	slog.InfoContext(s.baseContext(), "Processing refund", "payment_id", paymentID, "amount", amount)
 
 	// Get payment from database
 	payment, err := s.getPayment(paymentID)
@@ -199,10 +421,11 @@ func (s *PaymentService) ProcessRefund(paymentID string, amount float64, reason
 	}
 
 	// Process refund via Stripe
-	_, err = s.stripeService.CreateRefund(payment.StripePaymentID, amount, reason)
-	if err != nil {
+	if err := createRefund(payment.StripePaymentID); err != nil {
 		return fmt.Errorf("failed to process refund via Stripe: %w", err)
 	}
+	metrics.Refunds.Inc()
+	metrics.RefundedAmount.Add(amount)
 
 	// Update payment status
 	payment.Status = models.PaymentStatusRefunded
@@ -211,107 +434,275 @@ func (s *PaymentService) ProcessRefund(paymentID string, amount float64, reason
 		return fmt.Errorf("failed to update payment: %w", err)
 	}
 
-	log.Printf("[PaymentService] Refund processed successfully: %s", paymentID)
+	slog.InfoContext(s.baseContext(), "Refund processed", "payment_id", paymentID)
 	return nil
 }
 
 // GetEligibleEscrowReleases gets escrow transactions eligible for release
 func (s *PaymentService) GetEligibleEscrowReleases() ([]*models.EscrowTransaction, error) {
-	log.Printf("[PaymentService] Getting eligible escrow releases")
-
-	firestoreClient := config.FirestoreClient()
-	if firestoreClient == nil {
-		return nil, fmt.Errorf("firestore client not available")
-	}
-
-	ctx := context.Background()
-	now := time.Now()
+	slog.DebugContext(s.baseContext(), "Getting eligible escrow releases")
 
-	// Query for escrow transactions that are eligible for release
-	query := firestoreClient.Collection("escrow_transactions").
-		Where("status", "==", models.EscrowStatusHeld).
-		Where("releaseEligibleAt", "<=", now)
-
-	iter := query.Documents(ctx)
-	defer iter.Stop()
+	ctx := s.baseContext()
+	now := s.now()
+	batchSize := s.releaseLimits.withDefaults().BatchSize
 
 	var escrows []*models.EscrowTransaction
+	var after *models.EscrowTransaction
 	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
+		page, err := s.escrows().ListEligibleForRelease(ctx, now, after, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		escrows = append(escrows, page...)
+		if len(page) < batchSize {
 			break
 		}
+		after = page[len(page)-1]
+	}
+
+	slog.InfoContext(s.baseContext(), "Found eligible escrow releases", "count", len(escrows))
+	return escrows, nil
+}
+
+// autoReleaseTally accumulates the outcome of an automatic release run across batches
+type autoReleaseTally struct {
+	mu            sync.Mutex
+	processed     int
+	failed        int
+	errors        []string
+	totalReleased float64
+}
+
+// ProcessAutomaticReleases processes all eligible escrow releases automatically
+func (s *PaymentService) ProcessAutomaticReleases() (int, int, []string, float64, error) {
+	return s.ProcessAutomaticReleasesContext(s.baseContext())
+}
+
+// ProcessAutomaticReleasesContext processes eligible escrow releases in cursor-paginated batches
+// until none are left, the per-run cap is reached or ctx is cancelled. When cancelled, the counts
+// for escrows handled so far are returned together with the context error.
+func (s *PaymentService) ProcessAutomaticReleasesContext(ctx context.Context) (processed, failed int, errs []string, totalReleased float64, err error) {
+	processed, failed, errs, totalReleased, _, err = s.ProcessAutomaticReleasesFrom(ctx, nil)
+	return processed, failed, errs, totalReleased, err
+}
+
+// ProcessAutomaticReleasesFrom is ProcessAutomaticReleasesContext starting after the given escrow
+// (nil for the start). When the run stops before the end of the eligible escrows it also returns
+// the last escrow evaluated, so a later run can resume after it; next is nil once the scan is done.
+func (s *PaymentService) ProcessAutomaticReleasesFrom(ctx context.Context, after *models.EscrowTransaction) (processed, failed int, errs []string, totalReleased float64, next *models.EscrowTransaction, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.ProcessAutomaticReleases", attribute.Bool("resumed", after != nil))
+	defer func() {
+		span.SetAttributes(attribute.Int("processed", processed), attribute.Int("failed", failed))
+		tracing.End(span, err)
+	}()
+	s = s.WithContext(ctx)
+
+	slog.InfoContext(ctx, "Processing automatic escrow releases")
+
+	limits := s.releaseLimits.withDefaults()
+	now := s.now()
+	tally := &autoReleaseTally{}
+	considered := 0
+	remaining := limits.MaxPerRun
+	complete := false
+
+	for remaining > 0 {
+		batch, err := s.escrows().ListEligibleForRelease(ctx, now, after, limits.BatchSize)
 		if err != nil {
-			return nil, fmt.Errorf("failed to iterate escrow transactions: %w", err)
+			if ctx.Err() != nil {
+				break
+			}
+			return tally.processed, tally.failed, tally.errors, tally.totalReleased, after,
+				fmt.Errorf("failed to get eligible escrow releases: %w", err)
+		}
+		if len(batch) == 0 {
+			complete = true
+			break
 		}
 
-		var escrow models.EscrowTransaction
-		if err := doc.DataTo(&escrow); err != nil {
-			log.Printf("[PaymentService] Failed to parse escrow transaction: %v", err)
-			continue
+		handled, attempted := s.processReleaseBatch(ctx, batch, limits.Concurrency, remaining, tally)
+		considered += handled
+		remaining -= attempted
+		if handled > 0 {
+			after = batch[handled-1]
+		}
+		if handled < len(batch) {
+			break
 		}
+		if len(batch) < limits.BatchSize {
+			complete = true
+			break
+		}
+	}
 
-		escrows = append(escrows, &escrow)
+	if complete {
+		after = nil
 	}
 
-	log.Printf("[PaymentService] Found %d eligible escrow releases", len(escrows))
-	return escrows, nil
+	if err := ctx.Err(); err != nil {
+		slog.WarnContext(ctx, "Auto-release interrupted", "considered", considered)
+		return tally.processed, tally.failed, tally.errors, tally.totalReleased, after, fmt.Errorf("auto-release interrupted: %w", err)
+	}
+
+	if remaining <= 0 && !complete {
+		slog.InfoContext(ctx, "Auto-release reached the per-run cap, remaining escrows wait for the next run", "max_per_run", limits.MaxPerRun)
+	}
+
+	slog.InfoContext(ctx, "Auto-release completed",
+		"processed", tally.processed, "failed", tally.failed, "evaluated", considered)
+	return tally.processed, tally.failed, tally.errors, tally.totalReleased, after, nil
 }
 
-// ProcessAutomaticReleases processes all eligible escrow releases automatically
-func (s *PaymentService) ProcessAutomaticReleases() (int, int, []string, float64, error) {
-	log.Printf("[PaymentService] Processing automatic escrow releases")
+// ProcessAutomaticReleaseOf evaluates a single escrow the way an automatic release run would and
+// releases it when eligible, leaving every other escrow alone. It returns whether the escrow was
+// released, and the release error when it failed.
+func (s *PaymentService) ProcessAutomaticReleaseOf(ctx context.Context, escrowID string) (processed, failed int, errs []string, err error) {
+	s = s.WithContext(ctx)
 
-	// Get eligible escrow transactions
-	escrows, err := s.GetEligibleEscrowReleases()
+	escrow, err := s.getEscrowTransaction(escrowID)
 	if err != nil {
-		return 0, 0, nil, 0, fmt.Errorf("failed to get eligible escrow releases: %w", err)
+		return 0, 0, nil, fmt.Errorf("failed to get escrow transaction: %w", err)
+	}
+	// The same filter as the eligible escrows listing of a run
+	if !slices.Contains(releaseCandidateStatuses, escrow.Status) || s.now().Before(escrow.ReleaseEligibleAt) {
+		return 0, 0, nil, nil
 	}
 
-	processed := 0
-	failed := 0
-	totalReleased := 0.0
-	var errors []string
+	tally := &autoReleaseTally{}
+	s.processReleaseBatch(ctx, []*models.EscrowTransaction{escrow}, 1, 1, tally)
+	return tally.processed, tally.failed, tally.errors, nil
+}
+
+// processReleaseBatch evaluates the escrows in one batch and releases the eligible ones with at
+// most concurrency in flight and at most maxReleases attempted. Escrows that are not eligible have
+// their new status stored by storeReevaluation. It returns how many escrows were evaluated, which
+// is less than the batch size when ctx is cancelled or maxReleases is reached, and how many
+// releases were attempted.
+func (s *PaymentService) processReleaseBatch(ctx context.Context, batch []*models.EscrowTransaction, concurrency, maxReleases int, tally *autoReleaseTally) (int, int) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+
+	// Bring ratings up to date first, so eligibility and the releases see the current aggregate
+	s.refreshBatchRatings(ctx, batch, concurrency)
+
+	handled := 0
+	attempted := 0
+	for _, escrow := range batch {
+		// Stop between escrows so a shutdown never interrupts a release halfway
+		if ctx.Err() != nil || attempted >= maxReleases {
+			break
+		}
+		handled++
 
-	for _, escrow := range escrows {
 		// Check if escrow meets auto-release criteria
-		if s.isEligibleForAutoRelease(escrow) {
-			err := s.ProcessEscrowRelease(escrow.ID, "automatic_release")
-			if err != nil {
-				failed++
-				errorMsg := fmt.Sprintf("Escrow %s: %v", escrow.ID, err)
-				errors = append(errors, errorMsg)
-				log.Printf("[PaymentService] Failed to auto-release escrow %s: %v", escrow.ID, err)
-				s.sendSlackFailureNotification(escrow.ID, escrow.Amount, err.Error())
-			} else {
-				processed++
-				totalReleased += escrow.Amount
-				log.Printf("[PaymentService] Auto-released escrow: %s", escrow.ID)
-				s.sendSlackSuccessNotification(escrow.ID, escrow.Amount, "automatic_release")
+		previousStatus := escrow.Status
+		previousAlert := escrow.ReviewAlertSentAt
+		if !s.isEligibleForAutoRelease(escrow) {
+			// Poorly rated escrows have gone to manual review; the rest wait in pending_rating until
+			// a rating arrives or the grace period ends. Only write when something changed, as
+			// pending escrows are re-evaluated every run
+			if escrow.Status != models.EscrowStatusUnderReview {
+				escrow.Status = models.EscrowStatusPendingRating
 			}
-		} else {
-			// Update status to pending_rating if not eligible for auto-release
-			escrow.Status = models.EscrowStatusPendingRating
-			if err := s.updateEscrowTransaction(escrow); err != nil {
-				log.Printf("[PaymentService] Failed to update escrow status: %v", err)
+			if previousStatus != escrow.Status || previousAlert != escrow.ReviewAlertSentAt {
+				s.storeReevaluation(ctx, escrow, previousStatus)
 			}
+			continue
 		}
+		attempted++
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(escrow *models.EscrowTransaction) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			err := s.ProcessEscrowRelease(escrow.ID, "automatic_release")
+			if errors.Is(err, ErrEscrowNotReleasable) {
+				// Another run or a manual release got there first. The batch copy is stale, so
+				// writing it back would undo that release.
+				slog.InfoContext(ctx, "Escrow already handled, skipping auto-release", "escrow_id", escrow.ID, "error", err)
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to auto-release escrow", "escrow_id", escrow.ID, "game_id", escrow.GameID, "error", err)
+				// Record the failure on the stored escrow rather than the batch copy, which may be
+				// older than a rating update or review decision made since the batch was listed
+				if failed, deadLettered := s.recordReleaseFailure(escrow.ID, err); deadLettered {
+					s.notifyDeadLettered(failed)
+				} else {
+					s.notifyReleaseFailed(escrow.ID, escrow.Amount, err.Error())
+				}
+
+				tally.mu.Lock()
+				tally.failed++
+				tally.errors = append(tally.errors, fmt.Sprintf("Escrow %s: %v", escrow.ID, err))
+				tally.mu.Unlock()
+				return
+			}
+
+			slog.InfoContext(ctx, "Auto-released escrow", "escrow_id", escrow.ID, "game_id", escrow.GameID)
+			s.notifyReleaseSucceeded(escrow.ID, escrow.Amount, "automatic_release")
+
+			tally.mu.Lock()
+			tally.processed++
+			tally.totalReleased += escrow.Amount
+			tally.mu.Unlock()
+		}(escrow)
 	}
+	wg.Wait()
 
-	log.Printf("[PaymentService] Auto-release completed: %d processed, %d failed out of %d eligible",
-		processed, failed, len(escrows))
-	return processed, failed, errors, totalReleased, nil
+	return handled, attempted
 }
 
-// isEligibleForAutoRelease checks if an escrow transaction is eligible for automatic release
+// errEscrowChangedSinceListed stops a re-evaluation write for an escrow whose status changed since
+// its batch was listed
+var errEscrowChangedSinceListed = errors.New("escrow changed since it was listed")
+
+// storeReevaluation writes the status and review fields set by re-evaluating an escrow that is not
+// eligible for release, as long as the stored escrow still has the status it was listed with.
+// Otherwise another run, a release or a review decision has handled it meanwhile and is kept. The
+// write goes ahead even during shutdown, as the escrow has already been evaluated.
+func (s *PaymentService) storeReevaluation(ctx context.Context, evaluated *models.EscrowTransaction, listedStatus string) {
+	s = s.WithContext(context.WithoutCancel(ctx))
+	_, err := s.updateEscrowAtomically(evaluated.ID, func(escrow *models.EscrowTransaction) error {
+		if escrow.Status != listedStatus {
+			return errEscrowChangedSinceListed
+		}
+		escrow.Status = evaluated.Status
+		escrow.ReviewStartedAt = evaluated.ReviewStartedAt
+		escrow.ReviewDueAt = evaluated.ReviewDueAt
+		escrow.ReviewAlertSentAt = evaluated.ReviewAlertSentAt
+		return nil
+	})
+	if errors.Is(err, errEscrowChangedSinceListed) {
+		slog.InfoContext(ctx, "Escrow changed since it was listed, keeping its status", "escrow_id", evaluated.ID, "listed_status", listedStatus)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update re-evaluated escrow", "escrow_id", evaluated.ID, "status", evaluated.Status, "error", err)
+	}
+}
+
+// isEligibleForAutoRelease checks if an escrow transaction is eligible for automatic release.
+// Held, pending_rating and approved escrows are evaluated on every run:
+//   - approved escrows (rating met the minimum) release as soon as the hold ends
+//   - a rating at or above the minimum releases, one below it moves the escrow to under_review
+//   - without a rating, the escrow releases once the release policy's grace period after the hold has passed
 func (s *PaymentService) isEligibleForAutoRelease(escrow *models.EscrowTransaction) bool {
 	// Must be past release eligible time
-	if time.Now().Before(escrow.ReleaseEligibleAt) {
+	if s.now().Before(escrow.ReleaseEligibleAt) {
 		return false
 	}
 
-	// Must not be disputed
-	if escrow.Status == models.EscrowStatusDisputed {
+	switch escrow.Status {
+	case models.EscrowStatusApproved:
+		return true
+	case models.EscrowStatusHeld, models.EscrowStatusPendingRating:
+	default:
+		// Disputed, released, refunded or dead-lettered escrows are never auto-released
 		return false
 	}
 
@@ -320,51 +711,93 @@ func (s *PaymentService) isEligibleForAutoRelease(escrow *models.EscrowTransacti
 		if escrow.ActualRating >= escrow.MinRatingRequired {
 			escrow.RatingApproved = true
 			return true
-		} else {
-			// Poor rating - requires manual review - this should send an alert to slack using an environment variable called SLACK_ESCROW_WEBHOOK_URL
-			s.sendSlackAlert(escrow.ID, escrow.ActualRating, escrow.MinRatingRequired)
-			return false
 		}
+
+		// Poor rating - requires manual review
+		s.startReview(escrow, s.now())
+		return false
 	}
 
-	// No rating after deadline - check business rules
-	// For now, allow auto-release if no rating after 24h grace period
-	graceDeadline := escrow.ReleaseEligibleAt.Add(24 * time.Hour)
-	if time.Now().After(graceDeadline) {
-		log.Printf("[PaymentService] Auto-releasing escrow %s due to no rating after grace period", escrow.ID)
+	// No rating - release once the grace period for ratings has passed
+	graceDeadline := escrow.ReleaseEligibleAt.Add(s.releasePolicyFor(escrow).GracePeriod)
+	if s.now().After(graceDeadline) {
+		slog.InfoContext(s.baseContext(), "Auto-releasing escrow with no rating after the grace period", "escrow_id", escrow.ID)
 		return true
 	}
 
 	return false
 }
 
-// UpdateEscrowRating updates the rating for an escrow transaction
-func (s *PaymentService) UpdateEscrowRating(escrowID string, rating float64, reviewerID string) error {
-	log.Printf("[PaymentService] Updating escrow rating: %s, Rating: %.1f", escrowID, rating)
+// UpdateEscrowRating records a rating of the organizer by reviewerID and re-aggregates all
+// attendee ratings for the game into the escrow decision. Each reviewer has one rating per game;
+// rating again replaces the earlier one.
+func (s *PaymentService) UpdateEscrowRating(escrowID string, rating float64, reviewerID string) (err error) {
+	s, span := s.startSpan("UpdateEscrowRating", tracing.EscrowID(escrowID), attribute.Float64("rating", rating))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Updating escrow rating", "escrow_id", escrowID, "rating", rating)
+
+	if rating < models.MinRating || rating > models.MaxRating {
+		return fmt.Errorf("rating must be between %.1f and %.1f", models.MinRating, models.MaxRating)
+	}
 
 	escrow, err := s.getEscrowTransaction(escrowID)
 	if err != nil {
 		return fmt.Errorf("failed to get escrow transaction: %w", err)
 	}
 
-	escrow.RatingReceived = true
-	escrow.ActualRating = rating
-	escrow.ReviewedBy = reviewerID
+	ctx := s.baseContext()
+	ratingValidation := &models.RatingValidation{
+		ID:            fmt.Sprintf("%s_%s", escrow.GameID, reviewerID),
+		GameID:        escrow.GameID,
+		RatedPlayerID: escrow.OrganizerID,
+		RaterID:       reviewerID,
+		Rating:        rating,
+		Status:        models.RatingStatusPending,
+		CreatedAt:     s.now(),
+	}
+	if err := s.ratings().SaveRating(ctx, ratingValidation); err != nil {
+		return fmt.Errorf("failed to save rating: %w", err)
+	}
 
-	// Determine if rating meets minimum threshold
-	if rating >= escrow.MinRatingRequired {
-		escrow.RatingApproved = true
-		escrow.Status = models.EscrowStatusApproved
-	} else {
-		escrow.RatingApproved = false
-		// Poor rating - keep in held status for manual review
+	game, err := s.loadGameRatings(ctx, escrow)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate ratings: %w", err)
 	}
 
-	if err := s.updateEscrowTransaction(escrow); err != nil {
+	// Apply the aggregate to the stored escrow in one atomic update, so a release or review
+	// decision made meanwhile is never overwritten with the status read above
+	escrow, err = s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		game.apply(s, escrow)
+		escrow.ReviewedBy = reviewerID
+
+		// Determine if the aggregate meets minimum threshold. Only escrows still awaiting release
+		// change status; the next auto-release run picks them up again.
+		awaitingRelease := slices.Contains(releaseCandidateStatuses, escrow.Status)
+		switch {
+		case !escrow.RatingReceived:
+			// Quorum of raters not met yet
+			escrow.RatingApproved = false
+		case escrow.ActualRating >= escrow.MinRatingRequired:
+			escrow.RatingApproved = true
+			if awaitingRelease {
+				escrow.Status = models.EscrowStatusApproved
+			}
+		default:
+			escrow.RatingApproved = false
+			// Poor rating - wait in pending_rating, the next run raises the manual review alert
+			if awaitingRelease {
+				escrow.Status = models.EscrowStatusPendingRating
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update escrow transaction: %w", err)
 	}
 
-	log.Printf("[PaymentService] Escrow rating updated: %s (Approved: %v)", escrowID, escrow.RatingApproved)
+	slog.InfoContext(s.baseContext(), "Escrow rating updated", "escrow_id", escrowID,
+		"aggregate", escrow.ActualRating, "raters", escrow.RatingCount, "approved", escrow.RatingApproved)
 	return nil
 }
 
@@ -383,169 +816,138 @@ func (s *PaymentService) validatePaymentAmount(amount float64) error {
 
 // Database operations
 func (s *PaymentService) savePayment(payment *models.Payment) error {
-	firestoreClient := config.FirestoreClient()
-	if firestoreClient == nil {
-		return fmt.Errorf("firestore client not available")
-	}
-
-	ctx := context.Background()
-	_, err := firestoreClient.Collection("payments").Doc(payment.ID).Set(ctx, payment)
-	return err
+	ctx := s.baseContext()
+	return s.retry.Do(ctx, "firestore.save_payment", func() error {
+		return s.payments().Put(ctx, payment)
+	})
 }
 
 func (s *PaymentService) updatePayment(payment *models.Payment) error {
-	firestoreClient := config.FirestoreClient()
-	if firestoreClient == nil {
-		return fmt.Errorf("firestore client not available")
-	}
-
-	ctx := context.Background()
-	_, err := firestoreClient.Collection("payments").Doc(payment.ID).Set(ctx, payment)
-	return err
+	ctx := s.baseContext()
+	return s.retry.Do(ctx, "firestore.update_payment", func() error {
+		return s.payments().Put(ctx, payment)
+	})
 }
 
 func (s *PaymentService) getPayment(paymentID string) (*models.Payment, error) {
-	firestoreClient := config.FirestoreClient()
-	if firestoreClient == nil {
-		return nil, fmt.Errorf("firestore client not available")
-	}
-
-	ctx := context.Background()
-	doc, err := firestoreClient.Collection("payments").Doc(paymentID).Get(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	var payment models.Payment
-	if err := doc.DataTo(&payment); err != nil {
-		return nil, err
-	}
-
-	return &payment, nil
+	return s.payments().Get(s.baseContext(), paymentID)
 }
 
 func (s *PaymentService) saveEscrowTransaction(escrow *models.EscrowTransaction) error {
-	firestoreClient := config.FirestoreClient()
-	if firestoreClient == nil {
-		return fmt.Errorf("firestore client not available")
-	}
-
-	ctx := context.Background()
-	_, err := firestoreClient.Collection("escrow_transactions").Doc(escrow.ID).Set(ctx, escrow)
-	return err
+	ctx := s.baseContext()
+	return s.retry.Do(ctx, "firestore.save_escrow", func() error {
+		return s.escrows().Put(ctx, escrow)
+	})
 }
 
 func (s *PaymentService) updateEscrowTransaction(escrow *models.EscrowTransaction) error {
-	firestoreClient := config.FirestoreClient()
-	if firestoreClient == nil {
-		return fmt.Errorf("firestore client not available")
-	}
-
-	ctx := context.Background()
-	_, err := firestoreClient.Collection("escrow_transactions").Doc(escrow.ID).Set(ctx, escrow)
-	return err
+	ctx := s.baseContext()
+	return s.retry.Do(ctx, "firestore.update_escrow", func() error {
+		return s.escrows().Put(ctx, escrow)
+	})
 }
 
-func (s *PaymentService) getEscrowTransaction(escrowID string) (*models.EscrowTransaction, error) {
-	firestoreClient := config.FirestoreClient()
-	if firestoreClient == nil {
-		return nil, fmt.Errorf("firestore client not available")
-	}
+// updateEscrowAtomically applies fn to the stored escrow and writes it back with no other write in
+// between. Errors returned by fn are passed through and nothing is written.
+func (s *PaymentService) updateEscrowAtomically(escrowID string, fn func(escrow *models.EscrowTransaction) error) (*models.EscrowTransaction, error) {
+	ctx := s.baseContext()
+	var escrow *models.EscrowTransaction
+	err := s.retry.Do(ctx, "firestore.update_escrow", func() (err error) {
+		escrow, err = s.escrows().Update(ctx, escrowID, fn)
+		return err
+	})
+	return escrow, err
+}
 
-	ctx := context.Background()
-	doc, err := firestoreClient.Collection("escrow_transactions").Doc(escrowID).Get(ctx)
+// unclaimEscrow hands an escrow claimed by a failed release back to the status it had before, so
+// it can be released again
+func (s *PaymentService) unclaimEscrow(escrowID, status string) {
+	_, err := s.updateEscrowAtomically(escrowID, func(escrow *models.EscrowTransaction) error {
+		if escrow.Status == models.EscrowStatusReleasing {
+			escrow.Status = status
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
-	}
-
-	var escrow models.EscrowTransaction
-	if err := doc.DataTo(&escrow); err != nil {
-		return nil, err
+		slog.ErrorContext(s.baseContext(), "Failed to hand back escrow after a failed release", "escrow_id", escrowID, "status", models.EscrowStatusReleasing, "error", err)
 	}
+}
 
-	return &escrow, nil
+func (s *PaymentService) getEscrowTransaction(escrowID string) (*models.EscrowTransaction, error) {
+	return s.escrows().Get(s.baseContext(), escrowID)
 }
 
 // SlackMessage represents a Slack webhook message
 type SlackMessage struct {
-	Text string `json:"text"`
+	Text            string       `json:"text"`                       // Fallback for notifications when Blocks are set
+	Blocks          []SlackBlock `json:"blocks,omitempty"`           // Block Kit layout
+	ReplaceOriginal bool         `json:"replace_original,omitempty"` // Interaction responses only
+	ResponseType    string       `json:"response_type,omitempty"`    // Interaction responses only: ephemeral, in_channel
 }
 
-// sendSlackAlert sends an alert to Slack for manual review
-func (s *PaymentService) sendSlackAlert(escrowID string, rating float64, minRating float64) {
-	webhookURL := os.Getenv("SLACK_ESCROW_WEBHOOK_URL")
-	if webhookURL == "" {
-		log.Printf("[PaymentService] SLACK_ESCROW_WEBHOOK_URL not configured, skipping Slack alert")
-		return
-	}
+// pendingNotifications counts notifications that are still being delivered
+var pendingNotifications atomic.Int64
 
-	message := SlackMessage{
-		Text: fmt.Sprintf("🚨 *Escrow Manual Review Required*\n\nEscrow ID: %s\nActual Rating: %.1f\nMinimum Required: %.1f\n\nThis escrow requires manual review due to poor rating.",
-			escrowID, rating, minRating),
+// sendDueDigest sends the release digest if its period has ended, for the end of each job run
+func sendDueDigest(ctx context.Context) {
+	if router, ok := configuredNotifier(config.GetJobsConfig(), nil).(*NotificationRouter); ok {
+		if err := router.SendDueDigest(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to send release digest", "error", err)
+		}
 	}
+}
 
-	jsonData, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("[PaymentService] Failed to marshal Slack message: %v", err)
-		return
+// FlushNotifications sends the pending release digest, then waits for in-flight notifications to
+// be delivered or for ctx to expire
+func FlushNotifications(ctx context.Context) error {
+	if router, ok := configuredNotifier(config.GetJobsConfig(), nil).(*NotificationRouter); ok {
+		if err := router.FlushDigest(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to send release digest", "error", err)
+		}
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		log.Printf("[PaymentService] Failed to send Slack alert: %v", err)
-		return
-	}
-	defer resp.Body.Close()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("[PaymentService] Slack alert failed with status: %d", resp.StatusCode)
-		return
+	for pendingNotifications.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out flushing %d notification(s): %w", pendingNotifications.Load(), ctx.Err())
+		case <-ticker.C:
+		}
 	}
-
-	log.Printf("[PaymentService] Slack alert sent for escrow %s", escrowID)
+	return nil
 }
 
-// sendSlackSuccessNotification sends a success notification to Slack for processed escrow payments
-func (s *PaymentService) sendSlackSuccessNotification(escrowID string, amount float64, releaseReason string) {
-	webhookURL := os.Getenv("SLACK_ESCROW_WEBHOOK_URL")
-	if webhookURL == "" {
-		return
-	}
-
-	message := SlackMessage{
+// notifyReleaseSucceeded reports an escrow released to the organizer
+func (s *PaymentService) notifyReleaseSucceeded(escrowID string, amount float64, releaseReason string) {
+	s.notify(Notification{
+		Event:    EventReleaseSucceeded,
+		Severity: SeverityInfo,
+		Key:      escrowID,
+		Title:    fmt.Sprintf("Escrow %s released", escrowID),
 		Text: fmt.Sprintf("✅ *Escrow Payment Processed Successfully*\n\nEscrow ID: %s\nAmount: €%.2f\nReason: %s\nStatus: Released",
 			escrowID, amount, releaseReason),
-	}
-
-	s.sendSlackMessage(message, webhookURL)
+		Fields: map[string]interface{}{"escrowId": escrowID, "amount": amount, "releaseReason": releaseReason},
+	})
 }
 
-// sendSlackFailureNotification sends a failure notification to Slack for failed escrow payments
-func (s *PaymentService) sendSlackFailureNotification(escrowID string, amount float64, errorMsg string) {
-	webhookURL := os.Getenv("SLACK_ESCROW_WEBHOOK_URL")
-	if webhookURL == "" {
-		return
-	}
-
-	message := SlackMessage{
+// notifyReleaseFailed reports a failed escrow release attempt
+func (s *PaymentService) notifyReleaseFailed(escrowID string, amount float64, errorMsg string) {
+	s.notify(Notification{
+		Event:    EventReleaseFailed,
+		Severity: SeverityWarning,
+		Key:      escrowID,
+		Title:    fmt.Sprintf("Escrow %s release failed", escrowID),
 		Text: fmt.Sprintf("❌ *Escrow Payment Processing Failed*\n\nEscrow ID: %s\nAmount: €%.2f\nError: %s\nStatus: Failed",
 			escrowID, amount, errorMsg),
-	}
-
-	s.sendSlackMessage(message, webhookURL)
+		Fields: map[string]interface{}{"escrowId": escrowID, "amount": amount, "error": errorMsg},
+	})
 }
 
-// SendSlackRatingJobNotification sends a summary notification for rating reminder job execution
-func (s *PaymentService) SendSlackRatingJobNotification(matchesChecked, remindersSent, errors int, runtime time.Duration) {
-	log.Printf("[PaymentService] Sending rating job notification: matchesChecked=%d, remindersSent=%d, errors=%d", matchesChecked, remindersSent, errors)
-	
-	webhookURL := os.Getenv("SLACK_ESCROW_WEBHOOK_URL")
-	if webhookURL == "" {
-		log.Printf("[PaymentService] SLACK_ESCROW_WEBHOOK_URL not configured, skipping rating job notification")
-		return
-	}
-	
-	log.Printf("[PaymentService] Using Slack webhook for rating job: %s...%s", webhookURL[:20], webhookURL[len(webhookURL)-10:])
+// NotifyRatingJobSummary reports a rating reminder job run
+func (s *PaymentService) NotifyRatingJobSummary(matchesChecked, remindersSent, errors int, runtime time.Duration) {
+	slog.InfoContext(s.baseContext(), "Sending rating job notification", "matches_checked", matchesChecked, "reminders_sent", remindersSent, "errors", errors)
 
 	var statusIcon, statusText string
 	if errors > 0 {
@@ -559,27 +961,20 @@ func (s *PaymentService) SendSlackRatingJobNotification(matchesChecked, reminder
 		statusText = "No Reminders to Send"
 	}
 
-	message := SlackMessage{
+	s.notify(Notification{
+		Event:    EventJobSummary,
+		Severity: jobSummarySeverity(errors),
+		Idle:     remindersSent == 0 && errors == 0,
+		Title:    "Rating Reminder Job " + statusText,
 		Text: fmt.Sprintf("%s *Rating Reminder Job %s*\n\n📝 *Reminder Summary:*\n```\nMatches Checked:         %d\nReminders Sent:          %d\nErrors:                  %d\n```\n\n⏱️ *Runtime:* %v  |  📅 *Completed:* %s",
-			statusIcon, statusText, matchesChecked, remindersSent, errors, runtime.Round(time.Second), time.Now().Format("2006-01-02 15:04:05 MST")),
-	}
-
-	log.Printf("[PaymentService] 📤 Sending rating job summary to Slack: %s", statusText)
-	s.sendSlackMessage(message, webhookURL)
-	log.Printf("[PaymentService] ✅ Rating job Slack notification sent successfully!")
+			statusIcon, statusText, matchesChecked, remindersSent, errors, runtime.Round(time.Second), s.now().Format("2006-01-02 15:04:05 MST")),
+		Fields: map[string]interface{}{"job": "rating_reminder", "matchesChecked": matchesChecked, "remindersSent": remindersSent, "errors": errors, "runtimeSeconds": runtime.Seconds()},
+	})
 }
 
-// SendSlackDisputeJobNotification sends a summary notification for dispute escalation job execution
-func (s *PaymentService) SendSlackDisputeJobNotification(disputesChecked, escalated, errors int, runtime time.Duration) {
-	log.Printf("[PaymentService] Sending dispute job notification: disputesChecked=%d, escalated=%d, errors=%d", disputesChecked, escalated, errors)
-	
-	webhookURL := os.Getenv("SLACK_ESCROW_WEBHOOK_URL")
-	if webhookURL == "" {
-		log.Printf("[PaymentService] SLACK_ESCROW_WEBHOOK_URL not configured, skipping dispute job notification")
-		return
-	}
-	
-	log.Printf("[PaymentService] Using Slack webhook for dispute job: %s...%s", webhookURL[:20], webhookURL[len(webhookURL)-10:])
+// NotifyDisputeJobSummary reports a dispute escalation job run
+func (s *PaymentService) NotifyDisputeJobSummary(disputesChecked, escalated, errors int, runtime time.Duration) {
+	slog.InfoContext(s.baseContext(), "Sending dispute job notification", "disputes_checked", disputesChecked, "escalated", escalated, "errors", errors)
 
 	var statusIcon, statusText string
 	if errors > 0 {
@@ -593,27 +988,20 @@ func (s *PaymentService) SendSlackDisputeJobNotification(disputesChecked, escala
 		statusText = "No Disputes to Escalate"
 	}
 
-	message := SlackMessage{
+	s.notify(Notification{
+		Event:    EventJobSummary,
+		Severity: jobSummarySeverity(errors),
+		Idle:     escalated == 0 && errors == 0,
+		Title:    "Dispute Escalation Job " + statusText,
 		Text: fmt.Sprintf("%s *Dispute Escalation Job %s*\n\n⚖️ *Dispute Summary:*\n```\nDisputes Checked:        %d\nEscalated:               %d\nErrors:                  %d\n```\n\n⏱️ *Runtime:* %v  |  📅 *Completed:* %s",
-			statusIcon, statusText, disputesChecked, escalated, errors, runtime.Round(time.Second), time.Now().Format("2006-01-02 15:04:05 MST")),
-	}
-
-	log.Printf("[PaymentService] 📤 Sending dispute job summary to Slack: %s", statusText)
-	s.sendSlackMessage(message, webhookURL)
-	log.Printf("[PaymentService] ✅ Dispute job Slack notification sent successfully!")
+			statusIcon, statusText, disputesChecked, escalated, errors, runtime.Round(time.Second), s.now().Format("2006-01-02 15:04:05 MST")),
+		Fields: map[string]interface{}{"job": "dispute_escalation", "disputesChecked": disputesChecked, "escalated": escalated, "errors": errors, "runtimeSeconds": runtime.Seconds()},
+	})
 }
 
-// SendSlackJobSummaryNotification sends a summary notification for payment job execution
-func (s *PaymentService) SendSlackJobSummaryNotification(validated, processed, failed int, totalReleased float64, runtime time.Duration) {
-	log.Printf("[PaymentService] Sending job summary notification: validated=%d, processed=%d, failed=%d, totalReleased=€%.2f", validated, processed, failed, totalReleased)
-	
-	webhookURL := os.Getenv("SLACK_ESCROW_WEBHOOK_URL")
-	if webhookURL == "" {
-		log.Printf("[PaymentService] SLACK_ESCROW_WEBHOOK_URL not configured, skipping job summary notification")
-		return
-	}
-	
-	log.Printf("[PaymentService] Using Slack webhook: %s...%s", webhookURL[:20], webhookURL[len(webhookURL)-10:])
+// NotifyAutoReleaseJobSummary reports an automatic release job run
+func (s *PaymentService) NotifyAutoReleaseJobSummary(validated, processed, failed int, totalReleased float64, runtime time.Duration) {
+	slog.InfoContext(s.baseContext(), "Sending auto-release job notification", "validated", validated, "processed", processed, "failed", failed, "total_released", totalReleased)
 
 	var statusIcon, statusText string
 	if failed > 0 {
@@ -634,42 +1022,21 @@ func (s *PaymentService) SendSlackJobSummaryNotification(validated, processed, f
 		releaseText = "\n💰 *Money Released:* No payments released"
 	}
 
-	message := SlackMessage{
+	s.notify(Notification{
+		Event:    EventJobSummary,
+		Severity: jobSummarySeverity(failed),
+		Idle:     processed == 0 && failed == 0,
+		Title:    "Payment Processing Job " + statusText,
 		Text: fmt.Sprintf("%s *Payment Processing Job %s*\n\n📊 *Validation Summary:*\n```\nPayments Validated:      %d\nSuccessfully Processed:  %d\nFailed:                  %d\n```%s\n\n⏱️ *Runtime:* %v  |  📅 *Completed:* %s",
-			statusIcon, statusText, validated, processed, failed, releaseText, runtime.Round(time.Second), time.Now().Format("2006-01-02 15:04:05 MST")),
-	}
-
-	log.Printf("[PaymentService] 📤 Sending job summary to Slack: %s", statusText)
-	s.sendSlackMessage(message, webhookURL)
-	log.Printf("[PaymentService] ✅ Job summary Slack notification sent successfully!")
+			statusIcon, statusText, validated, processed, failed, releaseText, runtime.Round(time.Second), s.now().Format("2006-01-02 15:04:05 MST")),
+		Fields: map[string]interface{}{"job": "auto_release", "validated": validated, "processed": processed, "failed": failed, "totalReleased": totalReleased, "runtimeSeconds": runtime.Seconds()},
+	})
 }
 
-// sendSlackMessage sends a message to Slack webhook
-func (s *PaymentService) sendSlackMessage(message SlackMessage, webhookURL string) {
-	log.Printf("[PaymentService] 🌐 Posting to Slack webhook...")
-	
-	jsonData, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("[PaymentService] ❌ Failed to marshal Slack message: %v", err)
-		return
-	}
-
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		log.Printf("[PaymentService] ❌ Failed to send Slack message (network error): %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("[PaymentService] ❌ Slack message failed with HTTP status: %d", resp.StatusCode)
-		// Try to read response body for more details
-		body := make([]byte, 512)
-		if n, err := resp.Body.Read(body); err == nil && n > 0 {
-			log.Printf("[PaymentService] Slack error response: %s", string(body[:n]))
-		}
-		return
+// jobSummarySeverity raises job summaries with errors to warnings
+func jobSummarySeverity(errors int) string {
+	if errors > 0 {
+		return SeverityWarning
 	}
-
-	log.Printf("[PaymentService] ✅ Slack message delivered successfully (HTTP %d)", resp.StatusCode)
+	return SeverityInfo
 }
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// PaymentStore persists player payments
+type PaymentStore interface {
+	Get(ctx context.Context, paymentID string) (*models.Payment, error)
+	Put(ctx context.Context, payment *models.Payment) error
+}
+
+// FirestorePaymentStore stores payments in the payments collection
+type FirestorePaymentStore struct{}
+
+func (FirestorePaymentStore) client() (*firestore.Client, error) {
+	firestoreClient := config.FirestoreClient()
+	if firestoreClient == nil {
+		return nil, fmt.Errorf("firestore client not available")
+	}
+	return firestoreClient, nil
+}
+
+func (fs FirestorePaymentStore) Get(ctx context.Context, paymentID string) (*models.Payment, error) {
+	client, err := fs.client()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := client.Collection("payments").Doc(paymentID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment models.Payment
+	if err := doc.DataTo(&payment); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (fs FirestorePaymentStore) Put(ctx context.Context, payment *models.Payment) error {
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Collection("payments").Doc(payment.ID).Set(ctx, payment)
+	return err
+}
+
+// MemoryPaymentStore keeps payments in memory, for tests
+type MemoryPaymentStore struct {
+	mu       sync.RWMutex
+	payments map[string]models.Payment
+}
+
+// NewMemoryPaymentStore creates an empty in-memory payment store
+func NewMemoryPaymentStore() *MemoryPaymentStore {
+	return &MemoryPaymentStore{payments: make(map[string]models.Payment)}
+}
+
+func (ms *MemoryPaymentStore) Get(ctx context.Context, paymentID string) (*models.Payment, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	payment, exists := ms.payments[paymentID]
+	if !exists {
+		return nil, fmt.Errorf("payment not found: %s", paymentID)
+	}
+	return &payment, nil
+}
+
+func (ms *MemoryPaymentStore) Put(ctx context.Context, payment *models.Payment) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.payments[payment.ID] = *payment
+	return nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// countedRatings keeps the latest rating per attendee, ignoring the organizer rating themselves,
+// raters who were not present and out-of-range values. The result is ordered by rater ID.
+func countedRatings(ratings []*models.RatingValidation, organizerID string, playersPresent []string) []models.EscrowRaterRating {
+	latest := make(map[string]*models.RatingValidation)
+	for _, rating := range ratings {
+		if rating.RaterID == organizerID || !slices.Contains(playersPresent, rating.RaterID) {
+			continue
+		}
+		if rating.Rating < models.MinRating || rating.Rating > models.MaxRating {
+			continue
+		}
+		if existing, exists := latest[rating.RaterID]; !exists || rating.CreatedAt.After(existing.CreatedAt) {
+			latest[rating.RaterID] = rating
+		}
+	}
+
+	breakdown := make([]models.EscrowRaterRating, 0, len(latest))
+	for raterID, rating := range latest {
+		breakdown = append(breakdown, models.EscrowRaterRating{
+			RaterID:   raterID,
+			RatingID:  rating.ID,
+			Rating:    rating.Rating,
+			CreatedAt: rating.CreatedAt,
+		})
+	}
+	sort.Slice(breakdown, func(i, j int) bool { return breakdown[i].RaterID < breakdown[j].RaterID })
+	return breakdown
+}
+
+// aggregateRatings combines the counted ratings with the given method
+func aggregateRatings(breakdown []models.EscrowRaterRating, method string) float64 {
+	if len(breakdown) == 0 {
+		return 0
+	}
+
+	values := make([]float64, len(breakdown))
+	for i, rating := range breakdown {
+		values[i] = rating.Rating
+	}
+	sort.Float64s(values)
+
+	switch method {
+	case models.RatingAggregationMedian:
+		mid := len(values) / 2
+		if len(values)%2 == 0 {
+			return (values[mid-1] + values[mid]) / 2
+		}
+		return values[mid]
+	case models.RatingAggregationMin:
+		return values[0]
+	default:
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		return sum / float64(len(values))
+	}
+}
+
+// applyRatings stores the aggregate of the attendee ratings, combined as the release policy says,
+// on the escrow and reports whether anything changed. The escrow only counts as rated once the quorum of raters is met; with no
+// counted ratings at all the escrow is left untouched.
+func applyRatings(escrow *models.EscrowTransaction, ratings []*models.RatingValidation, playersPresent []string, policy models.ReleasePolicy) bool {
+	policy = policy.WithDefaults()
+
+	breakdown := countedRatings(ratings, escrow.OrganizerID, playersPresent)
+	if len(breakdown) == 0 {
+		return false
+	}
+
+	before := ratingFields(escrow)
+
+	escrow.RaterRatings = breakdown
+	escrow.RatingCount = len(breakdown)
+	escrow.RatingAggregation = policy.RatingAggregation
+	escrow.ActualRating = aggregateRatings(breakdown, policy.RatingAggregation)
+	escrow.RatingReceived = len(breakdown) >= policy.MinRaters
+
+	return !reflect.DeepEqual(before, ratingFields(escrow))
+}
+
+// ratingFields captures the rating state of an escrow for change detection
+func ratingFields(escrow *models.EscrowTransaction) []interface{} {
+	return []interface{}{escrow.RaterRatings, escrow.RatingCount, escrow.RatingAggregation, escrow.ActualRating, escrow.RatingReceived}
+}
+
+// gameRatings is what the ratings of an escrow's game are aggregated from
+type gameRatings struct {
+	ratings        []*models.RatingValidation
+	playersPresent []string
+}
+
+// loadGameRatings reads the attendee ratings and attendance for the escrow's game
+func (s *PaymentService) loadGameRatings(ctx context.Context, escrow *models.EscrowTransaction) (*gameRatings, error) {
+	playersPresent, err := s.ratings().PlayersPresent(ctx, escrow.GameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get players present for game %s: %w", escrow.GameID, err)
+	}
+
+	ratings, err := s.ratings().ListGameRatings(ctx, escrow.GameID, escrow.OrganizerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ratings for game %s: %w", escrow.GameID, err)
+	}
+
+	return &gameRatings{ratings: ratings, playersPresent: playersPresent}, nil
+}
+
+// apply stores the aggregate on the escrow as applyRatings does
+func (g *gameRatings) apply(s *PaymentService, escrow *models.EscrowTransaction) bool {
+	return applyRatings(escrow, g.ratings, g.playersPresent, s.releasePolicyFor(escrow))
+}
+
+// refreshBatchRatings re-aggregates ratings for the escrows still waiting on a rating decision, so
+// eligibility and the releases see the current aggregate. Escrows whose rating changed have only
+// their rating fields written back, atomically, so a status set by another run, a release or a
+// review decision since the batch was listed is kept. Escrows that could not be refreshed keep
+// their stored rating.
+func (s *PaymentService) refreshBatchRatings(ctx context.Context, batch []*models.EscrowTransaction, concurrency int) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+
+	for _, escrow := range batch {
+		if escrow.Status != models.EscrowStatusHeld && escrow.Status != models.EscrowStatusPendingRating {
+			continue
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(escrow *models.EscrowTransaction) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			game, err := s.loadGameRatings(ctx, escrow)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to refresh escrow ratings", "escrow_id", escrow.ID, "error", err)
+				return
+			}
+			if !game.apply(s, escrow) {
+				return
+			}
+
+			// The write goes ahead even during shutdown, as the aggregate is already computed
+			s := s.WithContext(context.WithoutCancel(ctx))
+			if _, err := s.updateEscrowAtomically(escrow.ID, func(stored *models.EscrowTransaction) error {
+				game.apply(s, stored)
+				return nil
+			}); err != nil {
+				slog.ErrorContext(ctx, "Failed to store refreshed escrow ratings", "escrow_id", escrow.ID, "error", err)
+			}
+		}(escrow)
+	}
+	wg.Wait()
+}
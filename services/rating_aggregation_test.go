@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func raterRatings(values ...float64) []models.EscrowRaterRating {
+	breakdown := make([]models.EscrowRaterRating, len(values))
+	for i, value := range values {
+		breakdown[i] = models.EscrowRaterRating{RaterID: string(rune('a' + i)), Rating: value}
+	}
+	return breakdown
+}
+
+func TestAggregateRatings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		ratings  []models.EscrowRaterRating
+		expected float64
+	}{
+		{"mean", models.RatingAggregationMean, raterRatings(5, 4, 1), 10.0 / 3},
+		{"median_odd", models.RatingAggregationMedian, raterRatings(5, 1, 4), 4},
+		{"median_even", models.RatingAggregationMedian, raterRatings(5, 1, 4, 2), 3},
+		{"min", models.RatingAggregationMin, raterRatings(5, 4, 2.5), 2.5},
+		{"no_ratings", models.RatingAggregationMean, nil, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, aggregateRatings(tc.ratings, tc.method), 0.0001)
+		})
+	}
+}
+
+func TestApplyRatings(t *testing.T) {
+	now := time.Now()
+	present := []string{"organizer_1", "player_1", "player_2", "player_3"}
+
+	newEscrow := func() *models.EscrowTransaction {
+		return &models.EscrowTransaction{ID: "escrow_1", GameID: "game_1", OrganizerID: "organizer_1"}
+	}
+	rating := func(raterID string, value float64, createdAt time.Time) *models.RatingValidation {
+		return &models.RatingValidation{
+			ID:            "game_1_" + raterID,
+			GameID:        "game_1",
+			RatedPlayerID: "organizer_1",
+			RaterID:       raterID,
+			Rating:        value,
+			CreatedAt:     createdAt,
+		}
+	}
+
+	t.Run("should ignore absent raters, the organizer and out-of-range ratings", func(t *testing.T) {
+		escrow := newEscrow()
+		ratings := []*models.RatingValidation{
+			rating("player_1", 4, now),
+			rating("stranger", 1, now),    // Not present
+			rating("organizer_1", 5, now), // Rating themselves
+			rating("player_2", 9, now),    // Out of range
+		}
+
+		changed := applyRatings(escrow, ratings, present, models.ReleasePolicy{RatingAggregation: models.RatingAggregationMean})
+
+		assert.True(t, changed)
+		assert.True(t, escrow.RatingReceived)
+		assert.Equal(t, 1, escrow.RatingCount)
+		assert.Equal(t, 4.0, escrow.ActualRating)
+		require.Len(t, escrow.RaterRatings, 1)
+		assert.Equal(t, "player_1", escrow.RaterRatings[0].RaterID)
+	})
+
+	t.Run("should keep only the latest rating per rater", func(t *testing.T) {
+		escrow := newEscrow()
+		ratings := []*models.RatingValidation{
+			rating("player_1", 1, now.Add(-1*time.Hour)),
+			rating("player_1", 5, now),
+			rating("player_2", 3, now),
+		}
+
+		applyRatings(escrow, ratings, present, models.ReleasePolicy{RatingAggregation: models.RatingAggregationMin})
+
+		assert.Equal(t, 2, escrow.RatingCount)
+		assert.Equal(t, 3.0, escrow.ActualRating)
+		assert.Equal(t, models.RatingAggregationMin, escrow.RatingAggregation)
+	})
+
+	t.Run("should not count as rated until the quorum is met", func(t *testing.T) {
+		escrow := newEscrow()
+		ratings := []*models.RatingValidation{rating("player_1", 5, now), rating("player_2", 4, now)}
+
+		applyRatings(escrow, ratings, present, models.ReleasePolicy{RatingAggregation: models.RatingAggregationMean, MinRaters: 3})
+
+		assert.False(t, escrow.RatingReceived)
+		assert.Equal(t, 2, escrow.RatingCount)
+		assert.Len(t, escrow.RaterRatings, 2)
+	})
+
+	t.Run("should leave the escrow untouched without counted ratings", func(t *testing.T) {
+		escrow := newEscrow()
+		escrow.RatingReceived = true
+		escrow.ActualRating = 4.5
+
+		changed := applyRatings(escrow, []*models.RatingValidation{rating("stranger", 1, now)}, present, models.ReleasePolicy{})
+
+		assert.False(t, changed)
+		assert.True(t, escrow.RatingReceived)
+		assert.Equal(t, 4.5, escrow.ActualRating)
+	})
+
+	t.Run("should report no change when ratings are unchanged", func(t *testing.T) {
+		escrow := newEscrow()
+		ratings := []*models.RatingValidation{rating("player_1", 4, now)}
+
+		assert.True(t, applyRatings(escrow, ratings, present, models.ReleasePolicy{}))
+		assert.False(t, applyRatings(escrow, ratings, present, models.ReleasePolicy{}))
+	})
+}
+
+func TestUpdateEscrowRatingAggregation(t *testing.T) {
+	escrowStore := NewMemoryEscrowStore(&models.EscrowTransaction{
+		ID:                "escrow_1",
+		GameID:            "game_1",
+		OrganizerID:       "organizer_1",
+		Status:            models.EscrowStatusHeld,
+		MinRatingRequired: 3.0,
+	})
+	ratingStore := NewMemoryRatingStore()
+	ratingStore.SetPlayersPresent("game_1", "organizer_1", "player_1", "player_2", "player_3")
+
+	service := &PaymentService{
+		escrowStore:     escrowStore,
+		ratingStore:     ratingStore,
+		releasePolicies: ReleasePolicies{Default: models.ReleasePolicy{RatingAggregation: models.RatingAggregationMin, MinRaters: 2}},
+	}
+	getEscrow := func() *models.EscrowTransaction {
+		escrow, err := escrowStore.Get(context.Background(), "escrow_1")
+		require.NoError(t, err)
+		return escrow
+	}
+
+	// One rating is below the quorum
+	require.NoError(t, service.UpdateEscrowRating("escrow_1", 5.0, "player_1"))
+	escrow := getEscrow()
+	assert.False(t, escrow.RatingReceived)
+	assert.Equal(t, models.EscrowStatusHeld, escrow.Status)
+
+	// The second rating meets the quorum, the minimum is poor
+	require.NoError(t, service.UpdateEscrowRating("escrow_1", 2.0, "player_2"))
+	escrow = getEscrow()
+	assert.True(t, escrow.RatingReceived)
+	assert.Equal(t, 2.0, escrow.ActualRating)
+	assert.Equal(t, models.EscrowStatusPendingRating, escrow.Status)
+
+	// Rating again replaces the earlier rating instead of adding another
+	require.NoError(t, service.UpdateEscrowRating("escrow_1", 4.0, "player_2"))
+	escrow = getEscrow()
+	assert.Equal(t, 2, escrow.RatingCount)
+	assert.Equal(t, 4.0, escrow.ActualRating)
+	assert.True(t, escrow.RatingApproved)
+	assert.Equal(t, models.EscrowStatusApproved, escrow.Status)
+
+	// Ratings from players who were not present are ignored
+	require.NoError(t, service.UpdateEscrowRating("escrow_1", 1.0, "stranger"))
+	escrow = getEscrow()
+	assert.Equal(t, 2, escrow.RatingCount)
+	assert.Equal(t, 4.0, escrow.ActualRating)
+
+	assert.Error(t, service.UpdateEscrowRating("escrow_1", 6.0, "player_3"), "should reject out-of-range ratings")
+}
+
+// savingRatingStore runs onSave after each rating is saved, to interleave other escrow updates
+type savingRatingStore struct {
+	*MemoryRatingStore
+	onSave func()
+}
+
+func (s savingRatingStore) SaveRating(ctx context.Context, rating *models.RatingValidation) error {
+	if err := s.MemoryRatingStore.SaveRating(ctx, rating); err != nil {
+		return err
+	}
+	s.onSave()
+	return nil
+}
+
+func TestUpdateEscrowRatingKeepsConcurrentRelease(t *testing.T) {
+	escrowStore := NewMemoryEscrowStore(&models.EscrowTransaction{
+		ID:                "escrow_1",
+		GameID:            "game_1",
+		OrganizerID:       "organizer_1",
+		Status:            models.EscrowStatusApproved,
+		MinRatingRequired: 3.0,
+	})
+	ratingStore := NewMemoryRatingStore()
+	ratingStore.SetPlayersPresent("game_1", "player_1")
+
+	// A release claims the escrow after the rating read it but before the rating is written
+	service := &PaymentService{
+		escrowStore: escrowStore,
+		ratingStore: savingRatingStore{ratingStore, func() {
+			_, err := escrowStore.Update(context.Background(), "escrow_1", func(escrow *models.EscrowTransaction) error {
+				escrow.Status = models.EscrowStatusReleasing
+				return nil
+			})
+			require.NoError(t, err)
+		}},
+	}
+
+	require.NoError(t, service.UpdateEscrowRating("escrow_1", 1.0, "player_1"))
+
+	escrow, err := escrowStore.Get(context.Background(), "escrow_1")
+	require.NoError(t, err)
+	assert.Equal(t, models.EscrowStatusReleasing, escrow.Status, "the release claim is kept")
+	assert.Equal(t, 1.0, escrow.ActualRating, "the rating is still recorded")
+	assert.False(t, escrow.RatingApproved)
+}
+
+func TestProcessAutomaticReleasesUsesAggregatedRatings(t *testing.T) {
+	now := time.Now()
+	escrows := []*models.EscrowTransaction{
+		{ID: "escrow_good", GameID: "game_good", OrganizerID: "organizer_1", Status: models.EscrowStatusPendingRating, ReleaseEligibleAt: now.Add(-2 * time.Hour), MinRatingRequired: 3.0},
+		{ID: "escrow_poor", GameID: "game_poor", OrganizerID: "organizer_1", Status: models.EscrowStatusPendingRating, ReleaseEligibleAt: now.Add(-2 * time.Hour), MinRatingRequired: 3.0},
+	}
+	escrowStore := NewMemoryEscrowStore(escrows...)
+
+	ratingStore := NewMemoryRatingStore()
+	for _, gameID := range []string{"game_good", "game_poor"} {
+		ratingStore.SetPlayersPresent(gameID, "player_1", "player_2")
+	}
+	for _, r := range []struct {
+		gameID, raterID string
+		rating          float64
+	}{
+		{"game_good", "player_1", 4}, {"game_good", "player_2", 3},
+		{"game_poor", "player_1", 4}, {"game_poor", "player_2", 1},
+	} {
+		require.NoError(t, ratingStore.SaveRating(context.Background(), &models.RatingValidation{
+			ID: r.gameID + "_" + r.raterID, GameID: r.gameID, RatedPlayerID: "organizer_1", RaterID: r.raterID, Rating: r.rating, CreatedAt: now,
+		}))
+	}
+
+	service := &PaymentService{
+		escrowStore:     escrowStore,
+		ratingStore:     ratingStore,
+		fundsReleaser:   &fakeFundsReleaser{},
+		releasePolicies: ReleasePolicies{Default: models.ReleasePolicy{RatingAggregation: models.RatingAggregationMean, MinRaters: 2}},
+	}
+
+	processed, failed, _, _, err := service.ProcessAutomaticReleases()
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, 0, failed)
+
+	released, _ := escrowStore.Get(context.Background(), "escrow_good")
+	assert.Equal(t, models.EscrowStatusReleased, released.Status)
+	assert.Equal(t, 3.5, released.ActualRating)
+	assert.Len(t, released.RaterRatings, 2)
+
+	held, _ := escrowStore.Get(context.Background(), "escrow_poor")
+	assert.Equal(t, models.EscrowStatusUnderReview, held.Status)
+	assert.Equal(t, 2.5, held.ActualRating)
+	assert.NotNil(t, held.ReviewAlertSentAt)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RatingStore reads the attendance and ratings that feed escrow release decisions
+type RatingStore interface {
+	// PlayersPresent returns the players who attended the game, or nil if the game is unknown
+	PlayersPresent(ctx context.Context, gameID string) ([]string, error)
+	// ListGameRatings returns the ratings given to ratedPlayerID for the game
+	ListGameRatings(ctx context.Context, gameID, ratedPlayerID string) ([]*models.RatingValidation, error)
+	SaveRating(ctx context.Context, rating *models.RatingValidation) error
+	// RecordAttendance sets the players who attended the game
+	RecordAttendance(ctx context.Context, gameID string, playerIDs []string) error
+}
+
+// FirestoreRatingStore reads matches and rating_validations from Firestore
+type FirestoreRatingStore struct{}
+
+func (FirestoreRatingStore) client() (*firestore.Client, error) {
+	firestoreClient := config.FirestoreClient()
+	if firestoreClient == nil {
+		return nil, fmt.Errorf("firestore client not available")
+	}
+	return firestoreClient, nil
+}
+
+func (fs FirestoreRatingStore) PlayersPresent(ctx context.Context, gameID string) ([]string, error) {
+	client, err := fs.client()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := client.Collection("matches").Doc(gameID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var match models.Match
+	if err := doc.DataTo(&match); err != nil {
+		return nil, fmt.Errorf("failed to parse match %s: %w", gameID, err)
+	}
+	return match.PlayersPresent, nil
+}
+
+func (fs FirestoreRatingStore) ListGameRatings(ctx context.Context, gameID, ratedPlayerID string) ([]*models.RatingValidation, error) {
+	client, err := fs.client()
+	if err != nil {
+		return nil, err
+	}
+
+	iter := client.Collection("rating_validations").
+		Where("gameId", "==", gameID).
+		Where("ratedPlayerId", "==", ratedPlayerID).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var ratings []*models.RatingValidation
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate ratings: %w", err)
+		}
+
+		var rating models.RatingValidation
+		if err := doc.DataTo(&rating); err != nil {
+			return nil, fmt.Errorf("failed to parse rating %s: %w", doc.Ref.ID, err)
+		}
+
+		ratings = append(ratings, &rating)
+	}
+
+	return ratings, nil
+}
+
+func (fs FirestoreRatingStore) SaveRating(ctx context.Context, rating *models.RatingValidation) error {
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Collection("rating_validations").Doc(rating.ID).Set(ctx, rating)
+	return err
+}
+
+func (fs FirestoreRatingStore) RecordAttendance(ctx context.Context, gameID string, playerIDs []string) error {
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Collection("matches").Doc(gameID).Set(ctx, map[string]interface{}{
+		"playersPresent": playerIDs,
+	}, firestore.MergeAll)
+	return err
+}
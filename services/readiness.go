@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/balance"
+	"google.golang.org/api/iterator"
+)
+
+// Dependency check statuses
+const (
+	DependencyStatusOK       = "ok"
+	DependencyStatusDown     = "down"
+	DependencyStatusDisabled = "disabled" // Not used by this deployment; does not affect readiness
+)
+
+// readinessCheckTimeout bounds each dependency check, so /ready answers within probe timeouts
+const readinessCheckTimeout = 2 * time.Second
+
+// ErrDependencyDisabled is returned by a check whose dependency is switched off on purpose
+var ErrDependencyDisabled = errors.New("disabled")
+
+// DependencyStatus reports the outcome of one readiness check
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // ok, down, disabled
+	LatencyMs float64 `json:"latencyMs"`
+	Details   string  `json:"details,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Readiness is the result of checking every dependency
+type Readiness struct {
+	Ready        bool               `json:"ready"`
+	CheckedAt    time.Time          `json:"checkedAt"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// ReadinessCheck checks one dependency. Check returns optional details, or an error when the
+// dependency is unusable (ErrDependencyDisabled when it is switched off on purpose).
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) (string, error)
+}
+
+// CheckReadiness runs the checks concurrently, each with its own timeout. The service is ready
+// when no check reports its dependency down.
+func CheckReadiness(ctx context.Context, checks []ReadinessCheck) Readiness {
+	readiness := Readiness{Ready: true, CheckedAt: time.Now(), Dependencies: make([]DependencyStatus, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check.Check(checkCtx)
+			dependency := DependencyStatus{
+				Name:      check.Name,
+				Status:    DependencyStatusOK,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				Details:   details,
+			}
+			switch {
+			case errors.Is(err, ErrDependencyDisabled):
+				dependency.Status = DependencyStatusDisabled
+			case err != nil:
+				dependency.Status = DependencyStatusDown
+				dependency.Error = err.Error()
+			}
+			readiness.Dependencies[i] = dependency
+		}()
+	}
+	wg.Wait()
+
+	for _, dependency := range readiness.Dependencies {
+		if dependency.Status == DependencyStatusDown {
+			readiness.Ready = false
+		}
+	}
+	return readiness
+}
+
+// ServiceReadinessChecks returns the checks behind /ready, apart from Firebase Auth, which the
+// caller adds as services does not depend on the auth package
+func ServiceReadinessChecks() []ReadinessCheck {
+	return []ReadinessCheck{
+		{Name: "firestore", Check: checkFirestore},
+		{Name: "stripe", Check: checkStripe},
+		{Name: "jobs", Check: checkJobSchedulers},
+	}
+}
+
+// checkFirestore reads at most one document to prove Firestore is reachable with our credentials
+func checkFirestore(ctx context.Context) (string, error) {
+	client := config.FirestoreClient()
+	if client == nil {
+		return "", fmt.Errorf("firestore client not initialized")
+	}
+
+	_, err := client.Collection("job_checkpoints").Limit(1).Documents(ctx).Next()
+	if err != nil && err != iterator.Done {
+		return "", err
+	}
+	return "", nil
+}
+
+// stripeKeyCheckTTL is how long a key Stripe accepted is trusted before asking Stripe again
+const stripeKeyCheckTTL = 5 * time.Minute
+
+var (
+	stripeKeyMu       sync.Mutex
+	stripeKeyVerified = make(map[string]time.Time) // Secret key -> when Stripe last accepted it
+
+	// verifyStripeKey asks Stripe whether the key works; replaced in tests
+	verifyStripeKey = func(ctx context.Context, key string) error {
+		params := &stripe.BalanceParams{}
+		params.Context = ctx
+		client := balance.Client{B: stripe.GetBackend(stripe.APIBackend), Key: key}
+		_, err := client.Get(params)
+		return err
+	}
+)
+
+// checkStripe checks that the secret key matches STRIPE_TEST_MODE and that Stripe accepts it
+func checkStripe(ctx context.Context) (string, error) {
+	conf := config.GetJobsConfig()
+	key := conf.StripeSecretKey
+
+	mode, err := stripeKeyMode(key, conf.StripeTestMode)
+	if err != nil {
+		return "", err
+	}
+
+	stripeKeyMu.Lock()
+	verifiedAt, verified := stripeKeyVerified[key]
+	stripeKeyMu.Unlock()
+	if verified && time.Since(verifiedAt) < stripeKeyCheckTTL {
+		return mode + " key, verified " + verifiedAt.UTC().Format(time.RFC3339), nil
+	}
+
+	if err := verifyStripeKey(ctx, key); err != nil {
+		return mode + " key", fmt.Errorf("stripe rejected the key: %w", err)
+	}
+
+	now := time.Now()
+	stripeKeyMu.Lock()
+	stripeKeyVerified = map[string]time.Time{key: now}
+	stripeKeyMu.Unlock()
+	return mode + " key, verified " + now.UTC().Format(time.RFC3339), nil
+}
+
+// stripeKeyMode returns "test" or "live" for a Stripe secret or restricted key, or an error when
+// the key is missing, malformed, or its mode disagrees with STRIPE_TEST_MODE
+func stripeKeyMode(key string, testMode bool) (string, error) {
+	mode := config.StripeKeyMode(key)
+	switch {
+	case key == "":
+		return "", fmt.Errorf("STRIPE_SECRET_KEY not set")
+	case mode == "":
+		return "", fmt.Errorf("STRIPE_SECRET_KEY is not a Stripe secret or restricted key")
+	}
+
+	if testMode && mode == "live" {
+		return mode, fmt.Errorf("live Stripe key configured while STRIPE_TEST_MODE is on")
+	}
+	if !testMode && mode == "test" {
+		return mode, fmt.Errorf("test Stripe key configured while STRIPE_TEST_MODE is false")
+	}
+	return mode, nil
+}
+
+// checkJobSchedulers checks that each job's scheduler goroutine is alive. A scheduler is busy
+// rather than stuck while a run of its job is active, so only idle schedulers must have beaten
+// recently.
+func checkJobSchedulers(ctx context.Context) (string, error) {
+	if jobManager == nil {
+		return "", fmt.Errorf("%w: job manager not started", ErrDependencyDisabled)
+	}
+	return jobManager.schedulerHealth(time.Now())
+}
+
+func (jm *BackgroundJobManager) schedulerHealth(now time.Time) (string, error) {
+	jm.mu.Lock()
+	running := jm.running
+	jm.mu.Unlock()
+	if !running {
+		return "", fmt.Errorf("job manager is shutting down")
+	}
+	if !jm.scheduled {
+		return "cron mode, jobs run on cron requests", nil
+	}
+
+	statusMutex.RLock()
+	busy := make(map[string]bool, len(activeRuns))
+	for jobKey := range activeRuns {
+		busy[jobKey] = true
+	}
+	statusMutex.RUnlock()
+
+	jm.beatMu.Lock()
+	defer jm.beatMu.Unlock()
+
+	var stale []string
+	for _, job := range jobRegistry {
+		if busy[job.Key] {
+			continue
+		}
+		lastBeat, exists := jm.heartbeats[job.Key]
+		if !exists || now.Sub(lastBeat) > 3*schedulerHeartbeatInterval {
+			stale = append(stale, job.Key)
+		}
+	}
+	if len(stale) > 0 {
+		return "", fmt.Errorf("schedulers not responding: %s", strings.Join(stale, ", "))
+	}
+	return fmt.Sprintf("%d schedulers alive", len(jobRegistry)), nil
+}
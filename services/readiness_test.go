@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReadiness(t *testing.T) {
+	ok := func(context.Context) (string, error) { return "fine", nil }
+	down := func(context.Context) (string, error) { return "", errors.New("unreachable") }
+	disabled := func(context.Context) (string, error) { return "", fmt.Errorf("%w: off", ErrDependencyDisabled) }
+
+	t.Run("should be ready when every dependency is ok or disabled", func(t *testing.T) {
+		readiness := CheckReadiness(context.Background(), []ReadinessCheck{
+			{Name: "a", Check: ok},
+			{Name: "b", Check: disabled},
+		})
+
+		assert.True(t, readiness.Ready)
+		require.Len(t, readiness.Dependencies, 2)
+		assert.Equal(t, DependencyStatus{Name: "a", Status: DependencyStatusOK, LatencyMs: readiness.Dependencies[0].LatencyMs, Details: "fine"}, readiness.Dependencies[0])
+		assert.Equal(t, DependencyStatusDisabled, readiness.Dependencies[1].Status)
+		assert.Empty(t, readiness.Dependencies[1].Error)
+	})
+
+	t.Run("should not be ready when a dependency is down", func(t *testing.T) {
+		readiness := CheckReadiness(context.Background(), []ReadinessCheck{
+			{Name: "a", Check: ok},
+			{Name: "b", Check: down},
+		})
+
+		assert.False(t, readiness.Ready)
+		assert.Equal(t, DependencyStatusDown, readiness.Dependencies[1].Status)
+		assert.Equal(t, "unreachable", readiness.Dependencies[1].Error)
+	})
+
+	t.Run("should bound each check with a timeout and report its latency", func(t *testing.T) {
+		slow := func(ctx context.Context) (string, error) {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				return "", nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		readiness := CheckReadiness(context.Background(), []ReadinessCheck{{Name: "slow", Check: slow}})
+
+		assert.True(t, readiness.Ready)
+		assert.GreaterOrEqual(t, readiness.Dependencies[0].LatencyMs, float64(20))
+	})
+}
+
+func TestStripeKeyMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		testMode bool
+		mode     string
+		errMsg   string
+	}{
+		{"test key in test mode", "sk_test_123", true, "test", ""},
+		{"restricted live key in live mode", "rk_live_123", false, "live", ""},
+		{"live key in test mode", "sk_live_123", true, "live", "STRIPE_TEST_MODE is on"},
+		{"test key in live mode", "sk_test_123", false, "test", "STRIPE_TEST_MODE is false"},
+		{"publishable key", "pk_test_123", true, "", "not a Stripe secret"},
+		{"missing key", "", true, "", "not set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := stripeKeyMode(tt.key, tt.testMode)
+			assert.Equal(t, tt.mode, mode)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestCheckStripe(t *testing.T) {
+	previous := verifyStripeKey
+	defer func() {
+		verifyStripeKey = previous
+		stripeKeyVerified = make(map[string]time.Time)
+	}()
+	t.Setenv("STRIPE_TEST_MODE", "true")
+	defer config.InitJobsConfig()
+
+	calls := 0
+	verifyStripeKey = func(ctx context.Context, key string) error {
+		calls++
+		if key == "sk_test_revoked" {
+			return errors.New("invalid api key")
+		}
+		return nil
+	}
+
+	t.Run("should report a key Stripe rejects", func(t *testing.T) {
+		t.Setenv("STRIPE_SECRET_KEY", "sk_test_revoked")
+		config.InitJobsConfig()
+		_, err := checkStripe(context.Background())
+		assert.ErrorContains(t, err, "stripe rejected the key")
+	})
+
+	t.Run("should cache a verified key", func(t *testing.T) {
+		t.Setenv("STRIPE_SECRET_KEY", "sk_test_valid")
+		config.InitJobsConfig()
+		calls = 0
+
+		details, err := checkStripe(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, details, "test key")
+
+		_, err = checkStripe(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should not call Stripe with a key for the wrong mode", func(t *testing.T) {
+		t.Setenv("STRIPE_SECRET_KEY", "sk_live_valid")
+		config.InitJobsConfig()
+		calls = 0
+
+		_, err := checkStripe(context.Background())
+		assert.ErrorContains(t, err, "live Stripe key")
+		assert.Zero(t, calls)
+	})
+}
+
+func TestSchedulerHealth(t *testing.T) {
+	resetJobRuns()
+	now := time.Now()
+
+	newScheduledManager := func() *BackgroundJobManager {
+		jm := newTestJobManager()
+		jm.scheduled = true
+		jm.heartbeats = make(map[string]time.Time)
+		for _, job := range jobRegistry {
+			jm.heartbeats[job.Key] = now
+		}
+		return jm
+	}
+
+	t.Run("should be healthy when every scheduler beat recently", func(t *testing.T) {
+		jm := newScheduledManager()
+		details, err := jm.schedulerHealth(now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, "3 schedulers alive", details)
+	})
+
+	t.Run("should report schedulers that stopped beating", func(t *testing.T) {
+		jm := newScheduledManager()
+		jm.heartbeats["auto_release"] = now.Add(-time.Hour)
+
+		_, err := jm.schedulerHealth(now)
+		assert.ErrorContains(t, err, "schedulers not responding: auto_release")
+	})
+
+	t.Run("should not expect a beat from a scheduler busy running its job", func(t *testing.T) {
+		defer resetJobRuns()
+		jm := newScheduledManager()
+		jm.heartbeats["auto_release"] = now.Add(-time.Hour)
+		_, err := beginJobRun("auto_release", JobTriggerScheduled)
+		require.NoError(t, err)
+
+		_, err = jm.schedulerHealth(now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should not expect schedulers in cron mode", func(t *testing.T) {
+		jm := newTestJobManager()
+		details, err := jm.schedulerHealth(now)
+		require.NoError(t, err)
+		assert.Contains(t, details, "cron mode")
+	})
+
+	t.Run("should fail once shutdown starts", func(t *testing.T) {
+		jm := newScheduledManager()
+		jm.running = false
+		_, err := jm.schedulerHealth(now)
+		assert.ErrorContains(t, err, "shutting down")
+	})
+}
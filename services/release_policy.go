@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// ReleasePolicies holds the default escrow release policy and the per-organizer overrides
+type ReleasePolicies struct {
+	Default    models.ReleasePolicy                    `json:"default"`
+	Organizers map[string]models.ReleasePolicyOverride `json:"organizers,omitempty"`
+}
+
+// For returns the policy that applies to escrows of the given organizer
+func (rp ReleasePolicies) For(organizerID string) models.ReleasePolicy {
+	policy := rp.Default.WithDefaults()
+	if override, exists := rp.Organizers[organizerID]; exists {
+		policy = policy.WithOverride(override)
+	}
+	return policy
+}
+
+// Validate checks the default policy and every organizer override
+func (rp ReleasePolicies) Validate() error {
+	if err := rp.Default.WithDefaults().Validate(); err != nil {
+		return fmt.Errorf("default release policy: %w", err)
+	}
+	for organizerID := range rp.Organizers {
+		if err := rp.For(organizerID).Validate(); err != nil {
+			return fmt.Errorf("release policy for organizer %s: %w", organizerID, err)
+		}
+	}
+	return nil
+}
+
+// ReleasePolicies builds the escrow release policies from the job configuration.
+// Unset values fall back to the platform defaults.
+func (c *JobConfig) ReleasePolicies() ReleasePolicies {
+	return ReleasePolicies{
+		Default: models.ReleasePolicy{
+			MinRating:         c.MinRatingForAutoRelease,
+			HoldPeriod:        c.EscrowHoldPeriod,
+			GracePeriod:       c.ReleaseGracePeriod,
+			RatingAggregation: c.RatingAggregation,
+			MinRaters:         c.MinRaters,
+		},
+		Organizers: c.OrganizerPolicies,
+	}
+}
+
+// jobConfigFromEnv builds the job configuration from the environment
+func jobConfigFromEnv() *JobConfig {
+	jobsConf := config.GetJobsConfig()
+
+	organizerPolicies := make(map[string]models.ReleasePolicyOverride, len(jobsConf.TrustedOrganizers))
+	for _, organizerID := range jobsConf.TrustedOrganizers {
+		organizerPolicies[organizerID] = models.ReleasePolicyOverride{HoldPeriod: jobsConf.TrustedOrganizerHoldPeriod}
+	}
+
+	return &JobConfig{
+		RatingReminderInterval:    jobsConf.RatingReminderInterval,
+		AutoReleaseInterval:       jobsConf.AutoReleaseInterval,
+		DisputeEscalationInterval: jobsConf.DisputeEscalationInterval,
+		RatingDeadlineDays:        jobsConf.RatingDeadlineDays,
+		MinRatingForAutoRelease:   jobsConf.MinRatingForAutoRelease,
+		DisputeEscalationHours:    jobsConf.DisputeEscalationHours,
+		EscrowHoldPeriod:          jobsConf.EscrowHoldPeriod,
+		ReleaseGracePeriod:        jobsConf.ReleaseGracePeriod,
+		RatingAggregation:         jobsConf.RatingAggregation,
+		MinRaters:                 jobsConf.MinRaters,
+		OrganizerPolicies:         organizerPolicies,
+	}
+}
+
+// currentReleasePolicies returns the release policies of the running job manager, or from the
+// environment when jobs are not running. Invalid policies fall back to the platform defaults.
+func currentReleasePolicies() ReleasePolicies {
+	jobConfig := GetJobConfig()
+	if jobConfig == nil {
+		jobConfig = jobConfigFromEnv()
+	}
+
+	policies := jobConfig.ReleasePolicies()
+	if err := policies.Validate(); err != nil {
+		slog.Warn("Invalid release policy, using defaults", "error", err)
+		return ReleasePolicies{}
+	}
+	return policies
+}
+
+// releasePolicyFor returns the policy recorded on the escrow when it was created, or the current
+// policy for its organizer for escrows created before policies were recorded
+func (s *PaymentService) releasePolicyFor(escrow *models.EscrowTransaction) models.ReleasePolicy {
+	if escrow.ReleasePolicy != nil {
+		return escrow.ReleasePolicy.WithDefaults()
+	}
+	return s.releasePolicies.For(escrow.OrganizerID)
+}
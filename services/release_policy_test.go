@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleasePolicies(t *testing.T) {
+	policies := (&JobConfig{
+		MinRatingForAutoRelease: 3.5,
+		EscrowHoldPeriod:        48 * time.Hour,
+		ReleaseGracePeriod:      72 * time.Hour,
+		RatingAggregation:       models.RatingAggregationMedian,
+		OrganizerPolicies: map[string]models.ReleasePolicyOverride{
+			"trusted_organizer": {HoldPeriod: 6 * time.Hour},
+		},
+	}).ReleasePolicies()
+
+	t.Run("should build the default policy from the job config", func(t *testing.T) {
+		policy := policies.For("organizer_1")
+
+		assert.Equal(t, models.ReleasePolicySourceDefault, policy.Source)
+		assert.Equal(t, 3.5, policy.MinRating)
+		assert.Equal(t, 48*time.Hour, policy.HoldPeriod)
+		assert.Equal(t, 72*time.Hour, policy.GracePeriod)
+		assert.Equal(t, models.RatingAggregationMedian, policy.RatingAggregation)
+		assert.Equal(t, models.DefaultMinRaters, policy.MinRaters)
+	})
+
+	t.Run("should apply organizer overrides on top of the default", func(t *testing.T) {
+		policy := policies.For("trusted_organizer")
+
+		assert.Equal(t, models.ReleasePolicySourceOrganizer, policy.Source)
+		assert.Equal(t, 6*time.Hour, policy.HoldPeriod)
+		assert.Equal(t, 3.5, policy.MinRating, "unset override fields keep the default")
+		assert.Equal(t, 72*time.Hour, policy.GracePeriod)
+	})
+
+	t.Run("should fall back to platform defaults for an empty config", func(t *testing.T) {
+		policy := (&JobConfig{}).ReleasePolicies().For("organizer_1")
+
+		assert.Equal(t, models.DefaultMinRatingRequired, policy.MinRating)
+		assert.Equal(t, time.Duration(models.EscrowHoldHours)*time.Hour, policy.HoldPeriod)
+		assert.Equal(t, models.DefaultGracePeriod, policy.GracePeriod)
+		assert.NoError(t, policy.Validate())
+	})
+
+	t.Run("should reject invalid policies", func(t *testing.T) {
+		assert.Error(t, (&JobConfig{RatingAggregation: "mode"}).ReleasePolicies().Validate())
+		assert.Error(t, (&JobConfig{
+			OrganizerPolicies: map[string]models.ReleasePolicyOverride{"organizer_1": {MinRating: 7}},
+		}).ReleasePolicies().Validate())
+	})
+}
+
+func TestUpdateJobConfigRejectsInvalidReleasePolicy(t *testing.T) {
+	original := &JobConfig{RatingReminderInterval: time.Hour}
+	jobManager = &BackgroundJobManager{config: original, shutdown: make(chan struct{})}
+	defer func() { jobManager = nil }()
+
+	err := UpdateJobConfig(&JobConfig{MinRatingForAutoRelease: 9})
+
+	assert.Error(t, err)
+	assert.Equal(t, original, GetJobConfig(), "an invalid config should not replace the current one")
+}
+
+func TestReleasePolicyRecordedOnEscrow(t *testing.T) {
+	service := &PaymentService{releasePolicies: ReleasePolicies{Default: models.ReleasePolicy{GracePeriod: 7 * 24 * time.Hour}}}
+	eligibleAt := time.Now().Add(-25 * time.Hour)
+
+	t.Run("should use the grace period recorded on the escrow", func(t *testing.T) {
+		escrow := &models.EscrowTransaction{
+			ID:                "escrow_recorded",
+			Status:            models.EscrowStatusHeld,
+			ReleaseEligibleAt: eligibleAt,
+			MinRatingRequired: 3.0,
+			ReleasePolicy:     &models.ReleasePolicy{GracePeriod: 24 * time.Hour},
+		}
+
+		assert.True(t, service.isEligibleForAutoRelease(escrow), "policy changes should not affect existing escrows")
+	})
+
+	t.Run("should use the current policy for escrows without a recorded one", func(t *testing.T) {
+		escrow := &models.EscrowTransaction{
+			ID:                "escrow_legacy",
+			Status:            models.EscrowStatusHeld,
+			ReleaseEligibleAt: eligibleAt,
+			MinRatingRequired: 3.0,
+		}
+
+		assert.False(t, service.isEligibleForAutoRelease(escrow))
+	})
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/metrics"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/tracing"
+	"github.com/stripe/stripe-go/v76"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy retries transient failures with exponential backoff and jitter
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt
+	BaseDelay  time.Duration // Delay before the first retry, doubled on each further retry
+	MaxDelay   time.Duration // Upper bound for a single delay
+	Jitter     float64       // Fraction of the delay randomised, between 0 and 1
+
+	stats *RetryStats
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// RetryStats accumulates attempt counts per operation
+type RetryStats struct {
+	mu         sync.Mutex
+	operations map[string]*OperationAttempts
+}
+
+// OperationAttempts summarises the attempts made for one kind of operation
+type OperationAttempts struct {
+	Calls    int `json:"calls"`
+	Attempts int `json:"attempts"`
+	Failures int `json:"failures"` // Calls that still failed after the last attempt
+}
+
+// Retry limits for calls made while serving an HTTP request, where a client is waiting
+const (
+	requestMaxRetries = 2
+	requestRetryDelay = 250 * time.Millisecond
+	requestMaxDelay   = time.Second
+)
+
+// NewRetryPolicy creates the retry policy for background jobs from the jobs configuration
+// (MAX_RETRIES, RETRY_DELAY)
+func NewRetryPolicy() *RetryPolicy {
+	jobsConf := config.GetJobsConfig()
+	return &RetryPolicy{
+		MaxRetries: jobsConf.MaxRetries,
+		BaseDelay:  jobsConf.RetryDelay,
+		MaxDelay:   8 * jobsConf.RetryDelay,
+		Jitter:     0.2,
+		stats:      &RetryStats{},
+	}
+}
+
+// NewRequestRetryPolicy creates the retry policy for HTTP request paths. It backs off for
+// milliseconds instead of RETRY_DELAY and makes at most MAX_RETRIES, capped at 2, retries so a
+// request is never held for minutes.
+func NewRequestRetryPolicy() *RetryPolicy {
+	maxRetries := config.GetJobsConfig().MaxRetries
+	if maxRetries > requestMaxRetries {
+		maxRetries = requestMaxRetries
+	}
+	return &RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  requestRetryDelay,
+		MaxDelay:   requestMaxDelay,
+		Jitter:     0.2,
+		stats:      &RetryStats{},
+	}
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error, the retries are used up or ctx
+// is cancelled. A nil policy runs fn exactly once. Each call is traced as a client span named
+// after the operation, with failed attempts recorded as span events.
+func (p *RetryPolicy) Do(ctx context.Context, operation string, fn func() error) error {
+	ctx, span := tracing.Tracer().Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+	attempts := 0
+	var err error
+
+	for {
+		attempts++
+		attemptStart := time.Now()
+		err = fn()
+		metrics.ObserveExternalCall(operation, time.Since(attemptStart), err)
+		if err == nil || p == nil || attempts > p.MaxRetries || !IsRetryableError(err) {
+			break
+		}
+		span.AddEvent("attempt failed", trace.WithAttributes(
+			attribute.Int("attempt", attempts),
+			attribute.String("error", err.Error()),
+		))
+
+		delay := p.backoff(attempts)
+		slog.WarnContext(ctx, "Operation failed, retrying", "operation", operation, "attempt", attempts, "max_attempts", p.MaxRetries+1, "delay", delay.String(), "error", err)
+
+		if sleepErr := p.wait(ctx, delay); sleepErr != nil {
+			err = fmt.Errorf("%w (retry cancelled: %v)", err, sleepErr)
+			break
+		}
+	}
+
+	if p != nil {
+		p.stats.record(operation, attempts, err != nil)
+	}
+	span.SetAttributes(attribute.Int("retry.attempts", attempts))
+	tracing.End(span, err)
+	return err
+}
+
+// Stats returns a snapshot of the attempts made through this policy
+func (p *RetryPolicy) Stats() map[string]OperationAttempts {
+	if p == nil {
+		return nil
+	}
+	return p.stats.snapshot()
+}
+
+// backoff returns the delay before the given retry (1-based), with jitter applied
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay)
+}
+
+func (p *RetryPolicy) wait(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		return p.sleep(ctx, d)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (rs *RetryStats) record(operation string, attempts int, failed bool) {
+	if rs == nil {
+		return
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.operations == nil {
+		rs.operations = make(map[string]*OperationAttempts)
+	}
+	op, exists := rs.operations[operation]
+	if !exists {
+		op = &OperationAttempts{}
+		rs.operations[operation] = op
+	}
+	op.Calls++
+	op.Attempts += attempts
+	if failed {
+		op.Failures++
+	}
+}
+
+func (rs *RetryStats) snapshot() map[string]OperationAttempts {
+	if rs == nil {
+		return nil
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	snapshot := make(map[string]OperationAttempts, len(rs.operations))
+	for name, op := range rs.operations {
+		snapshot[name] = *op
+	}
+	return snapshot
+}
+
+// TotalRetries returns how many attempts beyond the first were made across all operations
+func TotalRetries(stats map[string]OperationAttempts) int {
+	retries := 0
+	for _, op := range stats {
+		retries += op.Attempts - op.Calls
+	}
+	return retries
+}
+
+// SlackHTTPError is returned when a Slack webhook responds with a non-200 status
+type SlackHTTPError struct {
+	StatusCode int
+}
+
+func (e *SlackHTTPError) Error() string {
+	return fmt.Sprintf("slack webhook returned HTTP %d", e.StatusCode)
+}
+
+// WebhookHTTPError is returned when a notification webhook responds with a non-2xx status
+type WebhookHTTPError struct {
+	StatusCode int
+}
+
+func (e *WebhookHTTPError) Error() string {
+	return fmt.Sprintf("notification webhook returned HTTP %d", e.StatusCode)
+}
+
+// IsRetryableError reports whether err is a transient failure worth retrying:
+// Stripe rate limits and 5xx responses, Firestore Unavailable-style gRPC codes,
+// Slack and notification webhook 429/5xx responses and network timeouts
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) {
+		return stripeErr.HTTPStatusCode == http.StatusTooManyRequests ||
+			stripeErr.HTTPStatusCode >= http.StatusInternalServerError ||
+			stripeErr.Code == stripe.ErrorCodeRateLimit ||
+			stripeErr.Type == stripe.ErrorTypeAPI
+	}
+
+	var slackErr *SlackHTTPError
+	if errors.As(err, &slackErr) {
+		return slackErr.StatusCode == http.StatusTooManyRequests || slackErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var webhookErr *WebhookHTTPError
+	if errors.As(err, &webhookErr) {
+		return webhookErr.StatusCode == http.StatusTooManyRequests || webhookErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+			return true
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return false
+}
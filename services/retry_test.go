@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestRetryPolicy(maxRetries int) (*RetryPolicy, *[]time.Duration) {
+	var delays []time.Duration
+	policy := &RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  100 * time.Millisecond,
+		MaxDelay:   time.Second,
+		stats:      &RetryStats{},
+		sleep: func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return ctx.Err()
+		},
+	}
+	return policy, &delays
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := status.Error(codes.Unavailable, "firestore unavailable")
+
+	t.Run("should retry transient errors until success", func(t *testing.T) {
+		policy, delays := newTestRetryPolicy(3)
+
+		calls := 0
+		err := policy.Do(context.Background(), "firestore.update_escrow", func() error {
+			calls++
+			if calls < 3 {
+				return transient
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, *delays)
+
+		stats := policy.Stats()["firestore.update_escrow"]
+		assert.Equal(t, OperationAttempts{Calls: 1, Attempts: 3, Failures: 0}, stats)
+		assert.Equal(t, 2, TotalRetries(policy.Stats()))
+	})
+
+	t.Run("should stop after max retries", func(t *testing.T) {
+		policy, _ := newTestRetryPolicy(2)
+
+		calls := 0
+		err := policy.Do(context.Background(), "stripe.create_refund", func() error {
+			calls++
+			return transient
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 1, policy.Stats()["stripe.create_refund"].Failures)
+	})
+
+	t.Run("should not retry permanent errors", func(t *testing.T) {
+		policy, delays := newTestRetryPolicy(3)
+
+		calls := 0
+		err := policy.Do(context.Background(), "stripe.create_refund", func() error {
+			calls++
+			return &stripe.Error{HTTPStatusCode: http.StatusBadRequest, Type: stripe.ErrorTypeInvalidRequest}
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, *delays)
+	})
+
+	t.Run("should stop retrying when context is cancelled", func(t *testing.T) {
+		policy, _ := newTestRetryPolicy(5)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		calls := 0
+		err := policy.Do(ctx, "slack.post_message", func() error {
+			calls++
+			return &SlackHTTPError{StatusCode: http.StatusServiceUnavailable}
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should run once with a nil policy", func(t *testing.T) {
+		var policy *RetryPolicy
+
+		calls := 0
+		err := policy.Do(context.Background(), "slack.post_message", func() error {
+			calls++
+			return transient
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Nil(t, policy.Stats())
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Run("should double the delay up to the maximum", func(t *testing.T) {
+		policy := &RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+		assert.Equal(t, 1*time.Second, policy.backoff(1))
+		assert.Equal(t, 2*time.Second, policy.backoff(2))
+		assert.Equal(t, 4*time.Second, policy.backoff(3))
+		assert.Equal(t, 5*time.Second, policy.backoff(4))
+	})
+
+	t.Run("should keep jitter within bounds", func(t *testing.T) {
+		policy := &RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}
+
+		for i := 0; i < 50; i++ {
+			delay := policy.backoff(1)
+			assert.GreaterOrEqual(t, delay, 800*time.Millisecond)
+			assert.LessOrEqual(t, delay, 1200*time.Millisecond)
+		}
+	})
+}
+
+func TestRetryPolicyConstructors(t *testing.T) {
+	t.Cleanup(func() { config.InitJobsConfig() })
+	t.Setenv("MAX_RETRIES", "5")
+	t.Setenv("RETRY_DELAY", "30s")
+	require.NoError(t, config.InitJobsConfig())
+
+	t.Run("should back off by RETRY_DELAY in jobs", func(t *testing.T) {
+		policy := NewRetryPolicy()
+
+		assert.Equal(t, 5, policy.MaxRetries)
+		assert.Equal(t, 30*time.Second, policy.BaseDelay)
+	})
+
+	t.Run("should keep request retries short", func(t *testing.T) {
+		policy := NewRequestRetryPolicy()
+
+		assert.Equal(t, 2, policy.MaxRetries)
+		total := time.Duration(0)
+		for retry := 1; retry <= policy.MaxRetries; retry++ {
+			total += policy.backoff(retry)
+		}
+		assert.Less(t, total, 2*time.Second)
+	})
+}
+
+func TestIsRetryableError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"stripe_rate_limit", &stripe.Error{HTTPStatusCode: http.StatusTooManyRequests, Code: stripe.ErrorCodeRateLimit}, true},
+		{"stripe_server_error", &stripe.Error{HTTPStatusCode: http.StatusBadGateway, Type: stripe.ErrorTypeAPI}, true},
+		{"stripe_card_declined", &stripe.Error{HTTPStatusCode: http.StatusPaymentRequired, Type: stripe.ErrorTypeCard}, false},
+		{"wrapped_stripe_rate_limit", fmt.Errorf("failed to create refund: %w", &stripe.Error{HTTPStatusCode: http.StatusTooManyRequests}), true},
+		{"firestore_unavailable", status.Error(codes.Unavailable, "unavailable"), true},
+		{"firestore_deadline_exceeded", status.Error(codes.DeadlineExceeded, "deadline"), true},
+		{"firestore_not_found", status.Error(codes.NotFound, "not found"), false},
+		{"slack_rate_limited", &SlackHTTPError{StatusCode: http.StatusTooManyRequests}, true},
+		{"slack_server_error", &SlackHTTPError{StatusCode: http.StatusInternalServerError}, true},
+		{"slack_bad_request", &SlackHTTPError{StatusCode: http.StatusBadRequest}, false},
+		{"webhook_server_error", &WebhookHTTPError{StatusCode: http.StatusBadGateway}, true},
+		{"webhook_not_found", &WebhookHTTPError{StatusCode: http.StatusNotFound}, false},
+		{"plain_error", errors.New("escrow cannot be released"), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsRetryableError(tc.err))
+		})
+	}
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// ErrUnknownScenario is returned for a scenario name that has no definition
+var ErrUnknownScenario = errors.New("unknown scenario")
+
+// Scenario steps
+const (
+	ScenarioActionCreatePayment  = "create_payment"   // Create the payment and its payment intent
+	ScenarioActionConfirmPayment = "confirm_payment"  // Pay with a test card, then confirm the payment
+	ScenarioActionAdvanceTime    = "advance_time"     // Move the scenario's clock forward
+	ScenarioActionSubmitRatings  = "submit_ratings"   // Attendees rate the organizer
+	ScenarioActionRunAutoRelease = "run_auto_release" // Run automatic release for the scenario's escrow
+	ScenarioActionOpenDispute    = "open_dispute"     // A reviewer disputes the escrow under review
+	ScenarioActionRefund         = "refund"           // A reviewer refunds the escrow under review
+)
+
+// defaultScenarioOrganizer is the connected account scenarios pay when they don't name one
+const defaultScenarioOrganizer = "acct_test_organizer"
+
+//go:embed scenarios/*.json
+var scenarioFiles embed.FS
+
+// Scenario is a payment simulation read from a JSON file: a payment of Amount to the organizer,
+// taken through Steps with the state expected after each one
+type Scenario struct {
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	Amount         float64        `json:"amount"`
+	OrganizerID    string         `json:"organizerId,omitempty"` // Defaults to acct_test_organizer
+	ExpectedResult string         `json:"expectedResult"`
+	TestCard       string         `json:"testCard,omitempty"`      // Card number to use from a frontend
+	PaymentMethod  string         `json:"paymentMethod,omitempty"` // Stripe test payment method standing in for the card
+	Steps          []ScenarioStep `json:"steps"`
+}
+
+// ScenarioStep is one action of a scenario. Only the fields of its action are read.
+type ScenarioStep struct {
+	Action          string           `json:"action"`
+	PaymentMethod   string           `json:"paymentMethod,omitempty"`   // confirm_payment; defaults to the scenario's
+	Duration        string           `json:"duration,omitempty"`        // advance_time, e.g. "25h"
+	Ratings         []ScenarioRating `json:"ratings,omitempty"`         // submit_ratings
+	RequestedAction string           `json:"requestedAction,omitempty"` // open_dispute: release, refund, partial_refund
+	Amount          float64          `json:"amount,omitempty"`          // refund; zero refunds the whole escrow
+	Notes           string           `json:"notes,omitempty"`           // open_dispute, refund
+	Expect          ScenarioExpect   `json:"expect"`
+
+	advanceBy time.Duration
+}
+
+// ScenarioRating is an attendee's rating of the organizer
+type ScenarioRating struct {
+	RaterID string  `json:"raterId"`
+	Rating  float64 `json:"rating"`
+}
+
+// ScenarioExpect is the outcome expected from a step. Empty fields are not checked.
+type ScenarioExpect struct {
+	Error         string `json:"error,omitempty"`         // Text the step's error must contain; empty means the step must succeed
+	PaymentStatus string `json:"paymentStatus,omitempty"` // pending, confirmed, failed, refunded
+	EscrowStatus  string `json:"escrowStatus,omitempty"`  // held, approved, pending_rating, under_review, released, disputed, refunded; "none" when no escrow may exist
+	DisputeStatus string `json:"disputeStatus,omitempty"`
+}
+
+// escrowStatusNone expects a scenario to have no escrow, e.g. after a declined card
+const escrowStatusNone = "none"
+
+// ScenarioReport is the outcome of a scenario run
+type ScenarioReport struct {
+	Scenario       string               `json:"scenario"`
+	ExpectedResult string               `json:"expectedResult"`
+	Passed         bool                 `json:"passed"`
+	Steps          []ScenarioStepReport `json:"steps"`
+	TestData       ScenarioTestData     `json:"testData"`
+	StartedAt      time.Time            `json:"startedAt"`
+	Duration       string               `json:"duration"`
+}
+
+// ScenarioTestData identifies the records a scenario run created
+type ScenarioTestData struct {
+	UserID        string  `json:"userId"`
+	GameID        string  `json:"gameId"`
+	ApplicationID string  `json:"applicationId"`
+	OrganizerID   string  `json:"organizerId"`
+	Amount        float64 `json:"amount"`
+	PaymentID     string  `json:"paymentId,omitempty"`
+	EscrowID      string  `json:"escrowId,omitempty"`
+	DisputeID     string  `json:"disputeId,omitempty"`
+}
+
+// ScenarioStepReport is the outcome of one step. Steps after a failed one are skipped.
+type ScenarioStepReport struct {
+	Step          int      `json:"step"`
+	Action        string   `json:"action"`
+	Passed        bool     `json:"passed"`
+	Skipped       bool     `json:"skipped,omitempty"`
+	Error         string   `json:"error,omitempty"`    // Error returned by the step
+	Failures      []string `json:"failures,omitempty"` // Expectations that were not met
+	PaymentStatus string   `json:"paymentStatus,omitempty"`
+	EscrowStatus  string   `json:"escrowStatus,omitempty"`
+	DisputeStatus string   `json:"disputeStatus,omitempty"`
+	Detail        string   `json:"detail,omitempty"`
+}
+
+// Scenarios returns the scenarios shipped in services/scenarios, ordered by name
+func Scenarios() ([]Scenario, error) {
+	return LoadScenarios(scenarioFiles, "scenarios")
+}
+
+// LookupScenario finds a shipped scenario by name
+func LookupScenario(name string) (Scenario, error) {
+	scenarios, err := Scenarios()
+	if err != nil {
+		return Scenario{}, err
+	}
+	for _, scenario := range scenarios {
+		if scenario.Name == name {
+			return scenario, nil
+		}
+	}
+	return Scenario{}, fmt.Errorf("%w: %s", ErrUnknownScenario, name)
+}
+
+// LoadScenarios reads and validates every .json scenario in dir, ordered by name
+func LoadScenarios(fsys fs.FS, dir string) ([]Scenario, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var scenarios []Scenario
+	names := make(map[string]string)
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		var scenario Scenario
+		if err := json.Unmarshal(data, &scenario); err != nil {
+			return nil, fmt.Errorf("scenario %s: %w", file, err)
+		}
+		if err := scenario.validate(); err != nil {
+			return nil, fmt.Errorf("scenario %s: %w", file, err)
+		}
+		if other, exists := names[scenario.Name]; exists {
+			return nil, fmt.Errorf("scenario %s: name %q is also used by %s", file, scenario.Name, other)
+		}
+		names[scenario.Name] = file
+		scenarios = append(scenarios, scenario)
+	}
+
+	sort.Slice(scenarios, func(i, j int) bool { return scenarios[i].Name < scenarios[j].Name })
+	return scenarios, nil
+}
+
+// validate checks the scenario and parses its step durations
+func (sc *Scenario) validate() error {
+	if sc.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("at least one step is required")
+	}
+	if sc.Steps[0].Action != ScenarioActionCreatePayment {
+		return fmt.Errorf("the first step must be %s", ScenarioActionCreatePayment)
+	}
+
+	for i := range sc.Steps {
+		step := &sc.Steps[i]
+		switch step.Action {
+		case ScenarioActionCreatePayment, ScenarioActionRunAutoRelease, ScenarioActionRefund:
+		case ScenarioActionConfirmPayment:
+			if step.PaymentMethod == "" && sc.PaymentMethod == "" {
+				return fmt.Errorf("step %d: a payment method is required, on the step or the scenario", i+1)
+			}
+		case ScenarioActionAdvanceTime:
+			d, err := time.ParseDuration(step.Duration)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("step %d: invalid duration %q", i+1, step.Duration)
+			}
+			step.advanceBy = d
+		case ScenarioActionSubmitRatings:
+			if len(step.Ratings) == 0 {
+				return fmt.Errorf("step %d: at least one rating is required", i+1)
+			}
+		case ScenarioActionOpenDispute:
+			switch step.RequestedAction {
+			case models.DisputeActionRelease, models.DisputeActionRefund, models.DisputeActionPartialRefund:
+			default:
+				return fmt.Errorf("step %d: invalid requested action %q", i+1, step.RequestedAction)
+			}
+		default:
+			return fmt.Errorf("step %d: unknown action %q", i+1, step.Action)
+		}
+	}
+	return nil
+}
+
+// ScenarioRunner executes scenarios against the payment service. Payments go through Stripe, so
+// the service must be in test mode; point STRIPE_API_BASE at a stand-in to run offline.
+type ScenarioRunner struct {
+	service *PaymentService
+}
+
+// NewScenarioRunner creates a runner for the given payment service
+func NewScenarioRunner(service *PaymentService) *ScenarioRunner {
+	return &ScenarioRunner{service: service}
+}
+
+// scenarioRun is the state of one scenario run
+type scenarioRun struct {
+	scenario Scenario
+	clock    *VirtualClock
+	data     ScenarioTestData
+	payment  *models.Payment
+	escrow   *models.EscrowTransaction
+	dispute  *models.EscrowDispute
+}
+
+// Run executes the scenario's steps in order and reports per step whether the expected outcome
+// was met. The scenario's records are created under fresh IDs, and each run has its own clock
+// that only its advance_time steps move, so runs don't interfere. Every step, including the
+// auto-release run, only touches the scenario's own records.
+func (r *ScenarioRunner) Run(ctx context.Context, scenario Scenario) *ScenarioReport {
+	s := r.service.WithContext(ctx)
+	base := s.clock
+	if base == nil {
+		base = ProcessClock()
+	}
+	s.clock = NewVirtualClock(base)
+
+	organizerID := scenario.OrganizerID
+	if organizerID == "" {
+		organizerID = defaultScenarioOrganizer
+	}
+	run := &scenarioRun{
+		scenario: scenario,
+		clock:    s.clock.(*VirtualClock),
+		data: ScenarioTestData{
+			UserID:        "test_user_" + uuid.New().String()[:8],
+			GameID:        "test_game_" + uuid.New().String()[:8],
+			ApplicationID: "test_app_" + uuid.New().String()[:8],
+			OrganizerID:   organizerID,
+			Amount:        scenario.Amount,
+		},
+	}
+
+	report := &ScenarioReport{
+		Scenario:       scenario.Name,
+		ExpectedResult: scenario.ExpectedResult,
+		Passed:         true,
+		StartedAt:      time.Now(),
+	}
+	slog.InfoContext(ctx, "Running payment scenario", "scenario", scenario.Name, "game_id", run.data.GameID)
+
+	for i, step := range scenario.Steps {
+		stepReport := ScenarioStepReport{Step: i + 1, Action: step.Action}
+		if !report.Passed {
+			stepReport.Skipped = true
+			report.Steps = append(report.Steps, stepReport)
+			continue
+		}
+
+		detail, err := r.runStep(s, run, step)
+		stepReport.Detail = detail
+		if err != nil {
+			stepReport.Error = err.Error()
+		}
+		stepReport.Failures = r.check(s, run, step.Expect, err, &stepReport)
+		stepReport.Passed = len(stepReport.Failures) == 0
+		if !stepReport.Passed {
+			report.Passed = false
+			slog.WarnContext(ctx, "Payment scenario step failed", "scenario", scenario.Name, "step", i+1, "action", step.Action, "failures", stepReport.Failures)
+		}
+		report.Steps = append(report.Steps, stepReport)
+	}
+
+	report.TestData = run.data
+	report.Duration = time.Since(report.StartedAt).String()
+	slog.InfoContext(ctx, "Payment scenario finished", "scenario", scenario.Name, "passed", report.Passed, "duration", report.Duration)
+	return report
+}
+
+// runStep performs one action and returns a short description of what it did
+func (r *ScenarioRunner) runStep(s *PaymentService, run *scenarioRun, step ScenarioStep) (string, error) {
+	if step.Action != ScenarioActionCreatePayment && run.payment == nil {
+		return "", fmt.Errorf("no payment was created")
+	}
+
+	switch step.Action {
+	case ScenarioActionCreatePayment:
+		payment, result, err := s.CreateGamePayment(run.data.UserID, run.data.GameID, run.data.ApplicationID, run.data.OrganizerID, run.scenario.Amount)
+		if err != nil {
+			return "", err
+		}
+		run.payment = payment
+		run.data.PaymentID = payment.ID
+		return fmt.Sprintf("payment intent %s for €%.2f", result.PaymentIntent.ID, payment.Amount+payment.PaymentFee), nil
+
+	case ScenarioActionConfirmPayment:
+		paymentMethod := step.PaymentMethod
+		if paymentMethod == "" {
+			paymentMethod = run.scenario.PaymentMethod
+		}
+		// A declined card leaves the intent unpaid, which confirming then records as a failed payment
+		detail := "paid with " + paymentMethod
+		if _, err := s.stripeService.ConfirmTestPaymentIntent(run.payment.StripePaymentID, paymentMethod); err != nil {
+			detail = fmt.Sprintf("%s declined: %v", paymentMethod, err)
+		}
+		_, escrow, err := s.ConfirmGamePayment(run.payment.ID)
+		if escrow != nil {
+			run.escrow = escrow
+			run.data.EscrowID = escrow.ID
+		}
+		return detail, err
+
+	case ScenarioActionAdvanceTime:
+		run.clock.Advance(step.advanceBy)
+		return "clock at " + run.clock.Now().Format(time.RFC3339), nil
+
+	case ScenarioActionSubmitRatings:
+		escrowID, err := run.escrowID()
+		if err != nil {
+			return "", err
+		}
+		attendees := []string{run.data.UserID}
+		for _, rating := range step.Ratings {
+			attendees = append(attendees, rating.RaterID)
+		}
+		ctx := s.baseContext()
+		if err := s.ratings().RecordAttendance(ctx, run.data.GameID, attendees); err != nil {
+			return "", fmt.Errorf("failed to record attendance: %w", err)
+		}
+		for _, rating := range step.Ratings {
+			if err := s.UpdateEscrowRating(escrowID, rating.Rating, rating.RaterID); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("%d ratings", len(step.Ratings)), nil
+
+	case ScenarioActionRunAutoRelease:
+		// Only the scenario's escrow: its clock may be days ahead, and other escrows in the store
+		// belong to real users
+		escrowID, err := run.escrowID()
+		if err != nil {
+			return "", err
+		}
+		processed, failed, _, err := s.ProcessAutomaticReleaseOf(s.baseContext(), escrowID)
+		return fmt.Sprintf("released %d, failed %d", processed, failed), err
+
+	case ScenarioActionOpenDispute:
+		escrowID, err := run.escrowID()
+		if err != nil {
+			return "", err
+		}
+		dispute, err := s.DisputeReviewedEscrow(escrowID, step.RequestedAction, "scenario_reviewer", step.Notes)
+		if err != nil {
+			return "", err
+		}
+		run.dispute = dispute
+		run.data.DisputeID = dispute.ID
+		return "dispute " + dispute.ID, nil
+
+	case ScenarioActionRefund:
+		escrowID, err := run.escrowID()
+		if err != nil {
+			return "", err
+		}
+		escrow, err := s.RefundReviewedEscrow(escrowID, step.Amount, "scenario_reviewer", step.Notes)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("refunded €%.2f", escrow.RefundedAmount), nil
+	}
+
+	return "", fmt.Errorf("unknown action %q", step.Action)
+}
+
+// escrowID returns the ID of the escrow the scenario's payment created
+func (run *scenarioRun) escrowID() (string, error) {
+	if run.escrow == nil {
+		return "", fmt.Errorf("no escrow was created")
+	}
+	return run.escrow.ID, nil
+}
+
+// check compares the stored state after a step with what the step expects, records the observed
+// statuses on the report and returns the expectations that were not met
+func (r *ScenarioRunner) check(s *PaymentService, run *scenarioRun, expect ScenarioExpect, stepErr error, report *ScenarioStepReport) []string {
+	var failures []string
+
+	switch {
+	case expect.Error == "" && stepErr != nil:
+		failures = append(failures, "unexpected error: "+stepErr.Error())
+	case expect.Error != "" && stepErr == nil:
+		failures = append(failures, fmt.Sprintf("expected an error containing %q", expect.Error))
+	case expect.Error != "" && !strings.Contains(stepErr.Error(), expect.Error):
+		failures = append(failures, fmt.Sprintf("expected an error containing %q, got: %v", expect.Error, stepErr))
+	}
+
+	if run.payment != nil {
+		payment, err := s.getPayment(run.payment.ID)
+		if err != nil {
+			failures = append(failures, "failed to get payment: "+err.Error())
+		} else {
+			run.payment = payment
+			report.PaymentStatus = payment.Status
+		}
+	}
+	if run.escrow != nil {
+		escrow, err := s.getEscrowTransaction(run.escrow.ID)
+		if err != nil {
+			failures = append(failures, "failed to get escrow transaction: "+err.Error())
+		} else {
+			run.escrow = escrow
+			report.EscrowStatus = escrow.Status
+		}
+	}
+	if run.dispute != nil {
+		dispute, err := s.disputes().Get(s.baseContext(), run.dispute.ID)
+		if err != nil {
+			failures = append(failures, "failed to get escrow dispute: "+err.Error())
+		} else {
+			run.dispute = dispute
+			report.DisputeStatus = dispute.Status
+		}
+	}
+
+	if expect.PaymentStatus != "" && report.PaymentStatus != expect.PaymentStatus {
+		failures = append(failures, fmt.Sprintf("payment status: expected %q, got %q", expect.PaymentStatus, report.PaymentStatus))
+	}
+	switch {
+	case expect.EscrowStatus == escrowStatusNone:
+		if run.escrow != nil {
+			failures = append(failures, fmt.Sprintf("escrow status: expected no escrow, got %q", report.EscrowStatus))
+		}
+	case expect.EscrowStatus != "" && report.EscrowStatus != expect.EscrowStatus:
+		failures = append(failures, fmt.Sprintf("escrow status: expected %q, got %q", expect.EscrowStatus, report.EscrowStatus))
+	}
+	if expect.DisputeStatus != "" && report.DisputeStatus != expect.DisputeStatus {
+		failures = append(failures, fmt.Sprintf("dispute status: expected %q, got %q", expect.DisputeStatus, report.DisputeStatus))
+	}
+
+	return failures
+}
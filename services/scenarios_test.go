@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadScenarios(t *testing.T) {
+	t.Run("should load the shipped scenarios", func(t *testing.T) {
+		scenarios, err := Scenarios()
+		require.NoError(t, err)
+
+		var names []string
+		for _, scenario := range scenarios {
+			names = append(names, scenario.Name)
+		}
+		assert.Subset(t, names, []string{"successful_payment", "declined_card", "below_minimum", "rated_release", "poor_rating_dispute"})
+		assert.IsIncreasing(t, names)
+	})
+
+	t.Run("should look up a scenario by name", func(t *testing.T) {
+		scenario, err := LookupScenario("declined_card")
+		require.NoError(t, err)
+		assert.Equal(t, "pm_card_chargeDeclined", scenario.PaymentMethod)
+
+		_, err = LookupScenario("missing")
+		assert.ErrorIs(t, err, ErrUnknownScenario)
+	})
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "invalid JSON",
+			files:   map[string]string{"s/a.json": `{"name": `},
+			wantErr: "scenario s/a.json",
+		},
+		{
+			name:    "missing name",
+			files:   map[string]string{"s/a.json": `{"steps": [{"action": "create_payment"}]}`},
+			wantErr: "name is required",
+		},
+		{
+			name:    "first step is not create_payment",
+			files:   map[string]string{"s/a.json": `{"name": "a", "steps": [{"action": "run_auto_release"}]}`},
+			wantErr: "the first step must be create_payment",
+		},
+		{
+			name:    "unknown action",
+			files:   map[string]string{"s/a.json": `{"name": "a", "steps": [{"action": "create_payment"}, {"action": "teleport"}]}`},
+			wantErr: `step 2: unknown action "teleport"`,
+		},
+		{
+			name:    "invalid duration",
+			files:   map[string]string{"s/a.json": `{"name": "a", "steps": [{"action": "create_payment"}, {"action": "advance_time", "duration": "1 day"}]}`},
+			wantErr: `step 2: invalid duration "1 day"`,
+		},
+		{
+			name:    "confirm without a payment method",
+			files:   map[string]string{"s/a.json": `{"name": "a", "steps": [{"action": "create_payment"}, {"action": "confirm_payment"}]}`},
+			wantErr: "step 2: a payment method is required",
+		},
+		{
+			name:    "invalid dispute action",
+			files:   map[string]string{"s/a.json": `{"name": "a", "steps": [{"action": "create_payment"}, {"action": "open_dispute", "requestedAction": "keep"}]}`},
+			wantErr: `step 2: invalid requested action "keep"`,
+		},
+		{
+			name: "duplicate names",
+			files: map[string]string{
+				"s/a.json": `{"name": "a", "steps": [{"action": "create_payment"}]}`,
+				"s/b.json": `{"name": "a", "steps": [{"action": "create_payment"}]}`,
+			},
+			wantErr: `name "a" is also used by s/a.json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for name, data := range tt.files {
+				fsys[name] = &fstest.MapFile{Data: []byte(data)}
+			}
+
+			_, err := LoadScenarios(fsys, "s")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// newScenarioService returns a payment service that pays through the Stripe stand-in and keeps
+// its records in memory
+func newScenarioService(t *testing.T) *PaymentService {
+	t.Helper()
+	server := useStripeStandIn(t)
+	server.AddAccount(defaultScenarioOrganizer)
+
+	return NewInMemoryPaymentService()
+}
+
+func TestScenarioRunner(t *testing.T) {
+	service := newScenarioService(t)
+	runner := NewScenarioRunner(service)
+
+	scenarios, err := Scenarios()
+	require.NoError(t, err)
+
+	for _, scenario := range scenarios {
+		t.Run("should pass "+scenario.Name, func(t *testing.T) {
+			report := runner.Run(context.Background(), scenario)
+
+			for _, step := range report.Steps {
+				assert.True(t, step.Passed, "step %d %s: error %q, failures %v", step.Step, step.Action, step.Error, step.Failures)
+			}
+			assert.True(t, report.Passed)
+			assert.Len(t, report.Steps, len(scenario.Steps))
+			assert.NotEmpty(t, report.TestData.GameID)
+		})
+	}
+
+	t.Run("should report unmet expectations and skip the remaining steps", func(t *testing.T) {
+		scenario, err := LookupScenario("successful_payment")
+		require.NoError(t, err)
+		scenario.PaymentMethod = "pm_card_chargeDeclined"
+		scenario.Steps = append(scenario.Steps, ScenarioStep{Action: ScenarioActionRunAutoRelease})
+
+		report := runner.Run(context.Background(), scenario)
+
+		assert.False(t, report.Passed)
+		require.Len(t, report.Steps, 3)
+		assert.True(t, report.Steps[0].Passed)
+
+		confirm := report.Steps[1]
+		assert.False(t, confirm.Passed)
+		assert.Contains(t, confirm.Error, "Your card was declined")
+		assert.Equal(t, "failed", confirm.PaymentStatus)
+		assert.Contains(t, confirm.Failures, `payment status: expected "confirmed", got "failed"`)
+		assert.Contains(t, confirm.Failures, `escrow status: expected "held", got ""`)
+
+		assert.True(t, report.Steps[2].Skipped)
+		assert.False(t, report.Steps[2].Passed)
+	})
+
+	t.Run("should leave escrows outside the scenario alone", func(t *testing.T) {
+		// Due for release a day from now, long before the scenario's clock
+		other := &models.EscrowTransaction{
+			ID:                "escrow_other_user",
+			Amount:            20,
+			Status:            models.EscrowStatusHeld,
+			ReleaseEligibleAt: time.Now().Add(24 * time.Hour),
+		}
+		require.NoError(t, service.escrows().Put(context.Background(), other))
+
+		scenario, err := LookupScenario("unrated_release")
+		require.NoError(t, err)
+		report := runner.Run(context.Background(), scenario)
+		require.True(t, report.Passed)
+
+		stored, err := service.escrows().Get(context.Background(), other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.EscrowStatusHeld, stored.Status)
+	})
+}
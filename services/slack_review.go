@@ -0,0 +1,157 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+)
+
+// Slack action IDs of the review buttons; the button value is the escrow ID
+const (
+	SlackActionApproveEscrow = "escrow_review_approve"
+	SlackActionRefundEscrow  = "escrow_review_refund"
+	SlackActionDisputeEscrow = "escrow_review_dispute"
+)
+
+// SlackBlock is a Block Kit layout block
+type SlackBlock struct {
+	Type     string         `json:"type"` // section, actions
+	BlockID  string         `json:"block_id,omitempty"`
+	Text     *SlackText     `json:"text,omitempty"`
+	Fields   []SlackText    `json:"fields,omitempty"`
+	Elements []SlackElement `json:"elements,omitempty"`
+}
+
+// SlackText is a Block Kit text object
+type SlackText struct {
+	Type string `json:"type"` // mrkdwn, plain_text
+	Text string `json:"text"`
+}
+
+// SlackElement is a Block Kit block element, such as a button
+type SlackElement struct {
+	Type     string     `json:"type"` // button
+	Text     *SlackText `json:"text,omitempty"`
+	ActionID string     `json:"action_id,omitempty"`
+	Value    string     `json:"value,omitempty"`
+	Style    string     `json:"style,omitempty"` // primary, danger
+}
+
+func mrkdwn(text string) *SlackText {
+	return &SlackText{Type: "mrkdwn", Text: text}
+}
+
+func slackButton(label, actionID, value, style string) SlackElement {
+	return SlackElement{
+		Type:     "button",
+		Text:     &SlackText{Type: "plain_text", Text: label},
+		ActionID: actionID,
+		Value:    value,
+		Style:    style,
+	}
+}
+
+// reviewAlertMessage builds the manual review alert with Approve, Refund and Dispute buttons
+func reviewAlertMessage(escrow *models.EscrowTransaction) SlackMessage {
+	summary := fmt.Sprintf("Escrow ID: %s\nAmount: €%.2f\nActual Rating: %.1f\nMinimum Required: %.1f",
+		escrow.ID, escrow.Amount, escrow.ActualRating, escrow.MinRatingRequired)
+
+	return SlackMessage{
+		Text: fmt.Sprintf("🚨 *Escrow Manual Review Required*\n\n%s\n\nThis escrow requires manual review due to poor rating.", summary),
+		Blocks: []SlackBlock{
+			{Type: "section", Text: mrkdwn("🚨 *Escrow Manual Review Required*")},
+			{Type: "section", Text: mrkdwn(summary)},
+			{
+				Type:    "actions",
+				BlockID: "escrow_review",
+				Elements: []SlackElement{
+					slackButton("Approve release", SlackActionApproveEscrow, escrow.ID, "primary"),
+					slackButton("Refund", SlackActionRefundEscrow, escrow.ID, "danger"),
+					slackButton("Dispute", SlackActionDisputeEscrow, escrow.ID, ""),
+				},
+			},
+		},
+	}
+}
+
+// DecideReviewFromSlack executes the review decision of a Slack button press on behalf of
+// reviewerID and returns the message that replaces the alert. Refunds from Slack refund the full
+// escrow amount; disputes ask for a refund to be investigated.
+func (s *PaymentService) DecideReviewFromSlack(actionID, escrowID, reviewerID, slackUserID string) (SlackMessage, error) {
+	notes := fmt.Sprintf("Decided in Slack by <@%s>", slackUserID)
+
+	var outcome string
+	switch actionID {
+	case SlackActionApproveEscrow:
+		escrow, err := s.ApproveReviewedEscrow(escrowID, reviewerID, notes)
+		if err != nil {
+			return SlackMessage{}, err
+		}
+		outcome = fmt.Sprintf("✅ Release of €%.2f approved by <@%s>", escrow.Amount, slackUserID)
+	case SlackActionRefundEscrow:
+		escrow, err := s.RefundReviewedEscrow(escrowID, 0, reviewerID, notes)
+		if err != nil {
+			return SlackMessage{}, err
+		}
+		outcome = fmt.Sprintf("↩️ €%.2f refunded by <@%s>", escrow.RefundedAmount, slackUserID)
+	case SlackActionDisputeEscrow:
+		dispute, err := s.DisputeReviewedEscrow(escrowID, models.DisputeActionRefund, reviewerID, notes)
+		if err != nil {
+			return SlackMessage{}, err
+		}
+		outcome = fmt.Sprintf("⚖️ Dispute %s opened by <@%s>", dispute.ID, slackUserID)
+	default:
+		return SlackMessage{}, fmt.Errorf("unknown Slack action: %s", actionID)
+	}
+
+	header := fmt.Sprintf("*Escrow Manual Review: %s*", escrowID)
+	return SlackMessage{
+		Text:            header + "\n" + outcome,
+		ReplaceOriginal: true,
+		Blocks: []SlackBlock{
+			{Type: "section", Text: mrkdwn(header)},
+			{Type: "section", Text: mrkdwn(outcome)},
+		},
+	}, nil
+}
+
+// RespondToSlackAction posts a message to the response_url of a Slack interaction, replacing the
+// original message or, with ResponseType ephemeral, showing it only to the user who acted.
+// Only Slack's own hooks URLs are accepted.
+func (s *PaymentService) RespondToSlackAction(responseURL string, message SlackMessage) error {
+	parsed, err := url.Parse(responseURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host != "hooks.slack.com" {
+		return fmt.Errorf("refusing to respond to non-Slack response URL %q", responseURL)
+	}
+
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack response: %w", err)
+	}
+
+	ctx := s.baseContext()
+	client := &http.Client{Timeout: defaultNotifyTimeout}
+	return s.retry.Do(ctx, "slack.respond_to_action", func() error {
+		return postSlackMessage(ctx, client, jsonData, responseURL)
+	})
+}
+
+// notifyReviewNeeded sends the manual review alert, with buttons to decide it in Slack
+func (s *PaymentService) notifyReviewNeeded(escrow *models.EscrowTransaction) {
+	slog.InfoContext(s.baseContext(), "Sending manual review alert", "escrow_id", escrow.ID, "game_id", escrow.GameID)
+
+	message := reviewAlertMessage(escrow)
+	s.notify(Notification{
+		Event:    EventReviewNeeded,
+		Severity: SeverityWarning,
+		Key:      escrow.ID,
+		Title:    fmt.Sprintf("Escrow %s needs manual review", escrow.ID),
+		Text:     message.Text,
+		Blocks:   message.Blocks,
+		Fields:   map[string]interface{}{"escrowId": escrow.ID, "amount": escrow.Amount, "actualRating": escrow.ActualRating, "minRatingRequired": escrow.MinRatingRequired},
+	})
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewAlertMessage(t *testing.T) {
+	message := reviewAlertMessage(escrowUnderReview("escrow_1"))
+
+	assert.Contains(t, message.Text, "Escrow Manual Review Required")
+	require.Len(t, message.Blocks, 3)
+
+	actions := message.Blocks[2]
+	assert.Equal(t, "actions", actions.Type)
+	require.Len(t, actions.Elements, 3)
+
+	var actionIDs []string
+	for _, button := range actions.Elements {
+		assert.Equal(t, "button", button.Type)
+		assert.Equal(t, "escrow_1", button.Value)
+		actionIDs = append(actionIDs, button.ActionID)
+	}
+	assert.Equal(t, []string{SlackActionApproveEscrow, SlackActionRefundEscrow, SlackActionDisputeEscrow}, actionIDs)
+}
+
+func TestDecideReviewFromSlack(t *testing.T) {
+	testCases := []struct {
+		actionID       string
+		expectedStatus string
+		expectedText   string
+	}{
+		{SlackActionApproveEscrow, models.EscrowStatusReleased, "approved by <@U123>"},
+		{SlackActionRefundEscrow, models.EscrowStatusRefunded, "€24.00 refunded by <@U123>"},
+		{SlackActionDisputeEscrow, models.EscrowStatusDisputed, "opened by <@U123>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.actionID, func(t *testing.T) {
+			service, store, _, _ := newReviewService(escrowUnderReview("escrow_1"))
+
+			message, err := service.DecideReviewFromSlack(tc.actionID, "escrow_1", "admin_1", "U123")
+			require.NoError(t, err)
+			assert.True(t, message.ReplaceOriginal)
+			assert.Contains(t, message.Text, tc.expectedText)
+
+			escrow, _ := store.Get(context.Background(), "escrow_1")
+			assert.Equal(t, tc.expectedStatus, escrow.Status)
+			assert.Equal(t, "admin_1", escrow.ReviewedBy)
+			assert.Equal(t, "Decided in Slack by <@U123>", escrow.ReviewNotes)
+		})
+	}
+
+	t.Run("should not decide an escrow twice", func(t *testing.T) {
+		service, _, _, _ := newReviewService(escrowUnderReview("escrow_1"))
+
+		_, err := service.DecideReviewFromSlack(SlackActionApproveEscrow, "escrow_1", "admin_1", "U123")
+		require.NoError(t, err)
+
+		_, err = service.DecideReviewFromSlack(SlackActionRefundEscrow, "escrow_1", "admin_2", "U456")
+		assert.ErrorIs(t, err, ErrEscrowNotUnderReview)
+	})
+
+	t.Run("should reject unknown actions", func(t *testing.T) {
+		service, _, _, _ := newReviewService(escrowUnderReview("escrow_1"))
+
+		_, err := service.DecideReviewFromSlack("escrow_review_delete", "escrow_1", "admin_1", "U123")
+		assert.Error(t, err)
+	})
+}
+
+func TestRespondToSlackActionRejectsForeignURLs(t *testing.T) {
+	service := &PaymentService{}
+
+	for _, responseURL := range []string{"http://hooks.slack.com/actions/1", "https://example.com/actions/1", "::"} {
+		assert.Error(t, service.RespondToSlackAction(responseURL, SlackMessage{Text: "done"}), responseURL)
+	}
+}
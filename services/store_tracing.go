@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startStoreSpan starts a client span for a Firestore operation
+func startStoreSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", "firestore"))
+	return tracing.Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// tracedEscrowStore traces each call to the wrapped EscrowStore
+type tracedEscrowStore struct {
+	store EscrowStore
+}
+
+func (t tracedEscrowStore) Get(ctx context.Context, escrowID string) (*models.EscrowTransaction, error) {
+	ctx, span := startStoreSpan(ctx, "EscrowStore.Get", tracing.EscrowID(escrowID))
+	escrow, err := t.store.Get(ctx, escrowID)
+	tracing.End(span, err)
+	return escrow, err
+}
+
+func (t tracedEscrowStore) Put(ctx context.Context, escrow *models.EscrowTransaction) error {
+	ctx, span := startStoreSpan(ctx, "EscrowStore.Put", tracing.EscrowID(escrow.ID), tracing.PaymentID(escrow.PaymentID))
+	err := t.store.Put(ctx, escrow)
+	tracing.End(span, err)
+	return err
+}
+
+func (t tracedEscrowStore) Update(ctx context.Context, escrowID string, fn func(escrow *models.EscrowTransaction) error) (*models.EscrowTransaction, error) {
+	ctx, span := startStoreSpan(ctx, "EscrowStore.Update", tracing.EscrowID(escrowID))
+	escrow, err := t.store.Update(ctx, escrowID, fn)
+	tracing.End(span, err)
+	return escrow, err
+}
+
+func (t tracedEscrowStore) ListEligibleForRelease(ctx context.Context, now time.Time, after *models.EscrowTransaction, limit int) ([]*models.EscrowTransaction, error) {
+	ctx, span := startStoreSpan(ctx, "EscrowStore.ListEligibleForRelease", attribute.Int("limit", limit))
+	escrows, err := t.store.ListEligibleForRelease(ctx, now, after, limit)
+	span.SetAttributes(attribute.Int("escrow_count", len(escrows)))
+	tracing.End(span, err)
+	return escrows, err
+}
+
+func (t tracedEscrowStore) ListByStatus(ctx context.Context, status string) ([]*models.EscrowTransaction, error) {
+	ctx, span := startStoreSpan(ctx, "EscrowStore.ListByStatus", attribute.String("status", status))
+	escrows, err := t.store.ListByStatus(ctx, status)
+	span.SetAttributes(attribute.Int("escrow_count", len(escrows)))
+	tracing.End(span, err)
+	return escrows, err
+}
+
+func (t tracedEscrowStore) CountByStatus(ctx context.Context, status string) (int, error) {
+	ctx, span := startStoreSpan(ctx, "EscrowStore.CountByStatus", attribute.String("status", status))
+	count, err := t.store.CountByStatus(ctx, status)
+	tracing.End(span, err)
+	return count, err
+}
+
+// tracedRatingStore traces each call to the wrapped RatingStore
+type tracedRatingStore struct {
+	store RatingStore
+}
+
+func (t tracedRatingStore) PlayersPresent(ctx context.Context, gameID string) ([]string, error) {
+	ctx, span := startStoreSpan(ctx, "RatingStore.PlayersPresent", tracing.GameID(gameID))
+	players, err := t.store.PlayersPresent(ctx, gameID)
+	tracing.End(span, err)
+	return players, err
+}
+
+func (t tracedRatingStore) ListGameRatings(ctx context.Context, gameID, ratedPlayerID string) ([]*models.RatingValidation, error) {
+	ctx, span := startStoreSpan(ctx, "RatingStore.ListGameRatings", tracing.GameID(gameID))
+	ratings, err := t.store.ListGameRatings(ctx, gameID, ratedPlayerID)
+	tracing.End(span, err)
+	return ratings, err
+}
+
+func (t tracedRatingStore) SaveRating(ctx context.Context, rating *models.RatingValidation) error {
+	ctx, span := startStoreSpan(ctx, "RatingStore.SaveRating", tracing.GameID(rating.GameID))
+	err := t.store.SaveRating(ctx, rating)
+	tracing.End(span, err)
+	return err
+}
+
+func (t tracedRatingStore) RecordAttendance(ctx context.Context, gameID string, playerIDs []string) error {
+	ctx, span := startStoreSpan(ctx, "RatingStore.RecordAttendance", tracing.GameID(gameID), attribute.Int("player_count", len(playerIDs)))
+	err := t.store.RecordAttendance(ctx, gameID, playerIDs)
+	tracing.End(span, err)
+	return err
+}
+
+// tracedDisputeStore traces each call to the wrapped DisputeStore
+type tracedDisputeStore struct {
+	store DisputeStore
+}
+
+func (t tracedDisputeStore) Get(ctx context.Context, disputeID string) (*models.EscrowDispute, error) {
+	ctx, span := startStoreSpan(ctx, "DisputeStore.Get", attribute.String("dispute_id", disputeID))
+	dispute, err := t.store.Get(ctx, disputeID)
+	tracing.End(span, err)
+	return dispute, err
+}
+
+func (t tracedDisputeStore) Put(ctx context.Context, dispute *models.EscrowDispute) error {
+	ctx, span := startStoreSpan(ctx, "DisputeStore.Put", attribute.String("dispute_id", dispute.ID), tracing.EscrowID(dispute.EscrowID))
+	err := t.store.Put(ctx, dispute)
+	tracing.End(span, err)
+	return err
+}
+
+// tracedPaymentStore traces each call to the wrapped PaymentStore
+type tracedPaymentStore struct {
+	store PaymentStore
+}
+
+func (t tracedPaymentStore) Get(ctx context.Context, paymentID string) (*models.Payment, error) {
+	ctx, span := startStoreSpan(ctx, "PaymentStore.Get", tracing.PaymentID(paymentID))
+	payment, err := t.store.Get(ctx, paymentID)
+	tracing.End(span, err)
+	return payment, err
+}
+
+func (t tracedPaymentStore) Put(ctx context.Context, payment *models.Payment) error {
+	ctx, span := startStoreSpan(ctx, "PaymentStore.Put", tracing.PaymentID(payment.ID), tracing.GameID(payment.GameID))
+	err := t.store.Put(ctx, payment)
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records finished spans for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) string {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestEscrowReleaseSpans(t *testing.T) {
+	recorder := recordSpans(t)
+
+	escrow := seedReleasableEscrows(1)[0]
+	escrow.PaymentID = "payment_001"
+	service := &PaymentService{
+		escrowStore:   tracedEscrowStore{NewMemoryEscrowStore(escrow)},
+		fundsReleaser: &fakeFundsReleaser{},
+		notifications: &FakeNotifier{},
+	}
+
+	require.NoError(t, service.ProcessEscrowRelease(escrow.ID, "manual"))
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	release, ok := spans["PaymentService.ProcessEscrowRelease"]
+	require.True(t, ok, "release span recorded")
+	assert.Equal(t, escrow.ID, spanAttribute(release, "escrow_id"))
+	assert.Equal(t, "payment_001", spanAttribute(release, "payment_id"))
+
+	for _, name := range []string{"EscrowStore.Update", "firestore.update_escrow"} {
+		child, ok := spans[name]
+		require.True(t, ok, "%s span recorded", name)
+		assert.Equal(t, release.SpanContext().TraceID(), child.SpanContext().TraceID(), "%s in the release trace", name)
+	}
+	assert.Equal(t, "firestore", spanAttribute(spans["EscrowStore.Update"], "db.system"))
+}
+
+func TestFailedReleaseSpanRecordsError(t *testing.T) {
+	recorder := recordSpans(t)
+
+	escrow := seedReleasableEscrows(1)[0]
+	escrow.Status = models.EscrowStatusReleased
+	service := &PaymentService{escrowStore: NewMemoryEscrowStore(escrow), notifications: &FakeNotifier{}}
+
+	assert.Error(t, service.ProcessEscrowRelease(escrow.ID, "manual"))
+
+	var release sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "PaymentService.ProcessEscrowRelease" {
+			release = span
+		}
+	}
+	require.NotNil(t, release, "release span recorded")
+	assert.Equal(t, codes.Error, release.Status().Code)
+	assert.Contains(t, release.Attributes(), attribute.String("escrow_id", escrow.ID))
+}
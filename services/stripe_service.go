@@ -1,17 +1,24 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
-	"os"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/logging"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/tracing"
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/paymentintent"
 	"github.com/stripe/stripe-go/v76/refund"
 	"github.com/stripe/stripe-go/v76/transfer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // StripeConnectService handles Stripe Connect payments with escrow functionality
@@ -19,6 +26,8 @@ type StripeConnectService struct {
 	secretKey       string
 	connectAccount  string
 	testMode        bool
+	retry           *RetryPolicy
+	ctx             context.Context // Carries the request ID added to Stripe metadata
 }
 
 // PaymentResult represents the result of a payment operation
@@ -30,24 +39,94 @@ type PaymentResult struct {
 	Error            string                `json:"error,omitempty"`
 }
 
-// NewStripeConnectService creates a new Stripe Connect service
+// NewStripeConnectService creates a new Stripe Connect service with the request retry policy
 func NewStripeConnectService() *StripeConnectService {
-	secretKey := os.Getenv("STRIPE_SECRET_KEY")
-	if secretKey == "" {
-		log.Printf("⚠️ STRIPE_SECRET_KEY not found, using test key")
-		secretKey = "sk_test_..." // You'll need to replace this with your actual test key
-	}
+	return newStripeConnectService(NewRequestRetryPolicy())
+}
 
-	connectAccount := os.Getenv("STRIPE_CONNECT_ACCOUNT")
-	testMode := os.Getenv("STRIPE_TEST_MODE") != "false"
+func newStripeConnectService(retry *RetryPolicy) *StripeConnectService {
+	conf := config.GetJobsConfig()
+	if conf.StripeSecretKey == "" {
+		slog.Warn("STRIPE_SECRET_KEY not set, Stripe calls will fail")
+	}
 
-	stripe.Key = secretKey
+	stripe.Key = conf.StripeSecretKey
+	useStripeAPIBase(conf.StripeAPIBase)
 
 	return &StripeConnectService{
-		secretKey:      secretKey,
-		connectAccount: connectAccount,
-		testMode:       testMode,
+		secretKey:      conf.StripeSecretKey,
+		connectAccount: conf.StripeConnectAccount,
+		testMode:       conf.StripeTestMode,
+		retry:          retry,
+	}
+}
+
+var (
+	stripeBackendMu         sync.Mutex
+	stripeBackendConfigured bool
+	stripeBackendBase       string // API base the Stripe client currently uses, "" for api.stripe.com
+
+	// stripeLogger sends stripe-go's own log messages through slog
+	stripeLogger = logging.LeveledLogger{Component: "stripe"}
+)
+
+// useStripeAPIBase points the Stripe client at base, e.g. the stripetest stand-in, or back at
+// api.stripe.com when base is empty
+func useStripeAPIBase(base string) {
+	stripeBackendMu.Lock()
+	defer stripeBackendMu.Unlock()
+	if stripeBackendConfigured && base == stripeBackendBase {
+		return
+	}
+
+	url := stripe.APIURL
+	if base != "" {
+		url = base
+		slog.Warn("Stripe API calls go to a stand-in", "stripe_api_base", base)
+	}
+	// stripe-go logs request errors, including their bodies, through its own logger, which would
+	// skip secret masking
+	stripe.DefaultLeveledLogger = stripeLogger
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		URL: stripe.String(url),
+		// RetryPolicy does the retrying, so every attempt it counts is a single request
+		MaxNetworkRetries: stripe.Int64(0),
+		LeveledLogger:     stripeLogger,
+	}))
+	stripeBackendConfigured = true
+	stripeBackendBase = base
+}
+
+// baseContext returns the context set by PaymentService.WithContext
+func (s *StripeConnectService) baseContext() context.Context {
+	if s.ctx == nil {
+		return context.Background()
 	}
+	return s.ctx
+}
+
+// startSpan starts a span for a Stripe operation and returns a copy of the service scoped to it
+func (s *StripeConnectService) startSpan(operation string, attrs ...attribute.KeyValue) (*StripeConnectService, trace.Span) {
+	ctx, span := tracing.Start(s.baseContext(), "StripeConnectService."+operation, attrs...)
+	scoped := *s
+	scoped.ctx = ctx
+	return &scoped, span
+}
+
+// withRequestID returns a copy of metadata with the request ID, when there is one, so a Stripe
+// object can be traced back to the request that created it
+func (s *StripeConnectService) withRequestID(metadata map[string]string) map[string]string {
+	requestID := logging.RequestID(s.baseContext())
+	if requestID == "" {
+		return metadata
+	}
+
+	tagged := make(map[string]string, len(metadata)+1)
+	for key, value := range metadata {
+		tagged[key] = value
+	}
+	tagged[logging.KeyRequestID] = requestID
+	return tagged
 }
 
 // CalculateFees calculates platform and payment processing fees for Stripe
@@ -65,12 +144,15 @@ func (s *StripeConnectService) CalculateFees(amount float64) (platformFee, strip
 }
 
 // CreateEscrowPaymentIntent creates a payment intent with funds held in escrow
-func (s *StripeConnectService) CreateEscrowPaymentIntent(payment *models.Payment, organizerID string) (*PaymentResult, error) {
+func (s *StripeConnectService) CreateEscrowPaymentIntent(payment *models.Payment, organizerID string) (result *PaymentResult, err error) {
 	if payment == nil {
 		return nil, fmt.Errorf("payment cannot be nil")
 	}
+
+	s, span := s.startSpan("CreateEscrowPaymentIntent", tracing.PaymentID(payment.ID), tracing.GameID(payment.GameID))
+	defer func() { tracing.End(span, err) }()
 	
-	log.Printf("[StripeConnect] Creating escrow payment intent for €%.2f", payment.Amount)
+	slog.InfoContext(s.baseContext(), "Creating escrow payment intent", "payment_id", payment.ID, "game_id", payment.GameID, "amount", payment.Amount)
 
 	// Calculate fees
 	platformFee, stripeFee, netAmount := s.CalculateFees(payment.Amount)
@@ -88,14 +170,14 @@ func (s *StripeConnectService) CreateEscrowPaymentIntent(payment *models.Payment
 		TransferData: &stripe.PaymentIntentTransferDataParams{
 			Destination: stripe.String(organizerID), // Organizer's Stripe Connect account
 		},
-		Metadata: map[string]string{
+		Metadata: s.withRequestID(map[string]string{
 			"payment_id":     payment.ID,
 			"game_id":        payment.GameID,
 			"user_id":        payment.UserID,
 			"application_id": payment.ApplicationID,
 			"platform_fee":   fmt.Sprintf("%.2f", platformFee),
 			"net_amount":     fmt.Sprintf("%.2f", netAmount),
-		},
+		}),
 		Description: stripe.String(fmt.Sprintf("GoalHero Game Payment - Game %s", payment.GameID)),
 	}
 
@@ -104,13 +186,21 @@ func (s *StripeConnectService) CreateEscrowPaymentIntent(payment *models.Payment
 		Enabled: stripe.Bool(true),
 	}
 
-	pi, err := paymentintent.New(params)
+	// Same key on every attempt so a retried create never produces a second intent
+	params.SetIdempotencyKey("goalhero-payment-intent-" + payment.ID)
+
+	var pi *stripe.PaymentIntent
+	err = s.retry.Do(s.baseContext(), "stripe.create_payment_intent", func() error {
+		var err error
+		pi, err = paymentintent.New(params)
+		return err
+	})
 	if err != nil {
-		log.Printf("[StripeConnect] Failed to create payment intent: %v", err)
+		slog.ErrorContext(s.baseContext(), "Failed to create payment intent", "payment_id", payment.ID, "error", err)
 		return nil, fmt.Errorf("failed to create payment intent: %w", err)
 	}
 
-	log.Printf("[StripeConnect] Payment intent created: %s", pi.ID)
+	slog.InfoContext(s.baseContext(), "Payment intent created", "payment_id", payment.ID, "payment_intent_id", pi.ID)
 
 	return &PaymentResult{
 		PaymentIntent: pi,
@@ -120,16 +210,24 @@ func (s *StripeConnectService) CreateEscrowPaymentIntent(payment *models.Payment
 }
 
 // ConfirmPaymentIntent confirms a payment intent
-func (s *StripeConnectService) ConfirmPaymentIntent(paymentIntentID string) (*PaymentResult, error) {
-	log.Printf("[StripeConnect] Confirming payment intent: %s", paymentIntentID)
-
-	pi, err := paymentintent.Get(paymentIntentID, nil)
+func (s *StripeConnectService) ConfirmPaymentIntent(paymentIntentID string) (result *PaymentResult, err error) {
+	s, span := s.startSpan("ConfirmPaymentIntent", attribute.String("payment_intent_id", paymentIntentID))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Confirming payment intent", "payment_intent_id", paymentIntentID)
+
+	var pi *stripe.PaymentIntent
+	err = s.retry.Do(s.baseContext(), "stripe.get_payment_intent", func() error {
+		var err error
+		pi, err = paymentintent.Get(paymentIntentID, nil)
+		return err
+	})
 	if err != nil {
-		log.Printf("[StripeConnect] Failed to retrieve payment intent: %v", err)
+		slog.ErrorContext(s.baseContext(), "Failed to retrieve payment intent", "payment_intent_id", paymentIntentID, "error", err)
 		return nil, fmt.Errorf("failed to retrieve payment intent: %w", err)
 	}
 
-	result := &PaymentResult{
+	result = &PaymentResult{
 		PaymentIntent: pi,
 		Status:        string(pi.Status),
 	}
@@ -137,14 +235,52 @@ func (s *StripeConnectService) ConfirmPaymentIntent(paymentIntentID string) (*Pa
 	// Note: Transfer information would be available via separate API calls if needed
 	// For Stripe Connect payments, transfers are handled automatically
 
-	log.Printf("[StripeConnect] Payment intent status: %s", pi.Status)
+	slog.InfoContext(s.baseContext(), "Payment intent status", "payment_intent_id", paymentIntentID, "status", pi.Status)
 
 	return result, nil
 }
 
+// ConfirmTestPaymentIntent pays a payment intent with a Stripe test payment method such as
+// pm_card_visa or pm_card_chargeDeclined, standing in for the card form of the frontend. A
+// declined card returns the Stripe card error. Only available in test mode.
+func (s *StripeConnectService) ConfirmTestPaymentIntent(paymentIntentID, paymentMethod string) (result *PaymentResult, err error) {
+	if !s.testMode {
+		return nil, fmt.Errorf("test payments are only available in test mode")
+	}
+
+	s, span := s.startSpan("ConfirmTestPaymentIntent", attribute.String("payment_intent_id", paymentIntentID), attribute.String("payment_method", paymentMethod))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Paying payment intent with test payment method", "payment_intent_id", paymentIntentID, "payment_method", paymentMethod)
+
+	params := &stripe.PaymentIntentConfirmParams{
+		PaymentMethod: stripe.String(paymentMethod),
+		ReturnURL:     stripe.String(config.GetJobsConfig().MainAPIURL), // Required by Stripe, never used as test cards don't redirect
+	}
+
+	var pi *stripe.PaymentIntent
+	err = s.retry.Do(s.baseContext(), "stripe.confirm_payment_intent", func() error {
+		var err error
+		pi, err = paymentintent.Confirm(paymentIntentID, params)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(s.baseContext(), "Test payment failed", "payment_intent_id", paymentIntentID, "error", err)
+		return nil, fmt.Errorf("failed to pay payment intent: %w", err)
+	}
+
+	return &PaymentResult{
+		PaymentIntent: pi,
+		Status:        string(pi.Status),
+	}, nil
+}
+
 // ReleaseEscrowFunds releases escrowed funds to the organizer
 func (s *StripeConnectService) ReleaseEscrowFunds(escrow *models.EscrowTransaction) error {
-	log.Printf("[StripeConnect] Releasing escrow funds: %s for amount €%.2f", escrow.ID, escrow.Amount)
+	s, span := s.startSpan("ReleaseEscrowFunds", tracing.EscrowID(escrow.ID), tracing.PaymentID(escrow.PaymentID))
+	defer span.End()
+
+	slog.InfoContext(s.baseContext(), "Releasing escrow funds", "escrow_id", escrow.ID, "amount", escrow.Amount)
 
 	// In Stripe Connect, funds are automatically transferred when the payment intent succeeds
 	// If we need additional control, we could use separate transfers
@@ -155,13 +291,26 @@ func (s *StripeConnectService) ReleaseEscrowFunds(escrow *models.EscrowTransacti
 	// 2. Create additional transfers if needed
 	// 3. Handle partial releases
 
-	log.Printf("[StripeConnect] Escrow funds released successfully")
+	slog.InfoContext(s.baseContext(), "Escrow funds released", "escrow_id", escrow.ID)
 	return nil
 }
 
 // CreateRefund creates a refund for a payment
 func (s *StripeConnectService) CreateRefund(paymentIntentID string, amount float64, reason string) (*stripe.Refund, error) {
-	log.Printf("[StripeConnect] Creating refund for payment %s: €%.2f", paymentIntentID, amount)
+	return s.createRefund(paymentIntentID, amount, reason, "goalhero-refund-"+uuid.NewString())
+}
+
+// CreateEscrowRefund creates the refund for an escrow's payment. The idempotency key is scoped to
+// the escrow, so repeating the refund for the same escrow never refunds twice.
+func (s *StripeConnectService) CreateEscrowRefund(escrowID, paymentIntentID string, amount float64, reason string) (*stripe.Refund, error) {
+	return s.createRefund(paymentIntentID, amount, reason, "goalhero-refund-"+escrowID)
+}
+
+func (s *StripeConnectService) createRefund(paymentIntentID string, amount float64, reason, idempotencyKey string) (refundObj *stripe.Refund, err error) {
+	s, span := s.startSpan("CreateRefund", attribute.String("payment_intent_id", paymentIntentID), attribute.Float64("amount", amount))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Creating refund", "payment_intent_id", paymentIntentID, "amount", amount)
 
 	amountCents := int64(math.Round(amount * 100))
 	
@@ -169,29 +318,42 @@ func (s *StripeConnectService) CreateRefund(paymentIntentID string, amount float
 		PaymentIntent: stripe.String(paymentIntentID),
 		Amount:        stripe.Int64(amountCents),
 		Reason:        stripe.String("requested_by_customer"),
-		Metadata: map[string]string{
+		Metadata: s.withRequestID(map[string]string{
 			"refund_reason": reason,
 			"timestamp":     time.Now().Format(time.RFC3339),
-		},
+		}),
 	}
 
-	refundObj, err := refund.New(params)
+	params.SetIdempotencyKey(idempotencyKey)
+
+	err = s.retry.Do(s.baseContext(), "stripe.create_refund", func() error {
+		var err error
+		refundObj, err = refund.New(params)
+		return err
+	})
 	if err != nil {
-		log.Printf("[StripeConnect] Failed to create refund: %v", err)
+		slog.ErrorContext(s.baseContext(), "Failed to create refund", "payment_intent_id", paymentIntentID, "error", err)
 		return nil, fmt.Errorf("failed to create refund: %w", err)
 	}
 
-	log.Printf("[StripeConnect] Refund created successfully: %s", refundObj.ID)
+	slog.InfoContext(s.baseContext(), "Refund created", "payment_intent_id", paymentIntentID, "refund_id", refundObj.ID)
 	return refundObj, nil
 }
 
 // GetPaymentDetails retrieves payment details from Stripe
-func (s *StripeConnectService) GetPaymentDetails(paymentIntentID string) (*stripe.PaymentIntent, error) {
-	log.Printf("[StripeConnect] Retrieving payment details: %s", paymentIntentID)
+func (s *StripeConnectService) GetPaymentDetails(paymentIntentID string) (pi *stripe.PaymentIntent, err error) {
+	s, span := s.startSpan("GetPaymentDetails", attribute.String("payment_intent_id", paymentIntentID))
+	defer func() { tracing.End(span, err) }()
 
-	pi, err := paymentintent.Get(paymentIntentID, nil)
+	slog.DebugContext(s.baseContext(), "Retrieving payment details", "payment_intent_id", paymentIntentID)
+
+	err = s.retry.Do(s.baseContext(), "stripe.get_payment_intent", func() error {
+		var err error
+		pi, err = paymentintent.Get(paymentIntentID, nil)
+		return err
+	})
 	if err != nil {
-		log.Printf("[StripeConnect] Failed to retrieve payment: %v", err)
+		slog.ErrorContext(s.baseContext(), "Failed to retrieve payment", "payment_intent_id", paymentIntentID, "error", err)
 		return nil, fmt.Errorf("failed to retrieve payment: %w", err)
 	}
 
@@ -210,13 +372,16 @@ func (s *StripeConnectService) ValidateConnectAccount(accountID string) error {
 		return fmt.Errorf("invalid connect account ID format")
 	}
 
-	log.Printf("[StripeConnect] Connect account validated: %s", accountID)
+	slog.DebugContext(s.baseContext(), "Connect account validated", "account_id", accountID)
 	return nil
 }
 
 // CreateTransfer creates a manual transfer to a connected account
-func (s *StripeConnectService) CreateTransfer(amount float64, destinationAccount string, metadata map[string]string) (*stripe.Transfer, error) {
-	log.Printf("[StripeConnect] Creating transfer: €%.2f to %s", amount, destinationAccount)
+func (s *StripeConnectService) CreateTransfer(amount float64, destinationAccount string, metadata map[string]string) (transferObj *stripe.Transfer, err error) {
+	s, span := s.startSpan("CreateTransfer", attribute.Float64("amount", amount), attribute.String("destination", destinationAccount))
+	defer func() { tracing.End(span, err) }()
+
+	slog.InfoContext(s.baseContext(), "Creating transfer", "amount", amount, "destination", destinationAccount)
 
 	amountCents := int64(math.Round(amount * 100))
 	
@@ -224,17 +389,23 @@ func (s *StripeConnectService) CreateTransfer(amount float64, destinationAccount
 		Amount:      stripe.Int64(amountCents),
 		Currency:    stripe.String(string(models.DefaultCurrency)),
 		Destination: stripe.String(destinationAccount),
-		Metadata:    metadata,
+		Metadata:    s.withRequestID(metadata),
 	}
 
-	transfer, err := transfer.New(params)
+	params.SetIdempotencyKey("goalhero-transfer-" + uuid.NewString())
+
+	err = s.retry.Do(s.baseContext(), "stripe.create_transfer", func() error {
+		var err error
+		transferObj, err = transfer.New(params)
+		return err
+	})
 	if err != nil {
-		log.Printf("[StripeConnect] Failed to create transfer: %v", err)
+		slog.ErrorContext(s.baseContext(), "Failed to create transfer", "destination", destinationAccount, "error", err)
 		return nil, fmt.Errorf("failed to create transfer: %w", err)
 	}
 
-	log.Printf("[StripeConnect] Transfer created successfully: %s", transfer.ID)
-	return transfer, nil
+	slog.InfoContext(s.baseContext(), "Transfer created", "transfer_id", transferObj.ID, "destination", destinationAccount)
+	return transferObj, nil
 }
 
 // GetTestCardTokens returns test card tokens for testing
@@ -1,12 +1,24 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/config"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/logging"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
+	"github.com/sebastiancaldarola/goalhero-payment-jobs/stripetest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
 )
 
 func TestNewStripeConnectService(t *testing.T) {
@@ -16,11 +28,12 @@ func TestNewStripeConnectService(t *testing.T) {
 		os.Unsetenv("STRIPE_CONNECT_ACCOUNT")
 		os.Unsetenv("STRIPE_TEST_MODE")
 		
+		config.InitJobsConfig()
 		service := NewStripeConnectService()
 		
 		assert.NotNil(t, service)
 		assert.True(t, service.IsTestMode(), "Should default to test mode")
-		assert.NotEmpty(t, service.secretKey, "Should have a secret key")
+		assert.Empty(t, service.secretKey, "Should not fall back to a placeholder key")
 	})
 	
 	t.Run("should use environment variables when provided", func(t *testing.T) {
@@ -31,6 +44,7 @@ func TestNewStripeConnectService(t *testing.T) {
 		os.Setenv("STRIPE_CONNECT_ACCOUNT", testConnectAccount)
 		os.Setenv("STRIPE_TEST_MODE", "true")
 		
+		config.InitJobsConfig()
 		service := NewStripeConnectService()
 		
 		assert.Equal(t, testSecretKey, service.secretKey)
@@ -46,12 +60,14 @@ func TestNewStripeConnectService(t *testing.T) {
 	t.Run("should disable test mode when explicitly set", func(t *testing.T) {
 		os.Setenv("STRIPE_TEST_MODE", "false")
 		
+		config.InitJobsConfig()
 		service := NewStripeConnectService()
 		
 		assert.False(t, service.IsTestMode())
 		
 		// Clean up
 		os.Unsetenv("STRIPE_TEST_MODE")
+		config.InitJobsConfig()
 	})
 }
 
@@ -226,6 +242,7 @@ func TestGetTestCardTokens(t *testing.T) {
 func TestIsTestMode(t *testing.T) {
 	t.Run("should return true when in test mode", func(t *testing.T) {
 		os.Setenv("STRIPE_TEST_MODE", "true")
+		config.InitJobsConfig()
 		service := NewStripeConnectService()
 		
 		assert.True(t, service.IsTestMode())
@@ -235,6 +252,7 @@ func TestIsTestMode(t *testing.T) {
 	
 	t.Run("should return false when explicitly disabled", func(t *testing.T) {
 		os.Setenv("STRIPE_TEST_MODE", "false")
+		config.InitJobsConfig()
 		service := NewStripeConnectService()
 		
 		assert.False(t, service.IsTestMode())
@@ -244,6 +262,7 @@ func TestIsTestMode(t *testing.T) {
 	
 	t.Run("should default to true when not set", func(t *testing.T) {
 		os.Unsetenv("STRIPE_TEST_MODE")
+		config.InitJobsConfig()
 		service := NewStripeConnectService()
 		
 		assert.True(t, service.IsTestMode(), "Should default to test mode")
@@ -259,6 +278,7 @@ func TestStripeServiceIntegration(t *testing.T) {
 	
 	// Ensure we're in test mode
 	os.Setenv("STRIPE_TEST_MODE", "true")
+	config.InitJobsConfig()
 	service := NewStripeConnectService()
 	require.True(t, service.IsTestMode(), "Integration tests must run in test mode")
 	
@@ -385,4 +405,222 @@ func TestStripeServiceConcurrency(t *testing.T) {
 		
 		assert.Equal(t, numGoroutines, successCount, "All concurrent calculations should be correct")
 	})
-}
\ No newline at end of file
+}
+func TestStripeMetadataCarriesRequestID(t *testing.T) {
+	paymentService := &PaymentService{stripeService: &StripeConnectService{retry: NewRetryPolicy()}}
+	metadata := map[string]string{"escrow_id": "escrow_1"}
+
+	assert.Equal(t, metadata, paymentService.stripeService.withRequestID(metadata), "no request ID outside a request")
+
+	scoped := paymentService.WithContext(logging.WithRequestID(context.Background(), "req-1"))
+	tagged := scoped.stripeService.withRequestID(metadata)
+
+	assert.Equal(t, map[string]string{"escrow_id": "escrow_1", "request_id": "req-1"}, tagged)
+	assert.NotContains(t, metadata, "request_id", "caller's metadata must not be modified")
+	assert.Nil(t, paymentService.stripeService.ctx, "WithContext must not modify the original service")
+}
+
+// useStripeStandIn points the Stripe client at a stripetest stand-in until the test ends
+func useStripeStandIn(t *testing.T) *stripetest.Server {
+	t.Helper()
+	server := stripetest.NewServer()
+	t.Cleanup(func() {
+		server.Close()
+		config.InitJobsConfig()
+		NewStripeConnectService() // Points the Stripe client back at api.stripe.com
+	})
+
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test_standin")
+	t.Setenv("STRIPE_TEST_MODE", "true")
+	t.Setenv("STRIPE_API_BASE", server.URL)
+	require.NoError(t, config.InitJobsConfig())
+	return server
+}
+
+func TestStripeServiceAgainstStandIn(t *testing.T) {
+	server := useStripeStandIn(t)
+	server.AddAccount("acct_test_organizer")
+
+	var eventTypes []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		event, err := webhook.ConstructEvent(payload, r.Header.Get("Stripe-Signature"), server.WebhookSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		eventTypes = append(eventTypes, string(event.Type))
+	}))
+	defer endpoint.Close()
+	server.SetWebhookEndpoint(endpoint.URL)
+
+	service := NewStripeConnectService()
+	newPayment := func() *models.Payment {
+		payment := NewTestUtilities().GenerateTestPayment()
+		payment.Amount = 15.0
+		return payment
+	}
+
+	t.Run("should create an escrow payment intent for the organizer", func(t *testing.T) {
+		payment := newPayment()
+		result, err := service.CreateEscrowPaymentIntent(payment, "acct_test_organizer")
+		require.NoError(t, err)
+
+		assert.Equal(t, "requires_payment_method", result.Status)
+		assert.Equal(t, int64(1550), result.PaymentIntent.Amount, "amount includes the Stripe fee")
+		assert.Equal(t, int64(60), result.PaymentIntent.ApplicationFeeAmount)
+		assert.Equal(t, "acct_test_organizer", result.PaymentIntent.TransferData.Destination.ID)
+		assert.Equal(t, payment.ID, result.PaymentIntent.Metadata["payment_id"])
+		assert.NotEmpty(t, result.ClientSecret)
+
+		retried, err := service.CreateEscrowPaymentIntent(payment, "acct_test_organizer")
+		require.NoError(t, err)
+		assert.Equal(t, result.PaymentIntent.ID, retried.PaymentIntent.ID, "idempotency key prevents a second intent")
+	})
+
+	t.Run("should reject an unknown destination account", func(t *testing.T) {
+		_, err := service.CreateEscrowPaymentIntent(newPayment(), "acct_unknown_organizer")
+		assert.ErrorContains(t, err, "No such destination")
+	})
+
+	t.Run("should pay and partially refund a payment intent", func(t *testing.T) {
+		result, err := service.CreateEscrowPaymentIntent(newPayment(), "acct_test_organizer")
+		require.NoError(t, err)
+
+		paid, err := service.ConfirmTestPaymentIntent(result.PaymentIntent.ID, "pm_card_visa")
+		require.NoError(t, err)
+		assert.Equal(t, "succeeded", paid.Status)
+
+		pi, err := service.GetPaymentDetails(result.PaymentIntent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1550), pi.AmountReceived)
+
+		refund, err := service.CreateRefund(pi.ID, 5.0, "player cancelled")
+		require.NoError(t, err)
+		assert.Equal(t, int64(500), refund.Amount)
+		assert.Equal(t, stripe.RefundStatusSucceeded, refund.Status)
+
+		_, err = service.CreateRefund(pi.ID, 15.0, "player cancelled")
+		assert.ErrorContains(t, err, "greater than unrefunded amount")
+	})
+
+	t.Run("should refund an escrow once", func(t *testing.T) {
+		result, err := service.CreateEscrowPaymentIntent(newPayment(), "acct_test_organizer")
+		require.NoError(t, err)
+		_, err = service.ConfirmTestPaymentIntent(result.PaymentIntent.ID, "pm_card_visa")
+		require.NoError(t, err)
+
+		escrowID := "escrow_" + result.PaymentIntent.ID
+		refund, err := service.CreateEscrowRefund(escrowID, result.PaymentIntent.ID, 5.0, "manual_review: game cancelled")
+		require.NoError(t, err)
+
+		retried, err := service.CreateEscrowRefund(escrowID, result.PaymentIntent.ID, 5.0, "manual_review: game cancelled")
+		require.NoError(t, err)
+		assert.Equal(t, refund.ID, retried.ID, "idempotency key prevents a second refund")
+	})
+
+	t.Run("should leave a declined payment intent unpaid", func(t *testing.T) {
+		result, err := service.CreateEscrowPaymentIntent(newPayment(), "acct_test_organizer")
+		require.NoError(t, err)
+
+		_, err = service.ConfirmTestPaymentIntent(result.PaymentIntent.ID, "pm_card_chargeDeclinedInsufficientFunds")
+		var stripeErr *stripe.Error
+		require.ErrorAs(t, err, &stripeErr)
+		assert.Equal(t, stripe.DeclineCodeInsufficientFunds, stripeErr.DeclineCode)
+
+		confirmed, err := service.ConfirmPaymentIntent(result.PaymentIntent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "requires_payment_method", confirmed.Status)
+		assert.Equal(t, stripe.ErrorCodeCardDeclined, confirmed.PaymentIntent.LastPaymentError.Code)
+
+		_, err = service.CreateRefund(result.PaymentIntent.ID, 5.0, "nothing to refund")
+		assert.ErrorContains(t, err, "does not have a successful charge")
+	})
+
+	t.Run("should transfer to connected accounts only", func(t *testing.T) {
+		transfer, err := service.CreateTransfer(10.0, "acct_test_organizer", map[string]string{"escrow_id": "escrow_1"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), transfer.Amount)
+		assert.Equal(t, "acct_test_organizer", transfer.Destination.ID)
+
+		_, err = service.CreateTransfer(10.0, "acct_unknown_organizer", nil)
+		assert.ErrorContains(t, err, "No such destination")
+	})
+
+	t.Run("should pass the readiness check", func(t *testing.T) {
+		defer func() { stripeKeyVerified = make(map[string]time.Time) }()
+		_, err := checkStripe(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("should deliver signed webhooks for every change", func(t *testing.T) {
+		assert.Empty(t, server.DeliveryErrors())
+		assert.Equal(t, []string{
+			"payment_intent.created",
+			"payment_intent.created",
+			"payment_intent.succeeded",
+			"refund.created",
+			"payment_intent.created",
+			"payment_intent.succeeded",
+			"refund.created",
+			"payment_intent.created",
+			"payment_intent.payment_failed",
+			"transfer.created",
+		}, eventTypes)
+		assert.Len(t, server.Events(), len(eventTypes))
+	})
+}
+
+func TestStripeClientLeavesRetriesToRetryPolicy(t *testing.T) {
+	requests := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{"error":{"type":"api_error","message":"unavailable"}}`)
+	}))
+	t.Cleanup(func() {
+		api.Close()
+		config.InitJobsConfig()
+		NewStripeConnectService()
+	})
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test_standin")
+	t.Setenv("STRIPE_API_BASE", api.URL)
+	require.NoError(t, config.InitJobsConfig())
+
+	policy, _ := newTestRetryPolicy(1)
+	service := newStripeConnectService(policy)
+
+	_, err := service.GetPaymentDetails("pi_unavailable")
+
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests, "one request per RetryPolicy attempt")
+	assert.Equal(t, OperationAttempts{Calls: 1, Attempts: 2, Failures: 1}, policy.Stats()["stripe.get_payment_intent"])
+}
+
+func TestStripeClientLogsThroughMaskingHandler(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":{"type":"invalid_request_error","message":"No such payment_intent: pi_3abc_secret_xyz123"}}`)
+	}))
+	previous := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		api.Close()
+		config.InitJobsConfig()
+		NewStripeConnectService()
+	})
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(logging.NewHandler(&buf, "json", slog.LevelInfo)))
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test_standin")
+	t.Setenv("STRIPE_API_BASE", api.URL)
+	require.NoError(t, config.InitJobsConfig())
+
+	policy, _ := newTestRetryPolicy(0)
+	_, err := newStripeConnectService(policy).GetPaymentDetails("pi_3abc")
+	require.Error(t, err)
+
+	assert.Contains(t, buf.String(), `"component":"stripe"`, "stripe-go logs go through slog")
+	assert.NotContains(t, buf.String(), "xyz123")
+}
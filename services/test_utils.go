@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"math/rand"
+	"slices"
 	"time"
 
+	"cloud.google.com/go/firestore"
 	"github.com/sebastiancaldarola/goalhero-payment-jobs/models"
 )
 
@@ -281,15 +285,21 @@ func abs(x float64) float64 {
 
 // TestDataCleanup provides cleanup utilities for tests
 type TestDataCleanup struct {
-	testPaymentIDs []string
-	testEscrowIDs  []string
+	testPaymentIDs     []string
+	testEscrowIDs      []string
+	testMatchIDs       []string
+	testRatingIDs      []string
+	testCheckpointKeys []string
 }
 
 // NewTestDataCleanup creates a new cleanup utility
 func NewTestDataCleanup() *TestDataCleanup {
 	return &TestDataCleanup{
-		testPaymentIDs: make([]string, 0),
-		testEscrowIDs:  make([]string, 0),
+		testPaymentIDs:     make([]string, 0),
+		testEscrowIDs:      make([]string, 0),
+		testMatchIDs:       make([]string, 0),
+		testRatingIDs:      make([]string, 0),
+		testCheckpointKeys: make([]string, 0),
 	}
 }
 
@@ -303,6 +313,21 @@ func (tdc *TestDataCleanup) AddEscrowID(escrowID string) {
 	tdc.testEscrowIDs = append(tdc.testEscrowIDs, escrowID)
 }
 
+// AddMatchID adds a match ID for cleanup
+func (tdc *TestDataCleanup) AddMatchID(matchID string) {
+	tdc.testMatchIDs = append(tdc.testMatchIDs, matchID)
+}
+
+// AddRatingID adds a rating validation ID for cleanup
+func (tdc *TestDataCleanup) AddRatingID(ratingID string) {
+	tdc.testRatingIDs = append(tdc.testRatingIDs, ratingID)
+}
+
+// AddCheckpointKey adds the job key of a job checkpoint for cleanup
+func (tdc *TestDataCleanup) AddCheckpointKey(jobKey string) {
+	tdc.testCheckpointKeys = append(tdc.testCheckpointKeys, jobKey)
+}
+
 // GetPaymentIDs returns all registered payment IDs
 func (tdc *TestDataCleanup) GetPaymentIDs() []string {
 	return tdc.testPaymentIDs
@@ -313,18 +338,59 @@ func (tdc *TestDataCleanup) GetEscrowIDs() []string {
 	return tdc.testEscrowIDs
 }
 
+// GetMatchIDs returns all registered match IDs
+func (tdc *TestDataCleanup) GetMatchIDs() []string {
+	return tdc.testMatchIDs
+}
+
+// GetRatingIDs returns all registered rating validation IDs
+func (tdc *TestDataCleanup) GetRatingIDs() []string {
+	return tdc.testRatingIDs
+}
+
 // Clear removes all registered IDs
 func (tdc *TestDataCleanup) Clear() {
 	tdc.testPaymentIDs = make([]string, 0)
 	tdc.testEscrowIDs = make([]string, 0)
+	tdc.testMatchIDs = make([]string, 0)
+	tdc.testRatingIDs = make([]string, 0)
+	tdc.testCheckpointKeys = make([]string, 0)
+}
+
+// DeleteFromFirestore deletes every registered document and clears the registrations. It keeps
+// going past failed deletes and returns the first error, so one bad document does not leave the
+// rest of the test data behind.
+func (tdc *TestDataCleanup) DeleteFromFirestore(ctx context.Context, client *firestore.Client) error {
+	collections := []struct {
+		name string
+		ids  []string
+	}{
+		{"payments", tdc.testPaymentIDs},
+		{"escrow_transactions", tdc.testEscrowIDs},
+		{"matches", tdc.testMatchIDs},
+		{"rating_validations", tdc.testRatingIDs},
+		{"job_checkpoints", tdc.testCheckpointKeys},
+	}
+
+	var firstErr error
+	for _, collection := range collections {
+		for _, id := range collection.ids {
+			if _, err := client.Collection(collection.name).Doc(id).Delete(ctx); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("failed to delete %s/%s: %w", collection.name, id, err)
+			}
+		}
+	}
+
+	tdc.Clear()
+	return firstErr
 }
 
 // PerformanceTestConfig defines configuration for performance tests
 type PerformanceTestConfig struct {
-	ConcurrentUsers    int
-	PaymentsPerUser    int
-	TestDurationSeconds int
-	MaxAcceptableLatencyMs int64
+	ConcurrentUsers        int   `json:"concurrentUsers"`
+	PaymentsPerUser        int   `json:"paymentsPerUser"`
+	TestDurationSeconds    int   `json:"testDurationSeconds"`
+	MaxAcceptableLatencyMs int64 `json:"maxAcceptableLatencyMs"`
 }
 
 // GetDefaultPerformanceConfig returns default performance test configuration
@@ -339,14 +405,18 @@ func (tu *TestUtilities) GetDefaultPerformanceConfig() *PerformanceTestConfig {
 
 // LoadTestResult represents the result of a load test
 type LoadTestResult struct {
-	TotalRequests     int
-	SuccessfulRequests int
-	FailedRequests    int
-	AverageLatencyMs  int64
-	MaxLatencyMs      int64
-	MinLatencyMs      int64
-	RequestsPerSecond float64
-	ErrorRate         float64
+	TotalRequests      int     `json:"totalRequests"`
+	SuccessfulRequests int     `json:"successfulRequests"`
+	FailedRequests     int     `json:"failedRequests"`
+	AverageLatencyMs   int64   `json:"averageLatencyMs"`
+	MaxLatencyMs       int64   `json:"maxLatencyMs"`
+	MinLatencyMs       int64   `json:"minLatencyMs"`
+	P50LatencyMs       int64   `json:"p50LatencyMs"`
+	P90LatencyMs       int64   `json:"p90LatencyMs"`
+	P95LatencyMs       int64   `json:"p95LatencyMs"`
+	P99LatencyMs       int64   `json:"p99LatencyMs"`
+	RequestsPerSecond  float64 `json:"requestsPerSecond"`
+	ErrorRate          float64 `json:"errorRate"` // Percentage of failed requests
 }
 
 // CalculateLoadTestMetrics calculates metrics from load test results
@@ -363,23 +433,21 @@ func (tu *TestUtilities) CalculateLoadTestMetrics(
 	}
 	
 	if len(latencies) > 0 {
-		var totalLatency int64
-		result.MinLatencyMs = latencies[0].Milliseconds()
-		result.MaxLatencyMs = latencies[0].Milliseconds()
-		
-		for _, latency := range latencies {
-			ms := latency.Milliseconds()
-			totalLatency += ms
-			
-			if ms < result.MinLatencyMs {
-				result.MinLatencyMs = ms
-			}
-			if ms > result.MaxLatencyMs {
-				result.MaxLatencyMs = ms
-			}
+		sorted := slices.Clone(latencies)
+		slices.Sort(sorted)
+
+		var totalLatency time.Duration
+		for _, latency := range sorted {
+			totalLatency += latency
 		}
-		
-		result.AverageLatencyMs = totalLatency / int64(len(latencies))
+
+		result.MinLatencyMs = sorted[0].Milliseconds()
+		result.MaxLatencyMs = sorted[len(sorted)-1].Milliseconds()
+		result.AverageLatencyMs = (totalLatency / time.Duration(len(sorted))).Milliseconds()
+		result.P50LatencyMs = latencyPercentile(sorted, 50).Milliseconds()
+		result.P90LatencyMs = latencyPercentile(sorted, 90).Milliseconds()
+		result.P95LatencyMs = latencyPercentile(sorted, 95).Milliseconds()
+		result.P99LatencyMs = latencyPercentile(sorted, 99).Milliseconds()
 	}
 	
 	if testDuration.Seconds() > 0 {
@@ -391,4 +459,13 @@ func (tu *TestUtilities) CalculateLoadTestMetrics(
 	}
 	
 	return result
-}
\ No newline at end of file
+}
+
+// latencyPercentile returns the nearest-rank percentile of latencies, which must be sorted
+func latencyPercentile(sorted []time.Duration, percentile float64) time.Duration {
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
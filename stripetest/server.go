@@ -0,0 +1,656 @@
+// Package stripetest provides an in-process stand-in for the subset of the Stripe API the payment
+// jobs use, so payment flows can be tested end to end without Stripe keys or network access.
+//
+// Point the service at it by setting STRIPE_API_BASE to Server.URL and STRIPE_SECRET_KEY to any
+// test key. The stand-in keeps payment intents, refunds, transfers and connected accounts in
+// memory, and sends webhook events signed with WebhookSecret like Stripe does.
+package stripetest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
+)
+
+// minimumChargeCents is the smallest amount Stripe charges in EUR
+const minimumChargeCents = 50
+
+// decline describes how a test payment method fails; a zero decline means the payment succeeds
+type decline struct {
+	code        stripe.ErrorCode
+	declineCode stripe.DeclineCode
+	message     string
+}
+
+// testPaymentMethods are the Stripe test payment methods the stand-in understands
+var testPaymentMethods = map[string]decline{
+	"pm_card_visa":       {},
+	"pm_card_visa_debit": {},
+	"pm_card_mastercard": {},
+	"pm_card_amex":       {},
+	"pm_card_chargeDeclined": {
+		code: stripe.ErrorCodeCardDeclined, declineCode: stripe.DeclineCodeGenericDecline,
+		message: "Your card was declined.",
+	},
+	"pm_card_chargeDeclinedInsufficientFunds": {
+		code: stripe.ErrorCodeCardDeclined, declineCode: stripe.DeclineCodeInsufficientFunds,
+		message: "Your card has insufficient funds.",
+	},
+	"pm_card_chargeDeclinedExpiredCard": {
+		code: stripe.ErrorCodeExpiredCard, message: "Your card has expired.",
+	},
+	"pm_card_chargeDeclinedIncorrectCvc": {
+		code: stripe.ErrorCodeIncorrectCVC, message: "Your card's security code is incorrect.",
+	},
+	"pm_card_chargeDeclinedProcessingError": {
+		code: stripe.ErrorCodeProcessingError, message: "An error occurred while processing your card. Try again in a little bit.",
+	},
+}
+
+// Server is a Stripe API stand-in. Create one with NewServer for tests, or with New to serve it
+// on an address of your choice.
+type Server struct {
+	URL           string        // Base URL to use as STRIPE_API_BASE; set by NewServer
+	WebhookSecret string        // Secret the webhook events are signed with
+	Latency       time.Duration // Added to every response to approximate the round trip to Stripe
+
+	mux        *http.ServeMux
+	httpServer *httptest.Server
+	client     *http.Client
+
+	mu             sync.Mutex
+	webhookURL     string
+	paymentIntents map[string]*stripe.PaymentIntent
+	refunds        map[string]*stripe.Refund
+	transfers      map[string]*stripe.Transfer
+	accounts       map[string]*stripe.Account
+	idempotent     map[string]recordedResponse // Idempotency-Key -> first response
+	events         []stripe.Event
+	deliveryErrors []error
+}
+
+// recordedResponse is a response replayed for a request retried with the same idempotency key
+type recordedResponse struct {
+	status int
+	body   []byte
+}
+
+// New returns a stand-in that is not listening yet; serve it with http.ListenAndServe
+func New() *Server {
+	s := &Server{
+		WebhookSecret:  "whsec_" + randomID(),
+		client:         &http.Client{Timeout: 5 * time.Second},
+		paymentIntents: make(map[string]*stripe.PaymentIntent),
+		refunds:        make(map[string]*stripe.Refund),
+		transfers:      make(map[string]*stripe.Transfer),
+		accounts:       make(map[string]*stripe.Account),
+		idempotent:     make(map[string]recordedResponse),
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("POST /v1/payment_intents", s.createPaymentIntent)
+	s.mux.HandleFunc("GET /v1/payment_intents/{id}", s.getPaymentIntent)
+	s.mux.HandleFunc("POST /v1/payment_intents/{id}/confirm", s.confirmPaymentIntent)
+	s.mux.HandleFunc("POST /v1/refunds", s.createRefund)
+	s.mux.HandleFunc("POST /v1/transfers", s.createTransfer)
+	s.mux.HandleFunc("POST /v1/accounts", s.createAccount)
+	s.mux.HandleFunc("GET /v1/accounts/{id}", s.getAccount)
+	s.mux.HandleFunc("GET /v1/balance", s.getBalance)
+	return s
+}
+
+// NewServer starts a stand-in on a local port. Close it when the test is done.
+func NewServer() *Server {
+	s := New()
+	s.httpServer = httptest.NewServer(s)
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close stops a server started with NewServer
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// SetWebhookEndpoint makes the stand-in POST every event to url; empty stops delivery
+func (s *Server) SetWebhookEndpoint(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhookURL = url
+}
+
+// AddAccount registers a connected account, e.g. an organizer ID a test uses as destination
+func (s *Server) AddAccount(accountID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[accountID] = newAccount(accountID, stripe.AccountTypeExpress, "ES", "", nil)
+}
+
+// Events returns the events emitted so far, oldest first
+func (s *Server) Events() []stripe.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stripe.Event(nil), s.events...)
+}
+
+// DeliveryErrors returns the webhook deliveries that failed or were not acknowledged with a 2xx
+func (s *Server) DeliveryErrors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.deliveryErrors...)
+}
+
+// ServeHTTP checks the API key and idempotency key, then routes the request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Latency > 0 {
+		time.Sleep(s.Latency)
+	}
+
+	if err := checkAPIKey(r); err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	key := r.Header.Get("Idempotency-Key")
+	if r.Method != http.MethodPost || key == "" {
+		s.route(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	replay, seen := s.idempotent[key]
+	s.mu.Unlock()
+	if seen {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(replay.status)
+		w.Write(replay.body)
+		return
+	}
+
+	recorder := httptest.NewRecorder()
+	s.route(recorder, r)
+	// Like Stripe, only keep results the request itself decided; server errors may be retried
+	if recorder.Code < http.StatusInternalServerError {
+		s.mu.Lock()
+		s.idempotent[key] = recordedResponse{status: recorder.Code, body: recorder.Body.Bytes()}
+		s.mu.Unlock()
+	}
+	for name, values := range recorder.Header() {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(recorder.Code)
+	w.Write(recorder.Body.Bytes())
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := s.mux.Handler(r); pattern == "" {
+		writeError(w, http.StatusNotFound, &stripe.Error{
+			Type: stripe.ErrorTypeInvalidRequest,
+			Msg:  fmt.Sprintf("Unrecognized request URL (%s: %s).", r.Method, r.URL.Path),
+		})
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// checkAPIKey accepts any test key, as the stand-in must never be mistaken for live Stripe
+func checkAPIKey(r *http.Request) *stripe.Error {
+	key, _, ok := r.BasicAuth()
+	if !ok {
+		key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+
+	switch {
+	case key == "":
+		return &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, Msg: "You did not provide an API key."}
+	case !strings.HasPrefix(key, "sk_test_") && !strings.HasPrefix(key, "rk_test_"):
+		return &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, Msg: "The Stripe stand-in only accepts test keys."}
+	}
+	return nil
+}
+
+func (s *Server) createPaymentIntent(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	amount, err := formAmount(r, "amount")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if amount < minimumChargeCents {
+		writeError(w, http.StatusBadRequest, &stripe.Error{
+			Type: stripe.ErrorTypeInvalidRequest, Code: stripe.ErrorCodeAmountTooSmall, Param: "amount",
+			Msg: fmt.Sprintf("Amount must be at least %d cents.", minimumChargeCents),
+		})
+		return
+	}
+	currency := strings.ToLower(r.PostForm.Get("currency"))
+	if currency == "" {
+		writeError(w, http.StatusBadRequest, missingParam("currency"))
+		return
+	}
+
+	id := "pi_" + randomID()
+	pi := &stripe.PaymentIntent{
+		ID:           id,
+		Object:       "payment_intent",
+		Amount:       amount,
+		Currency:     stripe.Currency(currency),
+		ClientSecret: id + "_secret_" + randomID(),
+		Created:      time.Now().Unix(),
+		Description:  r.PostForm.Get("description"),
+		Metadata:     formMetadata(r),
+		Status:       stripe.PaymentIntentStatusRequiresPaymentMethod,
+	}
+	if fee := r.PostForm.Get("application_fee_amount"); fee != "" {
+		pi.ApplicationFeeAmount, _ = strconv.ParseInt(fee, 10, 64)
+	}
+	if paymentMethod := r.PostForm.Get("payment_method"); paymentMethod != "" {
+		pi.PaymentMethod = &stripe.PaymentMethod{ID: paymentMethod}
+		pi.Status = stripe.PaymentIntentStatusRequiresConfirmation
+	}
+
+	s.mu.Lock()
+	if destination := r.PostForm.Get("transfer_data[destination]"); destination != "" {
+		account, exists := s.accounts[destination]
+		if !exists {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, noSuch("destination", destination, "transfer_data[destination]"))
+			return
+		}
+		pi.TransferData = &stripe.PaymentIntentTransferData{Destination: account}
+	}
+	s.paymentIntents[id] = pi
+	body := mustMarshal(pi)
+	s.mu.Unlock()
+
+	s.emit("payment_intent.created", body)
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) getPaymentIntent(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pi, exists := s.paymentIntents[r.PathValue("id")]
+	if !exists {
+		writeError(w, http.StatusNotFound, noSuch("payment_intent", r.PathValue("id"), "intent"))
+		return
+	}
+	writeJSON(w, http.StatusOK, mustMarshal(pi))
+}
+
+// confirmPaymentIntent charges the payment method, as the frontend does with a real card
+func (s *Server) confirmPaymentIntent(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	s.mu.Lock()
+	pi, exists := s.paymentIntents[r.PathValue("id")]
+	if !exists {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, noSuch("payment_intent", r.PathValue("id"), "intent"))
+		return
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresPaymentMethod && pi.Status != stripe.PaymentIntentStatusRequiresConfirmation {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, &stripe.Error{
+			Type: stripe.ErrorTypeInvalidRequest, Code: stripe.ErrorCodePaymentIntentUnexpectedState,
+			Msg: fmt.Sprintf("This PaymentIntent's status is %s, so it cannot be confirmed.", pi.Status),
+		})
+		return
+	}
+
+	paymentMethod := r.PostForm.Get("payment_method")
+	if paymentMethod == "" && pi.PaymentMethod != nil {
+		paymentMethod = pi.PaymentMethod.ID
+	}
+	outcome, known := testPaymentMethods[paymentMethod]
+	if !known {
+		s.mu.Unlock()
+		if paymentMethod == "" {
+			writeError(w, http.StatusBadRequest, missingParam("payment_method"))
+		} else {
+			writeError(w, http.StatusBadRequest, noSuch("PaymentMethod", paymentMethod, "payment_method"))
+		}
+		return
+	}
+
+	pi.PaymentMethod = &stripe.PaymentMethod{ID: paymentMethod}
+	if outcome.code != "" {
+		pi.Status = stripe.PaymentIntentStatusRequiresPaymentMethod
+		pi.LastPaymentError = &stripe.Error{
+			Type: stripe.ErrorTypeCard, Code: outcome.code, DeclineCode: outcome.declineCode, Msg: outcome.message,
+		}
+		body := mustMarshal(pi)
+		cardErr := *pi.LastPaymentError
+		cardErr.PaymentIntent = pi
+		errBody := mustMarshal(map[string]interface{}{"error": &cardErr})
+		s.mu.Unlock()
+
+		s.emit("payment_intent.payment_failed", body)
+		writeJSON(w, http.StatusPaymentRequired, errBody)
+		return
+	}
+
+	pi.Status = stripe.PaymentIntentStatusSucceeded
+	pi.AmountReceived = pi.Amount
+	pi.LastPaymentError = nil
+	pi.LatestCharge = &stripe.Charge{ID: "ch_" + randomID()}
+	body := mustMarshal(pi)
+	s.mu.Unlock()
+
+	s.emit("payment_intent.succeeded", body)
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) createRefund(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	s.mu.Lock()
+	pi, exists := s.paymentIntents[r.PostForm.Get("payment_intent")]
+	if !exists {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, noSuch("payment_intent", r.PostForm.Get("payment_intent"), "payment_intent"))
+		return
+	}
+	if pi.Status != stripe.PaymentIntentStatusSucceeded {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, &stripe.Error{
+			Type: stripe.ErrorTypeInvalidRequest, Code: stripe.ErrorCodeChargeNotRefundable,
+			Msg: fmt.Sprintf("This PaymentIntent (%s) does not have a successful charge to refund.", pi.ID),
+		})
+		return
+	}
+
+	remaining := pi.AmountReceived - s.refundedLocked(pi.ID)
+	amount := remaining
+	if r.PostForm.Has("amount") {
+		var amountErr *stripe.Error
+		if amount, amountErr = formAmount(r, "amount"); amountErr != nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, amountErr)
+			return
+		}
+	}
+	if amount > remaining {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, &stripe.Error{
+			Type: stripe.ErrorTypeInvalidRequest, Code: stripe.ErrorCodeAmountTooLarge, Param: "amount",
+			Msg: fmt.Sprintf("Refund amount (%d) is greater than unrefunded amount on charge (%d)", amount, remaining),
+		})
+		return
+	}
+
+	refund := &stripe.Refund{
+		ID:            "re_" + randomID(),
+		Object:        "refund",
+		Amount:        amount,
+		Currency:      pi.Currency,
+		Created:       time.Now().Unix(),
+		Metadata:      formMetadata(r),
+		PaymentIntent: &stripe.PaymentIntent{ID: pi.ID},
+		Reason:        stripe.RefundReason(r.PostForm.Get("reason")),
+		Status:        stripe.RefundStatusSucceeded,
+	}
+	s.refunds[refund.ID] = refund
+	body := mustMarshal(refund)
+	s.mu.Unlock()
+
+	s.emit("refund.created", body)
+	writeJSON(w, http.StatusOK, body)
+}
+
+// refundedLocked returns how much of the payment intent has been refunded; s.mu must be held
+func (s *Server) refundedLocked(paymentIntentID string) int64 {
+	var refunded int64
+	for _, refund := range s.refunds {
+		if refund.PaymentIntent.ID == paymentIntentID {
+			refunded += refund.Amount
+		}
+	}
+	return refunded
+}
+
+func (s *Server) createTransfer(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	amount, err := formAmount(r, "amount")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	currency := strings.ToLower(r.PostForm.Get("currency"))
+	if currency == "" {
+		writeError(w, http.StatusBadRequest, missingParam("currency"))
+		return
+	}
+
+	s.mu.Lock()
+	destination := r.PostForm.Get("destination")
+	account, exists := s.accounts[destination]
+	if !exists {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, noSuch("destination", destination, "destination"))
+		return
+	}
+
+	transfer := &stripe.Transfer{
+		ID:          "tr_" + randomID(),
+		Object:      "transfer",
+		Amount:      amount,
+		Currency:    stripe.Currency(currency),
+		Created:     time.Now().Unix(),
+		Destination: account,
+		Metadata:    formMetadata(r),
+	}
+	s.transfers[transfer.ID] = transfer
+	body := mustMarshal(transfer)
+	s.mu.Unlock()
+
+	s.emit("transfer.created", body)
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) createAccount(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	accountType := stripe.AccountType(r.PostForm.Get("type"))
+	if accountType == "" {
+		accountType = stripe.AccountTypeExpress
+	}
+	account := newAccount("acct_"+randomID(), accountType, r.PostForm.Get("country"), r.PostForm.Get("email"), formMetadata(r))
+
+	s.mu.Lock()
+	s.accounts[account.ID] = account
+	body := mustMarshal(account)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, exists := s.accounts[r.PathValue("id")]
+	if !exists {
+		writeError(w, http.StatusNotFound, noSuch("account", r.PathValue("id"), "account"))
+		return
+	}
+	writeJSON(w, http.StatusOK, mustMarshal(account))
+}
+
+// getBalance reports what was charged minus refunds and transfers, which is enough for the
+// readiness check to see a working key
+func (s *Server) getBalance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var available int64
+	for _, pi := range s.paymentIntents {
+		available += pi.AmountReceived
+	}
+	for _, refund := range s.refunds {
+		available -= refund.Amount
+	}
+	for _, transfer := range s.transfers {
+		available -= transfer.Amount
+	}
+
+	writeJSON(w, http.StatusOK, mustMarshal(&stripe.Balance{
+		Object:    "balance",
+		Available: []*stripe.Amount{{Amount: available, Currency: stripe.CurrencyEUR}},
+		Pending:   []*stripe.Amount{},
+	}))
+}
+
+// emit records an event for object and, when an endpoint is set, delivers it signed with
+// WebhookSecret. Delivery happens before the API call returns, so tests can assert on it right away.
+func (s *Server) emit(eventType string, object []byte) {
+	payload := mustMarshal(map[string]interface{}{
+		"id":               "evt_" + randomID(),
+		"object":           "event",
+		"api_version":      stripe.APIVersion,
+		"created":          time.Now().Unix(),
+		"livemode":         false,
+		"pending_webhooks": 1,
+		"type":             eventType,
+		"data":             map[string]json.RawMessage{"object": object},
+	})
+
+	var event stripe.Event
+	json.Unmarshal(payload, &event)
+
+	s.mu.Lock()
+	s.events = append(s.events, event)
+	webhookURL := s.webhookURL
+	s.mu.Unlock()
+
+	if webhookURL == "" {
+		return
+	}
+	if err := s.deliver(webhookURL, payload); err != nil {
+		s.mu.Lock()
+		s.deliveryErrors = append(s.deliveryErrors, fmt.Errorf("%s %s: %w", eventType, event.ID, err))
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) deliver(webhookURL string, payload []byte) error {
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload:   payload,
+		Secret:    s.WebhookSecret,
+		Timestamp: time.Now(),
+	})
+
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", signed.Header)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func newAccount(id string, accountType stripe.AccountType, country, email string, metadata map[string]string) *stripe.Account {
+	return &stripe.Account{
+		ID:             id,
+		Object:         "account",
+		Type:           accountType,
+		Country:        country,
+		Email:          email,
+		Metadata:       metadata,
+		ChargesEnabled: true,
+		PayoutsEnabled: true,
+		Created:        time.Now().Unix(),
+	}
+}
+
+// formAmount parses a required positive amount in cents
+func formAmount(r *http.Request, param string) (int64, *stripe.Error) {
+	value := r.PostForm.Get(param)
+	if value == "" {
+		return 0, missingParam(param)
+	}
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, &stripe.Error{
+			Type: stripe.ErrorTypeInvalidRequest, Code: stripe.ErrorCodeParameterInvalidInteger, Param: param,
+			Msg: fmt.Sprintf("Invalid positive integer: %s", value),
+		}
+	}
+	return amount, nil
+}
+
+// formMetadata collects the metadata[key]=value parameters
+func formMetadata(r *http.Request) map[string]string {
+	metadata := make(map[string]string)
+	for key, values := range r.PostForm {
+		if name, found := strings.CutPrefix(key, "metadata["); found && strings.HasSuffix(name, "]") && len(values) > 0 {
+			metadata[strings.TrimSuffix(name, "]")] = values[0]
+		}
+	}
+	return metadata
+}
+
+func missingParam(param string) *stripe.Error {
+	return &stripe.Error{
+		Type: stripe.ErrorTypeInvalidRequest, Code: stripe.ErrorCodeParameterMissing, Param: param,
+		Msg: "Missing required param: " + param + ".",
+	}
+}
+
+func noSuch(object, id, param string) *stripe.Error {
+	return &stripe.Error{
+		Type: stripe.ErrorTypeInvalidRequest, Code: stripe.ErrorCodeResourceMissing, Param: param,
+		Msg: fmt.Sprintf("No such %s: '%s'", object, id),
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, stripeErr *stripe.Error) {
+	writeJSON(w, status, mustMarshal(map[string]interface{}{"error": stripeErr}))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Request-Id", "req_"+randomID())
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func mustMarshal(value interface{}) []byte {
+	body, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Sprintf("stripetest: %v", err))
+	}
+	return body
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
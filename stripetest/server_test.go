@@ -0,0 +1,121 @@
+package stripetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76"
+)
+
+func request(t *testing.T, server *Server, method, path, key string, form url.Values, headers map[string]string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp, body
+}
+
+func TestServerAuthentication(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"missing key", "", http.StatusUnauthorized},
+		{"live key", "sk_live_123", http.StatusUnauthorized},
+		{"test key", "sk_test_123", http.StatusOK},
+		{"restricted test key", "rk_test_123", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := request(t, server, http.MethodGet, "/v1/balance", tt.key, nil, nil)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestServerIdempotency(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	form := url.Values{"amount": {"1550"}, "currency": {"eur"}}
+	headers := map[string]string{"Idempotency-Key": "payment-1"}
+
+	first, created := request(t, server, http.MethodPost, "/v1/payment_intents", "sk_test_123", form, headers)
+	require.Equal(t, http.StatusOK, first.StatusCode)
+
+	replayed, again := request(t, server, http.MethodPost, "/v1/payment_intents", "sk_test_123", form, headers)
+	assert.Equal(t, http.StatusOK, replayed.StatusCode)
+	assert.Equal(t, "true", replayed.Header.Get("Idempotent-Replayed"))
+	assert.Equal(t, created["id"], again["id"])
+	assert.Len(t, server.Events(), 1, "a replay emits no new events")
+}
+
+func TestServerErrors(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		form   url.Values
+		status int
+		code   stripe.ErrorCode
+	}{
+		{"unknown route", http.MethodGet, "/v1/charges", nil, http.StatusNotFound, ""},
+		{"unknown payment intent", http.MethodGet, "/v1/payment_intents/pi_missing", nil, http.StatusNotFound, stripe.ErrorCodeResourceMissing},
+		{"amount below the minimum charge", http.MethodPost, "/v1/payment_intents", url.Values{"amount": {"10"}, "currency": {"eur"}}, http.StatusBadRequest, stripe.ErrorCodeAmountTooSmall},
+		{"missing currency", http.MethodPost, "/v1/payment_intents", url.Values{"amount": {"1550"}}, http.StatusBadRequest, stripe.ErrorCodeParameterMissing},
+		{"negative transfer", http.MethodPost, "/v1/transfers", url.Values{"amount": {"-100"}, "currency": {"eur"}}, http.StatusBadRequest, stripe.ErrorCodeParameterInvalidInteger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := request(t, server, tt.method, tt.path, "sk_test_123", tt.form, nil)
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			stripeErr, ok := body["error"].(map[string]interface{})
+			require.True(t, ok, "Stripe error envelope")
+			assert.Equal(t, string(stripe.ErrorTypeInvalidRequest), stripeErr["type"])
+			if tt.code != "" {
+				assert.Equal(t, string(tt.code), stripeErr["code"])
+			}
+		})
+	}
+}
+
+func TestServerConnectedAccounts(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	resp, account := request(t, server, http.MethodPost, "/v1/accounts", "sk_test_123", url.Values{"country": {"ES"}, "email": {"organizer@example.com"}}, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "express", account["type"])
+
+	resp, fetched := request(t, server, http.MethodGet, "/v1/accounts/"+account["id"].(string), "sk_test_123", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "organizer@example.com", fetched["email"])
+}
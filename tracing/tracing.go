@@ -0,0 +1,113 @@
+// Package tracing sets up OpenTelemetry tracing and provides the helpers used to start spans
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/sebastiancaldarola/goalhero-payment-jobs"
+
+// Trace exporters selectable with OTEL_TRACES_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Span attribute keys, matching the log field names
+const (
+	KeyPaymentID = "payment_id"
+	KeyEscrowID  = "escrow_id"
+	KeyGameID    = "game_id"
+	KeyJobName   = "job_name"
+	KeyRunID     = "run_id"
+)
+
+// Config selects the exporter and sampling for Setup
+type Config struct {
+	Exporter    string  // none, otlp or stdout
+	ServiceName string  // Reported as service.name
+	SampleRatio float64 // Fraction of new traces recorded; traces started upstream follow the caller
+}
+
+// Setup installs the global tracer provider and W3C trace context propagation. The OTLP exporter
+// sends to OTEL_EXPORTER_OTLP_ENDPOINT (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) over HTTP, with
+// headers from OTEL_EXPORTER_OTLP_HEADERS. The returned function flushes pending spans.
+func Setup(ctx context.Context, conf Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch conf.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q (want %s, %s or %s)", conf.Exporter, ExporterNone, ExporterOTLP, ExporterStdout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", conf.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", conf.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the service's tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span as a child of the span in ctx, if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// PaymentID returns the payment_id span attribute
+func PaymentID(paymentID string) attribute.KeyValue {
+	return attribute.String(KeyPaymentID, paymentID)
+}
+
+// EscrowID returns the escrow_id span attribute
+func EscrowID(escrowID string) attribute.KeyValue {
+	return attribute.String(KeyEscrowID, escrowID)
+}
+
+// GameID returns the game_id span attribute
+func GameID(gameID string) attribute.KeyValue {
+	return attribute.String(KeyGameID, gameID)
+}
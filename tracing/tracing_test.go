@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Config{Exporter: "jaeger"})
+	assert.ErrorContains(t, err, `unknown trace exporter "jaeger"`)
+}
+
+func TestEndRecordsErrors(t *testing.T) {
+	recorder := recordSpans(t)
+
+	_, span := Start(context.Background(), "ok", EscrowID("escrow_1"))
+	End(span, nil)
+	_, span = Start(context.Background(), "failed", PaymentID("payment_1"))
+	End(span, errors.New("card declined"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String(KeyEscrowID, "escrow_1"))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "card declined", spans[1].Status().Description)
+}
+
+func TestGinMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+	router.GET("/api/jobs/runs/:runId", func(c *gin.Context) {
+		_, span := Start(c.Request.Context(), "handler")
+		span.End()
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs/runs/run_1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	handler, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /api/jobs/runs/:runId", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(), "continues the caller's trace")
+	assert.Equal(t, server.SpanContext().SpanID(), handler.Parent().SpanID())
+	assert.Contains(t, server.Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+	assert.Equal(t, codes.Error, server.Status().Code)
+}